@@ -0,0 +1,228 @@
+package ddbretry
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// BackOffStrategy selects how the delay before each retry is calculated from
+// BackOffTime.
+type BackOffStrategy int
+
+const (
+	// ConstantBackOff sleeps for BackOffTime before every retry.
+	ConstantBackOff BackOffStrategy = iota
+	// FullJitterBackOff sleeps for a random duration in
+	// [0, BackOffTime*2^(attempt-1)], as described in the AWS Architecture
+	// Blog post "Exponential Backoff And Jitter".
+	FullJitterBackOff
+	// EqualJitterBackOff sleeps for half of BackOffTime*2^(attempt-1) plus a
+	// random duration in [0, BackOffTime*2^(attempt-1)/2], guaranteeing a
+	// minimum delay while still spreading retries out.
+	EqualJitterBackOff
+	// DecorrelatedJitterBackOff sleeps for a random duration in
+	// [BackOffTime, previous delay*3], so each delay is derived from the one
+	// before it rather than from the attempt number.
+	DecorrelatedJitterBackOff
+	// ExponentialBackOff sleeps for exactly BackOffTime*2^(attempt-1).
+	ExponentialBackOff
+)
+
+var backOffStrategyNames = [...]string{
+	ConstantBackOff:           "constant",
+	FullJitterBackOff:         "full-jitter",
+	EqualJitterBackOff:        "equal-jitter",
+	DecorrelatedJitterBackOff: "decorrelated-jitter",
+	ExponentialBackOff:        "exponential",
+}
+
+func (s BackOffStrategy) String() string {
+	if s < 0 || int(s) >= len(backOffStrategyNames) {
+		return "unknown"
+	}
+
+	return backOffStrategyNames[s]
+}
+
+// ParseBackOffStrategy returns the strategy with the given name, as returned
+// by BackOffStrategy.String, or an InvalidConfigError if there is none.
+func ParseBackOffStrategy(name string) (BackOffStrategy, error) {
+	s, ok := parseBackOffStrategy(name)
+	if !ok {
+		return 0, NewInvalidConfigError("BackOffStrategy", fmt.Sprintf("unknown strategy %q", name))
+	}
+
+	return s, nil
+}
+
+func parseBackOffStrategy(name string) (BackOffStrategy, bool) {
+	for s, n := range backOffStrategyNames {
+		if strings.EqualFold(name, n) {
+			return BackOffStrategy(s), true
+		}
+	}
+
+	return 0, false
+}
+
+// BackOff calculates the delay before a retry. Implementations can be set on
+// RetryDynamoDBClient to replace the built-in strategies, and take precedence
+// over BackOffFunc.
+type BackOff interface {
+	// NextDelay returns the delay to apply before the given retry attempt,
+	// where attempt is 1 for the first retry and err is the error returned by
+	// the attempt that failed.
+	NextDelay(attempt int, err error) time.Duration
+}
+
+const maxDuration = time.Duration(math.MaxInt64)
+
+// backOff returns the delay to apply before the given retry attempt, where
+// attempt is 1 for the first retry, prev is the delay applied before the
+// previous retry and err is the error that triggered the retry.
+//
+// ImmediateRetry makes the first retry without delay and starts backing off
+// from the second, and InitialBackOff, when set, replaces the delay before
+// the first retry that does back off.
+func (p *RetryPolicy) backOff(attempt int, prev time.Duration, err error) time.Duration {
+	if p.ImmediateRetry {
+		if attempt <= 1 {
+			return 0
+		}
+		attempt--
+	}
+	if attempt == 1 && p.InitialBackOff > 0 {
+		return p.capBackOff(p.InitialBackOff)
+	}
+
+	if p.BackOff != nil {
+		return p.capBackOff(p.BackOff.NextDelay(attempt, err))
+	}
+	if p.BackOffFunc != nil {
+		return p.capBackOff(p.BackOffFunc(attempt))
+	}
+
+	switch p.BackOffStrategy {
+	case FullJitterBackOff:
+		return p.Rand.jitter(p.capBackOff(exponential(p.BackOffTime, attempt)))
+	case EqualJitterBackOff:
+		d := p.capBackOff(exponential(p.BackOffTime, attempt))
+		return d/2 + p.Rand.jitter(d-d/2)
+	case DecorrelatedJitterBackOff:
+		if prev < p.BackOffTime {
+			prev = p.BackOffTime
+		}
+		upper := maxDuration
+		if prev <= maxDuration/3 {
+			upper = prev * 3
+		}
+		return p.capBackOff(p.BackOffTime + p.Rand.jitter(upper-p.BackOffTime))
+	case ExponentialBackOff:
+		return p.capBackOff(exponential(p.BackOffTime, attempt))
+	default:
+		return p.capBackOff(p.BackOffTime)
+	}
+}
+
+// capBackOff limits d to MaxBackOff, if one is set.
+func (p *RetryPolicy) capBackOff(d time.Duration) time.Duration {
+	if p.MaxBackOff > 0 && d > p.MaxBackOff {
+		return p.MaxBackOff
+	}
+
+	return d
+}
+
+// exponential returns base*2^(attempt-1), saturating at maxDuration rather
+// than overflowing.
+func exponential(base time.Duration, attempt int) time.Duration {
+	if base <= 0 {
+		return 0
+	}
+
+	shift := attempt - 1
+	if shift < 0 {
+		shift = 0
+	}
+	if shift >= 63 || base > maxDuration>>shift {
+		return maxDuration
+	}
+
+	return base << shift
+}
+
+// saturatingMul returns d*n, saturating at maxDuration rather than
+// overflowing.
+func saturatingMul(d time.Duration, n int64) time.Duration {
+	if d > 0 && n > 0 && d > maxDuration/time.Duration(n) {
+		return maxDuration
+	}
+
+	return d * time.Duration(n)
+}
+
+// jitter returns a random duration in [0, d], drawn from r.
+func (r *Rand) jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	if d == maxDuration {
+		return time.Duration(r.int63n(0))
+	}
+
+	return time.Duration(r.int63n(int64(d) + 1))
+}
+
+// retryAfter extracts the delay requested by the service through a
+// Retry-After response header, if err carries one.
+func retryAfter(err error, clock Clock) (time.Duration, bool) {
+	var responseError *smithyhttp.ResponseError
+	if !errors.As(err, &responseError) || responseError.Response == nil || responseError.Response.Response == nil {
+		return 0, false
+	}
+
+	value := strings.TrimSpace(responseError.Response.Header.Get("Retry-After"))
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		d := t.Sub(clock.Now())
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+
+	return 0, false
+}
+
+func (s BackOffStrategy) MarshalText() ([]byte, error) {
+	if s < 0 || int(s) >= len(backOffStrategyNames) {
+		return nil, NewInvalidConfigError("BackOffStrategy", fmt.Sprintf("unknown strategy %d", int(s)))
+	}
+
+	return []byte(s.String()), nil
+}
+
+func (s *BackOffStrategy) UnmarshalText(text []byte) error {
+	parsed, err := ParseBackOffStrategy(string(text))
+	if err != nil {
+		return err
+	}
+	*s = parsed
+
+	return nil
+}
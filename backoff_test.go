@@ -0,0 +1,396 @@
+package ddbretry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry/ddbretrytest"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+)
+
+type linearBackOff struct {
+	step time.Duration
+}
+
+func (b *linearBackOff) NextDelay(attempt int, err error) time.Duration {
+	return time.Duration(attempt) * b.step
+}
+
+func TestExponential(t *testing.T) {
+	type args struct {
+		base    time.Duration
+		attempt int
+	}
+	tests := []struct {
+		name string
+		args args
+		want time.Duration
+	}{
+		{
+			name: "should return base for first attempt",
+			args: args{
+				base:    time.Second,
+				attempt: 1,
+			},
+			want: time.Second,
+		},
+		{
+			name: "should double base for each subsequent attempt",
+			args: args{
+				base:    time.Second,
+				attempt: 4,
+			},
+			want: 8 * time.Second,
+		},
+		{
+			name: "should return zero when base is zero",
+			args: args{
+				base:    0,
+				attempt: 10,
+			},
+			want: 0,
+		},
+		{
+			name: "should saturate instead of overflowing",
+			args: args{
+				base:    time.Second,
+				attempt: 1000,
+			},
+			want: maxDuration,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, exponential(tt.args.base, tt.args.attempt))
+		})
+	}
+}
+
+func TestRetryPolicy_backOff(t *testing.T) {
+	type fields struct {
+		BackOffTime     time.Duration
+		BackOffStrategy BackOffStrategy
+		MaxBackOff      time.Duration
+		BackOff         BackOff
+		BackOffFunc     func(attempt int) time.Duration
+		InitialBackOff  time.Duration
+		ImmediateRetry  bool
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		attempt int
+		prev    time.Duration
+		wantMin time.Duration
+		wantMax time.Duration
+	}{
+		{
+			name: "should return BackOffTime for constant strategy",
+			fields: fields{
+				BackOffTime:     100 * time.Millisecond,
+				BackOffStrategy: ConstantBackOff,
+			},
+			attempt: 5,
+			wantMin: 100 * time.Millisecond,
+			wantMax: 100 * time.Millisecond,
+		},
+		{
+			name: "should return delay within exponential window for full jitter strategy",
+			fields: fields{
+				BackOffTime:     100 * time.Millisecond,
+				BackOffStrategy: FullJitterBackOff,
+			},
+			attempt: 3,
+			wantMin: 0,
+			wantMax: 400 * time.Millisecond,
+		},
+		{
+			name: "should return delay within upper half of exponential window for equal jitter strategy",
+			fields: fields{
+				BackOffTime:     100 * time.Millisecond,
+				BackOffStrategy: EqualJitterBackOff,
+			},
+			attempt: 3,
+			wantMin: 200 * time.Millisecond,
+			wantMax: 400 * time.Millisecond,
+		},
+		{
+			name: "should return delay between BackOffTime and three times BackOffTime for first decorrelated jitter retry",
+			fields: fields{
+				BackOffTime:     100 * time.Millisecond,
+				BackOffStrategy: DecorrelatedJitterBackOff,
+			},
+			attempt: 1,
+			wantMin: 100 * time.Millisecond,
+			wantMax: 300 * time.Millisecond,
+		},
+		{
+			name: "should return delay between BackOffTime and three times previous delay for decorrelated jitter strategy",
+			fields: fields{
+				BackOffTime:     100 * time.Millisecond,
+				BackOffStrategy: DecorrelatedJitterBackOff,
+			},
+			attempt: 4,
+			prev:    time.Second,
+			wantMin: 100 * time.Millisecond,
+			wantMax: 3 * time.Second,
+		},
+		{
+			name: "should double BackOffTime with every attempt for exponential strategy",
+			fields: fields{
+				BackOffTime:     100 * time.Millisecond,
+				BackOffStrategy: ExponentialBackOff,
+			},
+			attempt: 3,
+			wantMin: 400 * time.Millisecond,
+			wantMax: 400 * time.Millisecond,
+		},
+		{
+			name: "should cap exponential strategy at MaxBackOff",
+			fields: fields{
+				BackOffTime:     100 * time.Millisecond,
+				BackOffStrategy: ExponentialBackOff,
+				MaxBackOff:      time.Second,
+			},
+			attempt: 1000,
+			wantMin: time.Second,
+			wantMax: time.Second,
+		},
+		{
+			name: "should cap constant strategy at MaxBackOff",
+			fields: fields{
+				BackOffTime:     time.Second,
+				BackOffStrategy: ConstantBackOff,
+				MaxBackOff:      500 * time.Millisecond,
+			},
+			attempt: 1,
+			wantMin: 500 * time.Millisecond,
+			wantMax: 500 * time.Millisecond,
+		},
+		{
+			name: "should cap full jitter window at MaxBackOff",
+			fields: fields{
+				BackOffTime:     100 * time.Millisecond,
+				BackOffStrategy: FullJitterBackOff,
+				MaxBackOff:      time.Second,
+			},
+			attempt: 1000,
+			wantMin: 0,
+			wantMax: time.Second,
+		},
+		{
+			name: "should cap equal jitter window at MaxBackOff",
+			fields: fields{
+				BackOffTime:     100 * time.Millisecond,
+				BackOffStrategy: EqualJitterBackOff,
+				MaxBackOff:      time.Second,
+			},
+			attempt: 1000,
+			wantMin: 500 * time.Millisecond,
+			wantMax: time.Second,
+		},
+		{
+			name: "should cap decorrelated jitter at MaxBackOff",
+			fields: fields{
+				BackOffTime:     100 * time.Millisecond,
+				BackOffStrategy: DecorrelatedJitterBackOff,
+				MaxBackOff:      time.Second,
+			},
+			attempt: 10,
+			prev:    time.Hour,
+			wantMin: 100 * time.Millisecond,
+			wantMax: time.Second,
+		},
+		{
+			name: "should use BackOff implementation instead of strategy when set",
+			fields: fields{
+				BackOffTime:     time.Second,
+				BackOffStrategy: ConstantBackOff,
+				BackOff:         &linearBackOff{step: 10 * time.Millisecond},
+			},
+			attempt: 3,
+			wantMin: 30 * time.Millisecond,
+			wantMax: 30 * time.Millisecond,
+		},
+		{
+			name: "should cap BackOff implementation at MaxBackOff",
+			fields: fields{
+				BackOff:    &linearBackOff{step: time.Second},
+				MaxBackOff: 2 * time.Second,
+			},
+			attempt: 3,
+			wantMin: 2 * time.Second,
+			wantMax: 2 * time.Second,
+		},
+		{
+			name: "should use BackOffFunc instead of BackOffTime when set",
+			fields: fields{
+				BackOffTime: time.Second,
+				BackOffFunc: func(attempt int) time.Duration {
+					return time.Duration(attempt) * time.Millisecond
+				},
+			},
+			attempt: 7,
+			wantMin: 7 * time.Millisecond,
+			wantMax: 7 * time.Millisecond,
+		},
+		{
+			name: "should prefer BackOff implementation over BackOffFunc",
+			fields: fields{
+				BackOff: &linearBackOff{step: 10 * time.Millisecond},
+				BackOffFunc: func(attempt int) time.Duration {
+					return time.Hour
+				},
+			},
+			attempt: 2,
+			wantMin: 20 * time.Millisecond,
+			wantMax: 20 * time.Millisecond,
+		},
+		{
+			name: "should use InitialBackOff before first retry",
+			fields: fields{
+				BackOffTime:    time.Second,
+				InitialBackOff: 10 * time.Millisecond,
+			},
+			attempt: 1,
+			wantMin: 10 * time.Millisecond,
+			wantMax: 10 * time.Millisecond,
+		},
+		{
+			name: "should use strategy after first retry when InitialBackOff is set",
+			fields: fields{
+				BackOffTime:     time.Second,
+				BackOffStrategy: EqualJitterBackOff,
+				InitialBackOff:  10 * time.Millisecond,
+			},
+			attempt: 2,
+			wantMin: time.Second,
+			wantMax: 2 * time.Second,
+		},
+		{
+			name: "should retry immediately once when ImmediateRetry is set",
+			fields: fields{
+				BackOffTime:    time.Second,
+				ImmediateRetry: true,
+			},
+			attempt: 1,
+			wantMin: 0,
+			wantMax: 0,
+		},
+		{
+			name: "should start backing off from second retry when ImmediateRetry is set",
+			fields: fields{
+				BackOffTime:     100 * time.Millisecond,
+				BackOffStrategy: EqualJitterBackOff,
+				ImmediateRetry:  true,
+			},
+			attempt: 2,
+			wantMin: 50 * time.Millisecond,
+			wantMax: 100 * time.Millisecond,
+		},
+		{
+			name: "should use InitialBackOff after the immediate retry",
+			fields: fields{
+				BackOffTime:    time.Second,
+				InitialBackOff: 10 * time.Millisecond,
+				ImmediateRetry: true,
+			},
+			attempt: 2,
+			wantMin: 10 * time.Millisecond,
+			wantMax: 10 * time.Millisecond,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &RetryPolicy{
+				BackOffTime:     tt.fields.BackOffTime,
+				BackOffStrategy: tt.fields.BackOffStrategy,
+				MaxBackOff:      tt.fields.MaxBackOff,
+				BackOff:         tt.fields.BackOff,
+				BackOffFunc:     tt.fields.BackOffFunc,
+				InitialBackOff:  tt.fields.InitialBackOff,
+				ImmediateRetry:  tt.fields.ImmediateRetry,
+			}
+
+			for i := 0; i < 100; i++ {
+				got := p.backOff(tt.attempt, tt.prev, nil)
+				assert.GreaterOrEqual(t, got, tt.wantMin)
+				assert.LessOrEqual(t, got, tt.wantMax)
+			}
+		})
+	}
+}
+
+type recordingBackOff struct {
+	attempts []int
+	errs     []error
+}
+
+func (b *recordingBackOff) NextDelay(attempt int, err error) time.Duration {
+	b.attempts = append(b.attempts, attempt)
+	b.errs = append(b.errs, err)
+
+	return 0
+}
+
+func TestRetryDynamoDBClient_BackOff(t *testing.T) {
+	backOff := &recordingBackOff{}
+	c := &RetryDynamoDBClient{
+		DynamoDBClient: ddbretrytest.NewFakeClient().Script("GetItem", ddbretrytest.Throttles(3)...),
+		Retries:        3,
+		BackOff:        backOff,
+	}
+
+	_, err := c.GetItem(context.Background(), &ddb.GetItemInput{})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, backOff.attempts)
+	for _, err := range backOff.errs {
+		assert.True(t, IsProvisionedThroughputExceededException(err))
+	}
+}
+
+func TestParseBackOffStrategy(t *testing.T) {
+	for _, s := range []BackOffStrategy{ConstantBackOff, FullJitterBackOff, EqualJitterBackOff, DecorrelatedJitterBackOff, ExponentialBackOff} {
+		got, err := ParseBackOffStrategy(s.String())
+		assert.NoError(t, err)
+		assert.Equal(t, s, got)
+	}
+
+	_, err := ParseBackOffStrategy("linear")
+	assert.True(t, IsInvalidConfigError(err))
+	assert.Equal(t, "unknown", BackOffStrategy(-1).String())
+}
+
+func TestRetryDynamoDBClient_Rand(t *testing.T) {
+	schedule := func(seed int64) []time.Duration {
+		clock := ddbretrytest.NewFakeClock(time.Unix(1700000000, 0))
+		c := &RetryDynamoDBClient{
+			DynamoDBClient:  ddbretrytest.NewFakeClient().Script("GetItem", ddbretrytest.Throttles(5)...),
+			Retries:         5,
+			BackOffTime:     time.Second,
+			BackOffStrategy: FullJitterBackOff,
+			Rand:            NewSeededRand(seed),
+			Clock:           clock,
+		}
+
+		_, err := c.GetItem(context.Background(), &ddb.GetItemInput{})
+		assert.NoError(t, err)
+
+		return clock.Sleeps()
+	}
+
+	assert.Equal(t, schedule(1), schedule(1))
+	assert.NotEqual(t, schedule(1), schedule(2))
+
+	policy := RetryPolicy{
+		BackOffTime:     time.Second,
+		BackOffStrategy: DecorrelatedJitterBackOff,
+	}
+	a, b := policy, policy
+	a.Rand, b.Rand = NewSeededRand(1), NewSeededRand(1)
+	for attempt := 1; attempt <= 5; attempt++ {
+		assert.Equal(t, a.Delay(attempt, time.Second, nil), b.Delay(attempt, time.Second, nil))
+	}
+}
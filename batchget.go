@@ -0,0 +1,211 @@
+package ddbretry
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// MaxBatchGetItems is the largest number of keys DynamoDB accepts in a single
+// BatchGetItem call.
+const MaxBatchGetItems = 100
+
+type batchGetChunk struct {
+	table string
+	keys  []map[string]types.AttributeValue
+}
+
+// ParallelBatchGet reads the items with the given keys, grouped by table,
+// with BatchGetItem calls of up to MaxBatchGetItems keys sent from up to
+// workers goroutines, and returns the items found grouped by table.
+// Duplicate keys are read once. Unprocessed keys and chunks failing with
+// errors the policy retries are sent again after the policy's backoff until
+// its retries run out. While any chunk is backing off after being throttled,
+// the other workers hold off sending theirs, so that a throttled table is
+// not flooded with more requests.
+//
+// If any chunk fails with an error the policy does not retry, the remaining
+// chunks are cancelled and ParallelBatchGet returns the error. Keys left
+// unread once the retries run out are returned in an UnprocessedKeysError.
+// Either way, the items read so far are returned along with the error.
+func ParallelBatchGet(ctx context.Context, client DynamoDBClient, keys map[string][]map[string]types.AttributeValue, workers int, policy RetryPolicy) (map[string][]map[string]types.AttributeValue, error) {
+	return parallelBatchGet(ctx, client, keys, workers, policy, realClock{})
+}
+
+func parallelBatchGet(ctx context.Context, client DynamoDBClient, keys map[string][]map[string]types.AttributeValue, workers int, policy RetryPolicy, clock Clock) (map[string][]map[string]types.AttributeValue, error) {
+	if workers < 1 {
+		workers = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	chunks := make(chan batchGetChunk)
+	go func() {
+		defer close(chunks)
+		for _, chunk := range chunkKeys(keys) {
+			select {
+			case chunks <- chunk:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	g := &batchGetter{
+		client: client,
+		policy: policy,
+		clock:  clock,
+		items:  map[string][]map[string]types.AttributeValue{},
+	}
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for chunk := range chunks {
+				if !g.get(ctx, chunk) {
+					cancel()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if g.err != nil {
+		return g.items, g.err
+	}
+	if len(g.unprocessed) > 0 {
+		return g.items, NewUnprocessedKeysError(g.unprocessed, g.lastErr)
+	}
+
+	return g.items, nil
+}
+
+// chunkKeys splits keys into chunks of up to MaxBatchGetItems keys of a
+// single table, leaving out duplicates.
+func chunkKeys(keys map[string][]map[string]types.AttributeValue) []batchGetChunk {
+	var chunks []batchGetChunk
+	for table, tableKeys := range keys {
+		seen := map[string]bool{}
+		chunk := batchGetChunk{table: table}
+		for _, key := range tableKeys {
+			k := cacheKey(table, key)
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+
+			chunk.keys = append(chunk.keys, key)
+			if len(chunk.keys) == MaxBatchGetItems {
+				chunks = append(chunks, chunk)
+				chunk = batchGetChunk{table: table}
+			}
+		}
+		if len(chunk.keys) > 0 {
+			chunks = append(chunks, chunk)
+		}
+	}
+
+	return chunks
+}
+
+// batchGetter collects the results of the chunks of a ParallelBatchGet.
+type batchGetter struct {
+	client DynamoDBClient
+	policy RetryPolicy
+	clock  Clock
+
+	mu          sync.Mutex
+	items       map[string][]map[string]types.AttributeValue
+	unprocessed map[string][]map[string]types.AttributeValue
+	err         error
+	lastErr     error
+	pauseUntil  time.Time
+}
+
+// get reads a chunk, reporting false if it failed with an error that should
+// stop the other chunks.
+func (g *batchGetter) get(ctx context.Context, chunk batchGetChunk) bool {
+	keys := chunk.keys
+	var delay time.Duration
+	for attempt := 1; ; attempt++ {
+		if err := g.pause(ctx); err != nil {
+			return g.fail(err)
+		}
+
+		output, err := g.client.BatchGetItem(ctx, &ddb.BatchGetItemInput{
+			RequestItems: map[string]types.KeysAndAttributes{chunk.table: {Keys: keys}},
+		})
+		if err == nil {
+			g.add(chunk.table, output.Responses[chunk.table])
+			keys = output.UnprocessedKeys[chunk.table].Keys
+			if len(keys) == 0 {
+				return true
+			}
+		} else if ctx.Err() != nil || g.policy.Classify(err) == DoNotRetry {
+			return g.fail(err)
+		}
+
+		if g.policy.Retries != InfiniteRetries && attempt > g.policy.Retries {
+			g.leave(chunk.table, keys, err)
+			return true
+		}
+		delay = g.policy.Delay(attempt, delay, err)
+		g.holdOff(delay)
+	}
+}
+
+// pause waits until no chunk is backing off.
+func (g *batchGetter) pause(ctx context.Context) error {
+	g.mu.Lock()
+	wait := g.pauseUntil.Sub(g.clock.Now())
+	g.mu.Unlock()
+
+	return g.clock.Sleep(ctx, wait)
+}
+
+// holdOff makes every worker wait for delay before sending its next chunk.
+func (g *batchGetter) holdOff(delay time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if until := g.clock.Now().Add(delay); until.After(g.pauseUntil) {
+		g.pauseUntil = until
+	}
+}
+
+func (g *batchGetter) add(table string, items []map[string]types.AttributeValue) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if len(items) > 0 {
+		g.items[table] = append(g.items[table], items...)
+	}
+}
+
+func (g *batchGetter) leave(table string, keys []map[string]types.AttributeValue, err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.unprocessed == nil {
+		g.unprocessed = map[string][]map[string]types.AttributeValue{}
+	}
+	g.unprocessed[table] = append(g.unprocessed[table], keys...)
+	if err != nil {
+		g.lastErr = err
+	}
+}
+
+func (g *batchGetter) fail(err error) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.err == nil {
+		g.err = err
+	}
+
+	return false
+}
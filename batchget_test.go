@@ -0,0 +1,136 @@
+package ddbretry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry/ddbretrytest"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// echoBatchGetDynamoDBClient returns an item for every key it is asked for,
+// except that the first throttles calls are throttled, the first unprocessed
+// calls leave their last key unprocessed and every call fails with err when
+// it is set.
+type echoBatchGetDynamoDBClient struct {
+	ddbretrytest.FakeClient
+	mu          sync.Mutex
+	throttles   int
+	unprocessed int
+	err         error
+	calls       int
+	largest     int
+}
+
+func (c *echoBatchGetDynamoDBClient) BatchGetItem(ctx context.Context, input *ddb.BatchGetItemInput, o ...func(*ddb.Options)) (*ddb.BatchGetItemOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.calls++
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.throttles > 0 {
+		c.throttles--
+		return nil, &types.ProvisionedThroughputExceededException{}
+	}
+
+	output := &ddb.BatchGetItemOutput{Responses: map[string][]map[string]types.AttributeValue{}}
+	for table, ka := range input.RequestItems {
+		if len(ka.Keys) > c.largest {
+			c.largest = len(ka.Keys)
+		}
+		keys := ka.Keys
+		if c.unprocessed > 0 {
+			c.unprocessed--
+			output.UnprocessedKeys = map[string]types.KeysAndAttributes{table: {Keys: keys[len(keys)-1:]}}
+			keys = keys[:len(keys)-1]
+		}
+		output.Responses[table] = append(output.Responses[table], keys...)
+	}
+
+	return output, nil
+}
+
+func TestParallelBatchGet(t *testing.T) {
+	keys := func(n int) []map[string]types.AttributeValue {
+		keys := make([]map[string]types.AttributeValue, n)
+		for i := range keys {
+			keys[i] = testKey(fmt.Sprint(i))
+		}
+		return keys
+	}
+
+	tests := []struct {
+		name        string
+		keys        map[string][]map[string]types.AttributeValue
+		throttles   int
+		unprocessed int
+		err         error
+		wantItems   map[string]int
+		wantErr     bool
+		wantLeft    int
+	}{
+		{
+			name:      "should read every key in chunks",
+			keys:      map[string][]map[string]types.AttributeValue{"users": keys(250), "orders": keys(10)},
+			wantItems: map[string]int{"users": 250, "orders": 10},
+		},
+		{
+			name:      "should read duplicate keys once",
+			keys:      map[string][]map[string]types.AttributeValue{"users": append(keys(5), keys(5)...)},
+			wantItems: map[string]int{"users": 5},
+		},
+		{
+			name:        "should retry throttled chunks and unprocessed keys",
+			keys:        map[string][]map[string]types.AttributeValue{"users": keys(250)},
+			throttles:   2,
+			unprocessed: 2,
+			wantItems:   map[string]int{"users": 250},
+		},
+		{
+			name:        "should return keys left once retries run out",
+			keys:        map[string][]map[string]types.AttributeValue{"users": keys(10)},
+			unprocessed: 10,
+			wantItems:   map[string]int{"users": 9},
+			wantErr:     true,
+			wantLeft:    1,
+		},
+		{
+			name:      "should stop on errors that are not retried",
+			keys:      map[string][]map[string]types.AttributeValue{"users": keys(10)},
+			err:       errors.New("validation failed"),
+			wantItems: map[string]int{},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &echoBatchGetDynamoDBClient{throttles: tt.throttles, unprocessed: tt.unprocessed, err: tt.err}
+			clock := ddbretrytest.NewFakeClock(time.Unix(1700000000, 0))
+			policy := RetryPolicy{Retries: 5, BackOffTime: time.Second}
+
+			items, err := parallelBatchGet(context.Background(), client, tt.keys, 4, policy, clock)
+			assert.Equal(t, tt.wantErr, err != nil)
+			got := map[string]int{}
+			for table, tableItems := range items {
+				got[table] = len(tableItems)
+			}
+			assert.Equal(t, tt.wantItems, got)
+			assert.LessOrEqual(t, client.largest, MaxBatchGetItems)
+
+			var unprocessedKeysError *UnprocessedKeysError
+			if errors.As(err, &unprocessedKeysError) {
+				assert.Len(t, unprocessedKeysError.Keys["users"], tt.wantLeft)
+			} else {
+				assert.Zero(t, tt.wantLeft)
+			}
+		})
+	}
+}
@@ -0,0 +1,315 @@
+package ddbretry
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// MaxBatchWriteItems is the largest number of write requests DynamoDB
+// accepts in a single BatchWriteItem call.
+const MaxBatchWriteItems = 25
+
+type pendingWrite struct {
+	table   string
+	request types.WriteRequest
+	key     string // cacheKey of the item written, or empty if unknown
+}
+
+// BatchWriter buffers individual put and delete requests and writes them with
+// BatchWriteItem, once MaxBatchWriteItems requests are buffered, every flush
+// interval if one is set, and on Flush and Close. Unprocessed items and
+// batches failing with errors the policy retries, such as throttling errors,
+// are sent again after the policy's backoff until its retries run out, after
+// which Flush returns an UnprocessedItemsError holding the requests left
+// unwritten. A BatchWriter is safe for concurrent use.
+//
+// DynamoDB rejects batches writing the same item twice, so a request for an
+// item already written by the batch being filled starts a new batch. Deletes
+// always carry their key; the key of a put is only known once the names of
+// the table's key attributes are, from an earlier delete or SetKeyNames.
+//
+// Put and Delete flush the buffer themselves when it fills up, so callers
+// writing faster than the table accepts are slowed down to its pace. Errors
+// from flushes made in the background are returned by the next call to Put,
+// Delete, Flush or Close.
+type BatchWriter struct {
+	client DynamoDBClient
+	policy RetryPolicy
+	clock  Clock
+
+	mu      sync.Mutex
+	pending []pendingWrite
+	keys    map[string][]string // table name to the names of its key attributes
+	err     error
+	closed  bool
+
+	flushMu sync.Mutex
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// NewBatchWriter returns a BatchWriter writing to client, retrying under
+// policy and flushing every flushInterval if it is positive. Close must be
+// called to stop the background flushes and write the remaining requests.
+func NewBatchWriter(client DynamoDBClient, policy RetryPolicy, flushInterval time.Duration) *BatchWriter {
+	w := &BatchWriter{
+		client: client,
+		policy: policy,
+		clock:  realClock{},
+	}
+	if flushInterval > 0 {
+		w.stop = make(chan struct{})
+		w.done = make(chan struct{})
+		go w.run(flushInterval)
+	}
+
+	return w
+}
+
+func (w *BatchWriter) run(flushInterval time.Duration) {
+	defer close(w.done)
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.flushBackground()
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+func (w *BatchWriter) flushBackground() {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+
+	if err := w.write(context.Background(), w.take()); err != nil {
+		w.mu.Lock()
+		w.err = errors.Join(w.err, err)
+		w.mu.Unlock()
+	}
+}
+
+// SetKeyNames tells w the names of the key attributes of table, so that puts
+// of the same item can be kept out of the same batch.
+func (w *BatchWriter) SetKeyNames(table string, names ...string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.learn(table, names)
+}
+
+// learn records names as the names of the key attributes of table. The
+// caller must hold w.mu.
+func (w *BatchWriter) learn(table string, names []string) {
+	if len(names) == 0 {
+		return
+	}
+	if w.keys == nil {
+		w.keys = map[string][]string{}
+	}
+	w.keys[table] = names
+}
+
+// Put buffers a PutRequest for item in table, returning an
+// ItemTooLargeError if item exceeds MaxItemSize.
+func (w *BatchWriter) Put(ctx context.Context, table string, item map[string]types.AttributeValue) error {
+	if err := validateItemSize(table, item); err != nil {
+		return err
+	}
+
+	return w.add(ctx, table, types.WriteRequest{PutRequest: &types.PutRequest{Item: item}})
+}
+
+// Delete buffers a DeleteRequest for the item with the given key in table.
+func (w *BatchWriter) Delete(ctx context.Context, table string, key map[string]types.AttributeValue) error {
+	return w.add(ctx, table, types.WriteRequest{DeleteRequest: &types.DeleteRequest{Key: key}})
+}
+
+// add buffers request, unless the writer is closed or a background flush
+// failed, in which case the request is dropped and the error returned.
+func (w *BatchWriter) add(ctx context.Context, table string, request types.WriteRequest) error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return NewBatchWriterClosedError()
+	}
+	if err := w.err; err != nil {
+		w.err = nil
+		w.mu.Unlock()
+		return err
+	}
+	pending := pendingWrite{table: table, request: request}
+	if d := request.DeleteRequest; d != nil {
+		w.learn(table, keyNames([]map[string]types.AttributeValue{d.Key}))
+		pending.key = cacheKey(table, d.Key)
+	} else if names, ok := w.keys[table]; ok {
+		if key := project(request.PutRequest.Item, names); len(key) == len(names) {
+			pending.key = cacheKey(table, key)
+		}
+	}
+	w.pending = append(w.pending, pending)
+	full := len(w.pending) >= MaxBatchWriteItems
+	w.mu.Unlock()
+
+	if full {
+		return w.Flush(ctx)
+	}
+
+	return nil
+}
+
+// Flush writes every buffered request, returning once they are written or
+// have been given up on.
+func (w *BatchWriter) Flush(ctx context.Context) error {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+
+	err := w.write(ctx, w.take())
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err != nil {
+		if err == nil {
+			err = w.err
+		} else {
+			err = errors.Join(w.err, err)
+		}
+		w.err = nil
+	}
+
+	return err
+}
+
+// Close stops the background flushes and flushes the buffered requests.
+// Requests buffered after Close fail with a BatchWriterClosedError.
+func (w *BatchWriter) Close(ctx context.Context) error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.mu.Unlock()
+
+	if w.stop != nil {
+		close(w.stop)
+		<-w.done
+	}
+
+	return w.Flush(ctx)
+}
+
+// take empties the buffer, returning the requests it held.
+func (w *BatchWriter) take() []pendingWrite {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	pending := w.pending
+	w.pending = nil
+
+	return pending
+}
+
+// write sends pending in batches of up to MaxBatchWriteItems requests and
+// an estimated MaxBatchWritePayload bytes, returning an
+// UnprocessedItemsError holding the requests of every batch that could not
+// be written.
+func (w *BatchWriter) write(ctx context.Context, pending []pendingWrite) error {
+	var left map[string][]types.WriteRequest
+	var lastErr error
+	for len(pending) > 0 {
+		n := batchLen(pending)
+		batch := map[string][]types.WriteRequest{}
+		for _, p := range pending[:n] {
+			batch[p.table] = append(batch[p.table], p.request)
+		}
+		pending = pending[n:]
+
+		unprocessed, err := w.writeBatch(ctx, batch)
+		if len(unprocessed) == 0 {
+			continue
+		}
+		if left == nil {
+			left = map[string][]types.WriteRequest{}
+		}
+		for table, requests := range unprocessed {
+			left[table] = append(left[table], requests...)
+		}
+		if err != nil {
+			lastErr = err
+		}
+	}
+	if left != nil {
+		return NewUnprocessedItemsError(left, lastErr)
+	}
+
+	return nil
+}
+
+// batchLen returns the number of requests at the start of pending that fit
+// in a single batch, which is at least one, without writing any item twice.
+func batchLen(pending []pendingWrite) int {
+	payload := len(`{"RequestItems":{}}`)
+	tables := map[string]bool{}
+	keys := map[string]bool{}
+	for n, p := range pending {
+		if n == MaxBatchWriteItems {
+			return n
+		}
+		if p.key != "" {
+			if keys[p.key] {
+				return n
+			}
+			keys[p.key] = true
+		}
+
+		size := writeRequestPayload(p.request)
+		if !tables[p.table] {
+			size += len(`"":[],`) + len(p.table)
+		}
+		if n > 0 && payload+size > MaxBatchWritePayload {
+			return n
+		}
+		payload += size
+		tables[p.table] = true
+	}
+
+	return len(pending)
+}
+
+// writeBatch sends a single batch, sending its unprocessed items again until
+// they are all written or the policy gives up on them, and returns the
+// requests left unwritten with the error that stopped the last attempt, if
+// any.
+func (w *BatchWriter) writeBatch(ctx context.Context, items map[string][]types.WriteRequest) (map[string][]types.WriteRequest, error) {
+	var delay time.Duration
+	for attempt := 1; ; attempt++ {
+		output, err := w.client.BatchWriteItem(ctx, &ddb.BatchWriteItemInput{RequestItems: items})
+		if err == nil {
+			items = output.UnprocessedItems
+			if len(items) == 0 {
+				return nil, nil
+			}
+		} else if ctx.Err() != nil || w.policy.Classify(err) == DoNotRetry {
+			return items, err
+		}
+
+		if w.policy.Retries != InfiniteRetries && attempt > w.policy.Retries {
+			return items, err
+		}
+		delay = w.policy.Delay(attempt, delay, err)
+		if sleepErr := w.clock.Sleep(ctx, delay); sleepErr != nil {
+			return items, sleepErr
+		}
+	}
+}
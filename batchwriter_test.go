@@ -0,0 +1,266 @@
+package ddbretry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry/ddbretrytest"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// batchWriteDynamoDBClient records the requests it writes. The first
+// unprocessed calls leave the last request of their batch unprocessed, and
+// the first throttles calls fail.
+type batchWriteDynamoDBClient struct {
+	ddbretrytest.FakeClient
+	mu          sync.Mutex
+	unprocessed int
+	throttles   int
+	calls       int
+	written     []types.WriteRequest
+}
+
+func (c *batchWriteDynamoDBClient) BatchWriteItem(ctx context.Context, input *ddb.BatchWriteItemInput, o ...func(*ddb.Options)) (*ddb.BatchWriteItemOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.calls++
+	if c.throttles > 0 {
+		c.throttles--
+		return nil, &types.ProvisionedThroughputExceededException{}
+	}
+
+	output := &ddb.BatchWriteItemOutput{}
+	for table, requests := range input.RequestItems {
+		if c.unprocessed > 0 {
+			c.unprocessed--
+			output.UnprocessedItems = map[string][]types.WriteRequest{table: requests[len(requests)-1:]}
+			requests = requests[:len(requests)-1]
+		}
+		c.written = append(c.written, requests...)
+	}
+
+	return output, nil
+}
+
+func (c *batchWriteDynamoDBClient) count() (int, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.calls, len(c.written)
+}
+
+func TestBatchWriter(t *testing.T) {
+	tests := []struct {
+		name        string
+		requests    int
+		unprocessed int
+		throttles   int
+		wantCalls   int
+		wantWritten int
+		wantErr     bool
+	}{
+		{
+			name:        "should write full batches as they fill up",
+			requests:    60,
+			wantCalls:   3,
+			wantWritten: 60,
+		},
+		{
+			name:        "should write unprocessed items again",
+			requests:    10,
+			unprocessed: 2,
+			wantCalls:   3,
+			wantWritten: 10,
+		},
+		{
+			name:        "should retry throttled batches",
+			requests:    10,
+			throttles:   1,
+			wantCalls:   2,
+			wantWritten: 10,
+		},
+		{
+			name:        "should return unprocessed items once retries run out",
+			requests:    10,
+			unprocessed: 5,
+			wantCalls:   3,
+			wantWritten: 9,
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &batchWriteDynamoDBClient{unprocessed: tt.unprocessed, throttles: tt.throttles}
+			w := NewBatchWriter(client, RetryPolicy{Retries: 2, BackOffTime: time.Millisecond}, 0)
+			clock := ddbretrytest.NewFakeClock(time.Time{})
+			w.clock = clock
+			ctx := context.Background()
+
+			for i := 0; i < tt.requests; i++ {
+				item := map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: fmt.Sprint(i)}}
+				assert.NoError(t, w.Put(ctx, "users", item))
+			}
+			err := w.Close(ctx)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if tt.wantErr {
+				assert.True(t, IsUnprocessedItemsError(err))
+				var unprocessedItemsError *UnprocessedItemsError
+				if assert.ErrorAs(t, err, &unprocessedItemsError) {
+					assert.Len(t, unprocessedItemsError.Items["users"], tt.requests-tt.wantWritten)
+				}
+			}
+
+			calls, written := client.count()
+			assert.Equal(t, tt.wantCalls, calls)
+			assert.Equal(t, tt.wantWritten, written)
+			assert.True(t, IsBatchWriterClosedError(w.Delete(ctx, "users", nil)))
+		})
+	}
+}
+
+func TestBatchWriter_flushInterval(t *testing.T) {
+	client := &batchWriteDynamoDBClient{}
+	w := NewBatchWriter(client, RetryPolicy{}, time.Millisecond)
+	defer w.Close(context.Background())
+
+	assert.NoError(t, w.Put(context.Background(), "users", map[string]types.AttributeValue{}))
+	assert.Eventually(t, func() bool {
+		_, written := client.count()
+		return written == 1
+	}, time.Second, time.Millisecond)
+}
+
+func TestBatchWriter_duplicateKeys(t *testing.T) {
+	key := func(id string) map[string]types.AttributeValue {
+		return map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: id}}
+	}
+	item := func(id string, name string) map[string]types.AttributeValue {
+		return map[string]types.AttributeValue{
+			"id":   &types.AttributeValueMemberS{Value: id},
+			"name": &types.AttributeValueMemberS{Value: name},
+		}
+	}
+	tests := []struct {
+		name      string
+		keyNames  []string
+		write     func(ctx context.Context, w *BatchWriter) error
+		wantCalls int
+	}{
+		{
+			name:     "should write puts of the same item in separate batches",
+			keyNames: []string{"id"},
+			write: func(ctx context.Context, w *BatchWriter) error {
+				return errors.Join(
+					w.Put(ctx, "users", item("1", "a")),
+					w.Put(ctx, "users", item("2", "b")),
+					w.Put(ctx, "users", item("1", "c")),
+				)
+			},
+			wantCalls: 2,
+		},
+		{
+			name: "should learn the key names of a table from its deletes",
+			write: func(ctx context.Context, w *BatchWriter) error {
+				return errors.Join(
+					w.Delete(ctx, "users", key("1")),
+					w.Put(ctx, "users", item("1", "a")),
+					w.Delete(ctx, "users", key("1")),
+				)
+			},
+			wantCalls: 3,
+		},
+		{
+			name:     "should write the same key to different tables in one batch",
+			keyNames: []string{"id"},
+			write: func(ctx context.Context, w *BatchWriter) error {
+				return errors.Join(
+					w.Put(ctx, "users", item("1", "a")),
+					w.Put(ctx, "accounts", item("1", "a")),
+				)
+			},
+			wantCalls: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &batchWriteDynamoDBClient{}
+			w := NewBatchWriter(client, RetryPolicy{}, 0)
+			w.SetKeyNames("users", tt.keyNames...)
+			w.SetKeyNames("accounts", tt.keyNames...)
+			ctx := context.Background()
+
+			assert.NoError(t, tt.write(ctx, w))
+			assert.NoError(t, w.Close(ctx))
+
+			calls, _ := client.count()
+			assert.Equal(t, tt.wantCalls, calls)
+		})
+	}
+}
+
+func TestBatchLen(t *testing.T) {
+	put := func(size int) pendingWrite {
+		return pendingWrite{
+			table: "users",
+			request: types.WriteRequest{PutRequest: &types.PutRequest{Item: map[string]types.AttributeValue{
+				"data": &types.AttributeValueMemberB{Value: make([]byte, size)},
+			}}},
+		}
+	}
+	batch := func(n int, size int) []pendingWrite {
+		pending := make([]pendingWrite, n)
+		for i := range pending {
+			pending[i] = put(size)
+		}
+		return pending
+	}
+
+	tests := []struct {
+		name    string
+		pending []pendingWrite
+		want    int
+	}{
+		{
+			name:    "should limit batches to MaxBatchWriteItems requests",
+			pending: batch(30, 10),
+			want:    MaxBatchWriteItems,
+		},
+		{
+			name:    "should take every request that fits",
+			pending: batch(3, 10),
+			want:    3,
+		},
+		{
+			name:    "should limit batches to MaxBatchWritePayload bytes",
+			pending: batch(30, 1024*1024),
+			want:    11,
+		},
+		{
+			name: "should end a batch before a key repeats",
+			pending: []pendingWrite{
+				{table: "users", request: put(1).request, key: "1"},
+				{table: "users", request: put(1).request, key: "2"},
+				{table: "users", request: put(1).request},
+				{table: "users", request: put(1).request, key: "1"},
+			},
+			want: 3,
+		},
+		{
+			name:    "should always take at least one request",
+			pending: batch(2, 20*1024*1024),
+			want:    1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, batchLen(tt.pending))
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package ddbretry
+
+import (
+	"sync"
+	"time"
+)
+
+type CircuitState int
+
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreaker stops a client from sending requests during a prolonged
+// capacity shortfall. After threshold consecutive throttling errors it
+// opens and fails every attempt fast for the cool-down period, then half-opens
+// and lets up to probes requests through. The circuit closes again once all
+// probes succeed, and reopens if any of them is throttled.
+type CircuitBreaker struct {
+	mu sync.Mutex
+	clockSource
+	threshold int
+	coolDown  time.Duration
+	probes    int
+
+	state     CircuitState
+	failures  int
+	openedAt  time.Time
+	inFlight  int
+	successes int
+}
+
+func NewCircuitBreaker(threshold int, coolDown time.Duration, probes int) *CircuitBreaker {
+	if probes < 1 {
+		probes = 1
+	}
+
+	return &CircuitBreaker{
+		threshold: threshold,
+		coolDown:  coolDown,
+		probes:    probes,
+	}
+}
+
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.advance()
+
+	return b.state
+}
+
+// advance moves an open circuit to half-open once its cool-down has elapsed.
+func (b *CircuitBreaker) advance() {
+	if b.state == CircuitOpen && b.now().Sub(b.openedAt) >= b.coolDown {
+		b.state = CircuitHalfOpen
+		b.inFlight = 0
+		b.successes = 0
+	}
+}
+
+// allow reports whether an attempt may be sent. When it returns false, until
+// is the earliest time the circuit will admit a probe.
+func (b *CircuitBreaker) allow(clock Clock) (ok bool, until time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.use(clock)
+	b.advance()
+
+	switch b.state {
+	case CircuitOpen:
+		return false, b.openedAt.Add(b.coolDown)
+	case CircuitHalfOpen:
+		if b.inFlight >= b.probes {
+			return false, time.Time{}
+		}
+		b.inFlight++
+	}
+
+	return true, time.Time{}
+}
+
+// release gives back a probe admitted by allow that was not sent, so that
+// the attempt's outcome will never be recorded.
+func (b *CircuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitHalfOpen && b.inFlight > 0 {
+		b.inFlight--
+	}
+}
+
+// record updates the circuit with the outcome of an attempt.
+func (b *CircuitBreaker) record(throttled bool, clock Clock) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.use(clock)
+	switch b.state {
+	case CircuitClosed:
+		if !throttled {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= b.threshold {
+			b.open()
+		}
+	case CircuitHalfOpen:
+		if throttled {
+			b.open()
+			return
+		}
+		b.successes++
+		if b.successes >= b.probes {
+			b.state = CircuitClosed
+			b.failures = 0
+		}
+	}
+}
+
+func (b *CircuitBreaker) open() {
+	b.state = CircuitOpen
+	b.openedAt = b.now()
+	b.failures = 0
+}
@@ -0,0 +1,93 @@
+package ddbretry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry/ddbretrytest"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	clock := ddbretrytest.NewFakeClock(time.Unix(1700000000, 0))
+	b := NewCircuitBreaker(3, time.Minute, 2)
+
+	b.record(true, clock)
+	b.record(true, clock)
+	b.record(false, clock)
+	b.record(true, clock)
+	b.record(true, clock)
+	assert.Equal(t, CircuitClosed, b.State())
+
+	b.record(true, clock)
+	assert.Equal(t, CircuitOpen, b.State())
+	ok, until := b.allow(clock)
+	assert.False(t, ok)
+	assert.Equal(t, clock.Now().Add(time.Minute), until)
+
+	clock.Advance(time.Minute)
+	assert.Equal(t, CircuitHalfOpen, b.State())
+	ok, _ = b.allow(clock)
+	assert.True(t, ok)
+	ok, _ = b.allow(clock)
+	assert.True(t, ok)
+	ok, _ = b.allow(clock)
+	assert.False(t, ok)
+
+	b.record(false, clock)
+	assert.Equal(t, CircuitHalfOpen, b.State())
+	b.record(true, clock)
+	assert.Equal(t, CircuitOpen, b.State())
+
+	clock.Advance(time.Minute)
+	b.allow(clock)
+	b.allow(clock)
+	b.record(false, clock)
+	b.record(false, clock)
+	assert.Equal(t, CircuitClosed, b.State())
+}
+
+func TestRetryDynamoDBClient_CircuitBreaker(t *testing.T) {
+	breaker := NewCircuitBreaker(2, time.Hour, 1)
+	c := &RetryDynamoDBClient{
+		DynamoDBClient: ddbretrytest.NewFakeClient().Script("GetItem", ddbretrytest.Throttles(5)...),
+		Retries:        10,
+		CircuitBreaker: breaker,
+	}
+
+	_, err := c.GetItem(context.Background(), &ddb.GetItemInput{})
+	assert.True(t, IsCircuitOpenError(err))
+	assert.Equal(t, CircuitOpen, breaker.State())
+
+	_, err = c.PutItem(context.Background(), &ddb.PutItemInput{})
+	assert.True(t, IsCircuitOpenError(err))
+}
+
+func TestRetryDynamoDBClient_CircuitBreakerProbeNotSent(t *testing.T) {
+	clock := ddbretrytest.NewFakeClock(time.Unix(1700000000, 0))
+	breaker := NewCircuitBreaker(1, time.Minute, 1)
+	limiter := NewConcurrencyLimiter(1)
+	c := &RetryDynamoDBClient{
+		DynamoDBClient:     ddbretrytest.NewFakeClient(),
+		CircuitBreaker:     breaker,
+		ConcurrencyLimiter: limiter,
+		Clock:              clock,
+	}
+
+	breaker.record(true, clock)
+	clock.Advance(time.Minute)
+	assert.Equal(t, CircuitHalfOpen, breaker.State())
+
+	assert.NoError(t, limiter.acquire(context.Background()))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := c.GetItem(ctx, &ddb.GetItemInput{})
+	assert.ErrorIs(t, err, context.Canceled)
+	limiter.release()
+
+	_, err = c.GetItem(context.Background(), &ddb.GetItemInput{})
+	assert.NoError(t, err)
+	assert.Equal(t, CircuitClosed, breaker.State())
+}
@@ -0,0 +1,99 @@
+package ddbretry
+
+import (
+	"sync"
+)
+
+const (
+	DefaultRetryTokenBucketCapacity = 500
+	DefaultRetryCost                = 5
+)
+
+// RetryTokenBucket is a retry budget that can be shared by every operation of
+// a client, or by several clients. Each retry withdraws a fixed number of
+// tokens and each call that succeeds on its first attempt deposits one, so a
+// burst of throttling can only produce a bounded number of retries.
+type RetryTokenBucket struct {
+	mu        sync.Mutex
+	capacity  int
+	tokens    int
+	retryCost int
+	reserves  map[Priority]int
+}
+
+func NewRetryTokenBucket(capacity int, retryCost int) *RetryTokenBucket {
+	return &RetryTokenBucket{
+		capacity:  capacity,
+		tokens:    capacity,
+		retryCost: retryCost,
+	}
+}
+
+func NewDefaultRetryTokenBucket() *RetryTokenBucket {
+	return NewRetryTokenBucket(DefaultRetryTokenBucketCapacity, DefaultRetryCost)
+}
+
+// Tokens returns the number of tokens currently available.
+func (b *RetryTokenBucket) Tokens() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.tokens
+}
+
+// SetReserve holds tokens back from the retries of calls of priority p, so
+// that they stop retrying once the bucket holds fewer than tokens plus the
+// cost of a retry, leaving the rest to calls of higher priority. Reserves
+// should grow as priority falls.
+func (b *RetryTokenBucket) SetReserve(p Priority, tokens int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.reserves == nil {
+		b.reserves = map[Priority]int{}
+	}
+	b.reserves[p] = tokens
+}
+
+// acquire withdraws the cost of one retry of a NormalPriority call, reporting
+// false if the bucket does not hold enough tokens.
+func (b *RetryTokenBucket) acquire() bool {
+	return b.acquireFor(NormalPriority)
+}
+
+// acquireFor withdraws the cost of one retry of a call of priority p,
+// reporting false if the bucket does not hold enough tokens beyond p's
+// reserve.
+func (b *RetryTokenBucket) acquireFor(p Priority) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.tokens-b.reserves[p] < b.retryCost {
+		return false
+	}
+	b.tokens -= b.retryCost
+
+	return true
+}
+
+// refund returns the cost of a retry withdrawn by acquireFor that was not
+// made, up to the bucket's capacity.
+func (b *RetryTokenBucket) refund() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens += b.retryCost
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+}
+
+// deposit returns a single token to the bucket, up to its capacity.
+func (b *RetryTokenBucket) deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.tokens < b.capacity {
+		b.tokens++
+	}
+}
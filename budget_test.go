@@ -0,0 +1,85 @@
+package ddbretry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Thumbscrew/ddbretry/ddbretrytest"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryTokenBucket(t *testing.T) {
+	b := NewRetryTokenBucket(10, 5)
+
+	assert.True(t, b.acquire())
+	assert.True(t, b.acquire())
+	assert.False(t, b.acquire())
+	assert.Equal(t, 0, b.Tokens())
+
+	for i := 0; i < 20; i++ {
+		b.deposit()
+	}
+	assert.Equal(t, 10, b.Tokens())
+}
+
+func TestRetryDynamoDBClient_RetryBudget(t *testing.T) {
+	budget := NewRetryTokenBucket(10, 5)
+
+	getItemClient := &RetryDynamoDBClient{
+		DynamoDBClient: ddbretrytest.NewFakeClient().Script("GetItem", ddbretrytest.Throttles(2)...),
+		Retries:        5,
+		RetryBudget:    budget,
+	}
+	_, err := getItemClient.GetItem(context.Background(), &ddb.GetItemInput{})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, budget.Tokens())
+
+	putItemClient := &RetryDynamoDBClient{
+		DynamoDBClient: ddbretrytest.NewFakeClient().Script("PutItem", ddbretrytest.Throttled()),
+		Retries:        5,
+		RetryBudget:    budget,
+	}
+	_, err = putItemClient.PutItem(context.Background(), &ddb.PutItemInput{})
+	assert.True(t, IsRetryBudgetExhaustedError(err))
+	assert.True(t, IsProvisionedThroughputExceededException(err))
+
+	_, err = putItemClient.PutItem(context.Background(), &ddb.PutItemInput{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, budget.Tokens())
+}
+
+func TestRetryTokenBucket_reserves(t *testing.T) {
+	b := NewRetryTokenBucket(20, 5)
+	b.SetReserve(LowPriority, 10)
+	b.SetReserve(NormalPriority, 5)
+
+	assert.True(t, b.acquireFor(LowPriority))
+	assert.True(t, b.acquireFor(LowPriority))
+	assert.False(t, b.acquireFor(LowPriority))
+	assert.True(t, b.acquireFor(NormalPriority))
+	assert.False(t, b.acquireFor(NormalPriority))
+	assert.True(t, b.acquireFor(HighPriority))
+	assert.False(t, b.acquireFor(HighPriority))
+	assert.Equal(t, 0, b.Tokens())
+}
+
+func TestRetryDynamoDBClient_PriorityRetryBudget(t *testing.T) {
+	budget := NewRetryTokenBucket(10, 5)
+	budget.SetReserve(LowPriority, 10)
+
+	client := &RetryDynamoDBClient{
+		DynamoDBClient: ddbretrytest.NewFakeClient().Script("GetItem", ddbretrytest.Throttled()),
+		Retries:        5,
+		RetryBudget:    budget,
+	}
+	_, err := client.GetItem(context.Background(), &ddb.GetItemInput{}, WithCallPriority(LowPriority))
+	assert.True(t, IsRetryBudgetExhaustedError(err))
+	assert.Equal(t, 10, budget.Tokens())
+
+	client.DynamoDBClient = ddbretrytest.NewFakeClient().Script("GetItem", ddbretrytest.Throttled())
+	ctx := ContextWithPriority(context.Background(), HighPriority)
+	_, err = client.GetItem(ctx, &ddb.GetItemInput{})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, budget.Tokens())
+}
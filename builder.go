@@ -0,0 +1,115 @@
+package ddbretry
+
+import (
+	"time"
+)
+
+// Builder assembles a RetryDynamoDBClient step by step, as an alternative to
+// option functions:
+//
+//	client, err := ddbretry.NewBuilder().
+//		Retries(5).
+//		BackOff(100 * time.Millisecond).
+//		Exponential().
+//		Build(dynamoDBClient)
+//
+// Settings are only validated by Build.
+type Builder struct {
+	cfg Config
+}
+
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+// Retries sets the number of retries, with InfiniteRetries retrying
+// indefinitely and zero disabling retries.
+func (b *Builder) Retries(retries int) *Builder {
+	b.cfg.Retries = retries
+	b.cfg.DisableRetries = retries == 0
+	b.cfg.Infinite = retries == InfiniteRetries
+
+	return b
+}
+
+// MaxAttempts limits each call to maxAttempts attempts in total. Build
+// fails if Retries is also set.
+func (b *Builder) MaxAttempts(maxAttempts int) *Builder {
+	b.cfg.MaxAttempts = maxAttempts
+
+	return b
+}
+
+func (b *Builder) Infinite() *Builder {
+	b.cfg.Infinite = true
+	b.cfg.DisableRetries = false
+
+	return b
+}
+
+func (b *Builder) BackOff(backOff time.Duration) *Builder {
+	b.cfg.BackOffTime = backOff
+
+	return b
+}
+
+func (b *Builder) MaxBackOff(maxBackOff time.Duration) *Builder {
+	b.cfg.MaxBackOff = maxBackOff
+
+	return b
+}
+
+func (b *Builder) Constant() *Builder {
+	b.cfg.BackOffStrategy = ConstantBackOff
+
+	return b
+}
+
+func (b *Builder) Exponential() *Builder {
+	b.cfg.BackOffStrategy = ExponentialBackOff
+
+	return b
+}
+
+func (b *Builder) FullJitter() *Builder {
+	b.cfg.BackOffStrategy = FullJitterBackOff
+
+	return b
+}
+
+func (b *Builder) EqualJitter() *Builder {
+	b.cfg.BackOffStrategy = EqualJitterBackOff
+
+	return b
+}
+
+func (b *Builder) DecorrelatedJitter() *Builder {
+	b.cfg.BackOffStrategy = DecorrelatedJitterBackOff
+
+	return b
+}
+
+func (b *Builder) MaxElapsedTime(maxElapsedTime time.Duration) *Builder {
+	b.cfg.MaxElapsedTime = maxElapsedTime
+
+	return b
+}
+
+func (b *Builder) AttemptTimeout(attemptTimeout time.Duration) *Builder {
+	b.cfg.AttemptTimeout = attemptTimeout
+
+	return b
+}
+
+// Config replaces every setting made so far with those in cfg.
+func (b *Builder) Config(cfg Config) *Builder {
+	b.cfg = cfg
+
+	return b
+}
+
+// Build wraps client with the settings made so far, returning the errors
+// reported by Config.Validate if any of them is invalid.
+func (b *Builder) Build(client DynamoDBClient) (*RetryDynamoDBClient, error) {
+	return NewFromConfig(client, b.cfg)
+}
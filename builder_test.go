@@ -0,0 +1,47 @@
+package ddbretry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry/ddbretrytest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilder(t *testing.T) {
+	client := ddbretrytest.NewFakeClient()
+
+	c, err := NewBuilder().
+		Retries(5).
+		BackOff(100 * time.Millisecond).
+		MaxBackOff(time.Second).
+		Exponential().
+		Build(client)
+	assert.NoError(t, err)
+	want := &RetryDynamoDBClient{
+		DynamoDBClient:  client,
+		Retries:         5,
+		BackOffTime:     100 * time.Millisecond,
+		MaxBackOff:      time.Second,
+		BackOffStrategy: ExponentialBackOff,
+	}
+	want.freeze()
+	assert.Equal(t, want, c)
+
+	c, err = NewBuilder().Retries(0).Build(client)
+	assert.NoError(t, err)
+	assert.True(t, c.DisableRetries)
+
+	c, err = NewBuilder().MaxAttempts(3).Build(client)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, c.policy().Retries)
+
+	c, err = NewBuilder().Retries(3).MaxAttempts(4).Build(client)
+	assert.Nil(t, c)
+	assert.True(t, IsInvalidConfigError(err))
+
+	c, err = NewBuilder().Retries(-2).BackOff(-time.Second).Build(client)
+	assert.Nil(t, c)
+	assert.True(t, IsInvalidRetryError(err))
+	assert.True(t, IsInvalidBackOffError(err))
+}
@@ -0,0 +1,68 @@
+package ddbretry
+
+import (
+	"sync"
+)
+
+// Bulkheads isolate the tables a client calls from each other, giving each
+// table its own ConcurrencyLimiter and RetryTokenBucket, so that retries
+// against one hot table cannot use up the slots and retry budget of the
+// others. They apply on top of the client's own ConcurrencyLimiter and
+// RetryBudget, if it has them. Calls without a single table, such as
+// multi-table batches, share the bulkhead of the empty table name.
+type Bulkheads struct {
+	mu          sync.Mutex
+	maxInFlight int
+	capacity    int
+	retryCost   int
+	tables      map[string]*bulkhead
+}
+
+type bulkhead struct {
+	limiter *ConcurrencyLimiter
+	budget  *RetryTokenBucket
+}
+
+// NewBulkheads returns Bulkheads allowing each table up to maxInFlight
+// attempts in flight, if it is positive, and a retry budget of capacity
+// tokens with retries costing retryCost, if capacity is positive.
+func NewBulkheads(maxInFlight int, capacity int, retryCost int) *Bulkheads {
+	return &Bulkheads{
+		maxInFlight: maxInFlight,
+		capacity:    capacity,
+		retryCost:   retryCost,
+		tables:      map[string]*bulkhead{},
+	}
+}
+
+// Limiter returns the ConcurrencyLimiter of table, or nil if the bulkheads
+// do not limit concurrency.
+func (b *Bulkheads) Limiter(table string) *ConcurrencyLimiter {
+	return b.get(table).limiter
+}
+
+// RetryBudget returns the RetryTokenBucket of table, or nil if the bulkheads
+// do not limit retries.
+func (b *Bulkheads) RetryBudget(table string) *RetryTokenBucket {
+	return b.get(table).budget
+}
+
+// get returns the bulkhead of table, creating it on first use.
+func (b *Bulkheads) get(table string) *bulkhead {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	h, ok := b.tables[table]
+	if !ok {
+		h = &bulkhead{}
+		if b.maxInFlight > 0 {
+			h.limiter = NewConcurrencyLimiter(b.maxInFlight)
+		}
+		if b.capacity > 0 {
+			h.budget = NewRetryTokenBucket(b.capacity, b.retryCost)
+		}
+		b.tables[table] = h
+	}
+
+	return h
+}
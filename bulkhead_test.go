@@ -0,0 +1,59 @@
+package ddbretry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry/ddbretrytest"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryDynamoDBClient_Bulkheads(t *testing.T) {
+	fake := ddbretrytest.NewFakeClient().Script("GetItem", ddbretrytest.Throttles(10)...)
+	c := &RetryDynamoDBClient{
+		DynamoDBClient: fake,
+		Retries:        10,
+		BackOffTime:    time.Millisecond,
+		Bulkheads:      NewBulkheads(2, 10, 5),
+	}
+	ctx := context.Background()
+
+	_, err := c.GetItem(ctx, &ddb.GetItemInput{TableName: aws.String("hot")})
+	assert.True(t, IsRetryBudgetExhaustedError(err))
+	assert.Equal(t, 3, fake.CallCount("GetItem"))
+	assert.Equal(t, 0, c.Bulkheads.RetryBudget("hot").Tokens())
+	assert.Equal(t, 0, c.Bulkheads.Limiter("hot").InFlight())
+
+	c.DynamoDBClient = ddbretrytest.NewFakeClient().Script("GetItem", ddbretrytest.Throttled())
+	_, err = c.GetItem(ctx, &ddb.GetItemInput{TableName: aws.String("cold")})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, c.Bulkheads.RetryBudget("cold").Tokens())
+}
+
+func TestRetryDynamoDBClient_BulkheadsRetryBudget(t *testing.T) {
+	c := &RetryDynamoDBClient{
+		DynamoDBClient: ddbretrytest.NewFakeClient().Script("GetItem", ddbretrytest.Throttled()),
+		Retries:        10,
+		BackOffTime:    time.Millisecond,
+		Bulkheads:      NewBulkheads(0, 10, 5),
+		RetryBudget:    NewRetryTokenBucket(0, 5),
+	}
+
+	_, err := c.GetItem(context.Background(), &ddb.GetItemInput{TableName: aws.String("users")})
+	assert.True(t, IsRetryBudgetExhaustedError(err))
+	assert.Equal(t, 10, c.Bulkheads.RetryBudget("users").Tokens())
+}
+
+func TestBulkheads(t *testing.T) {
+	b := NewBulkheads(0, 0, 0)
+	assert.Nil(t, b.Limiter("users"))
+	assert.Nil(t, b.RetryBudget("users"))
+
+	b = NewBulkheads(4, 100, 5)
+	assert.Same(t, b.Limiter("users"), b.Limiter("users"))
+	assert.NotSame(t, b.Limiter("users"), b.Limiter("orders"))
+	assert.Equal(t, 100, b.RetryBudget("users").Tokens())
+}
@@ -0,0 +1,429 @@
+package ddbretry
+
+import (
+	"container/list"
+	"context"
+	"encoding/base64"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DefaultCacheSize is the number of items held by the LRUCache a
+// CachingDynamoDBClient creates when it is given no store.
+const DefaultCacheSize = 10000
+
+// CacheEntry is an item held by a CacheStore, with the time it was read.
+type CacheEntry struct {
+	Item   map[string]types.AttributeValue
+	Stored time.Time
+}
+
+// CacheStore holds the items cached by a CachingDynamoDBClient. Keys encode
+// the table and key of an item. Implementations must be safe for concurrent
+// use.
+type CacheStore interface {
+	Get(key string) (CacheEntry, bool)
+	Set(key string, entry CacheEntry)
+	Delete(key string)
+}
+
+// LRUCache is an in-memory CacheStore holding up to a fixed number of items,
+// evicting the least recently used item to make room for a new one.
+type LRUCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List
+}
+
+type lruEntry struct {
+	key   string
+	entry CacheEntry
+}
+
+// NewLRUCache returns an LRUCache holding up to capacity items, or
+// DefaultCacheSize items if capacity is not positive.
+func NewLRUCache(capacity int) *LRUCache {
+	if capacity <= 0 {
+		capacity = DefaultCacheSize
+	}
+
+	return &LRUCache{
+		capacity: capacity,
+		entries:  map[string]*list.Element{},
+		order:    list.New(),
+	}
+}
+
+func (c *LRUCache) Get(key string) (CacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return CacheEntry{}, false
+	}
+	c.order.MoveToFront(e)
+
+	return e.Value.(*lruEntry).entry, true
+}
+
+func (c *LRUCache) Set(key string, entry CacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[key]; ok {
+		e.Value.(*lruEntry).entry = entry
+		c.order.MoveToFront(e)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, entry: entry})
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).key)
+	}
+}
+
+func (c *LRUCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[key]; ok {
+		c.order.Remove(e)
+		delete(c.entries, key)
+	}
+}
+
+// Len returns the number of items held by c.
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+// CachingDynamoDBClient is a read-through cache in front of the GetItem and
+// BatchGetItem calls of a DynamoDBClient, usually a RetryDynamoDBClient, to
+// take retry pressure off hot keys. Items read within TTL are served from
+// Store without a request; older items are read again, and are served from
+// Store if the read fails with a throttling error, unless they are older than
+// StaleTTL when it is set. Strongly consistent reads and reads with a
+// projection always go to DynamoDB, though the full items returned by
+// strongly consistent reads are cached.
+//
+// PutItem, DeleteItem and UpdateItem calls made through the client evict
+// the items they write. PutItem finds the key of its item from the names of
+// the key attributes of the reads that cached items of its table.
+type CachingDynamoDBClient struct {
+	DynamoDBClient
+	Store    CacheStore
+	TTL      time.Duration
+	StaleTTL time.Duration
+	// Clock, when set, replaces the real clock used to age cached items.
+	Clock Clock
+
+	keys sync.Map // table name to the []string names of its key attributes
+}
+
+// NewCachingDynamoDBClient wraps client, caching items in store, or in an
+// LRUCache of DefaultCacheSize items if store is nil, for ttl.
+func NewCachingDynamoDBClient(client DynamoDBClient, store CacheStore, ttl time.Duration) *CachingDynamoDBClient {
+	if store == nil {
+		store = NewLRUCache(DefaultCacheSize)
+	}
+
+	return &CachingDynamoDBClient{
+		DynamoDBClient: client,
+		Store:          store,
+		TTL:            ttl,
+	}
+}
+
+// Unwrap returns the client c wraps.
+func (c *CachingDynamoDBClient) Unwrap() DynamoDBClient {
+	return c.DynamoDBClient
+}
+
+func (c *CachingDynamoDBClient) GetItem(ctx context.Context, input *ddb.GetItemInput, o ...func(*ddb.Options)) (*ddb.GetItemOutput, error) {
+	if input.ProjectionExpression != nil || len(input.AttributesToGet) > 0 {
+		return c.DynamoDBClient.GetItem(ctx, input, o...)
+	}
+
+	key := cacheKey(aws.ToString(input.TableName), input.Key)
+	entry, cached := c.Store.Get(key)
+	if cached && !aws.ToBool(input.ConsistentRead) && c.fresh(entry) {
+		return &ddb.GetItemOutput{Item: copyItem(entry.Item)}, nil
+	}
+
+	output, err := c.DynamoDBClient.GetItem(ctx, input, o...)
+	if err != nil {
+		if cached && !aws.ToBool(input.ConsistentRead) && c.servable(entry, err) {
+			return &ddb.GetItemOutput{Item: copyItem(entry.Item)}, nil
+		}
+		return nil, err
+	}
+	if output.Item != nil {
+		c.learn(aws.ToString(input.TableName), keyNames([]map[string]types.AttributeValue{input.Key}))
+		c.Store.Set(key, CacheEntry{Item: copyItem(output.Item), Stored: c.clock()})
+	}
+
+	return output, nil
+}
+
+// BatchGetItem serves the keys it holds fresh items for from Store and reads
+// the rest. If that read fails with a throttling error, the keys it holds
+// servable items for are served from Store and the others are returned as
+// UnprocessedKeys, as DynamoDB does for a batch it partly processes.
+func (c *CachingDynamoDBClient) BatchGetItem(ctx context.Context, input *ddb.BatchGetItemInput, o ...func(*ddb.Options)) (*ddb.BatchGetItemOutput, error) {
+	responses := map[string][]map[string]types.AttributeValue{}
+	stale := map[string][]cachedKey{}
+	remaining := make(map[string]types.KeysAndAttributes, len(input.RequestItems))
+	for table, ka := range input.RequestItems {
+		if aws.ToBool(ka.ConsistentRead) || ka.ProjectionExpression != nil || len(ka.AttributesToGet) > 0 {
+			remaining[table] = ka
+			continue
+		}
+
+		var keys []map[string]types.AttributeValue
+		for _, key := range ka.Keys {
+			entry, ok := c.Store.Get(cacheKey(table, key))
+			switch {
+			case ok && c.fresh(entry):
+				responses[table] = append(responses[table], copyItem(entry.Item))
+			case ok:
+				stale[table] = append(stale[table], cachedKey{key: key, entry: entry})
+				keys = append(keys, key)
+			default:
+				keys = append(keys, key)
+			}
+		}
+		if len(keys) > 0 {
+			ka.Keys = keys
+			remaining[table] = ka
+		}
+	}
+	if len(remaining) == 0 {
+		return &ddb.BatchGetItemOutput{Responses: responses}, nil
+	}
+
+	request := *input
+	request.RequestItems = remaining
+	output, err := c.DynamoDBClient.BatchGetItem(ctx, &request, o...)
+	if err != nil {
+		if !IsThrottlingError(err) || len(stale) == 0 {
+			return nil, err
+		}
+		return c.serveStale(remaining, responses, stale, err), nil
+	}
+
+	for table, items := range output.Responses {
+		if ka := remaining[table]; ka.ProjectionExpression != nil || len(ka.AttributesToGet) > 0 {
+			responses[table] = append(responses[table], items...)
+			continue
+		}
+		names := keyNames(remaining[table].Keys)
+		c.learn(table, names)
+		for _, item := range items {
+			c.Store.Set(cacheKey(table, project(item, names)), CacheEntry{Item: copyItem(item), Stored: c.clock()})
+		}
+		responses[table] = append(responses[table], items...)
+	}
+	output.Responses = responses
+
+	return output, nil
+}
+
+type cachedKey struct {
+	key   map[string]types.AttributeValue
+	entry CacheEntry
+}
+
+// serveStale answers a batch whose read failed with err from the servable
+// entries in stale, returning the keys it cannot serve as UnprocessedKeys.
+func (c *CachingDynamoDBClient) serveStale(remaining map[string]types.KeysAndAttributes, responses map[string][]map[string]types.AttributeValue, stale map[string][]cachedKey, err error) *ddb.BatchGetItemOutput {
+	unprocessed := map[string]types.KeysAndAttributes{}
+	for table, ka := range remaining {
+		served := map[string]bool{}
+		for _, k := range stale[table] {
+			if c.servable(k.entry, err) {
+				responses[table] = append(responses[table], copyItem(k.entry.Item))
+				served[cacheKey(table, k.key)] = true
+			}
+		}
+
+		var keys []map[string]types.AttributeValue
+		for _, key := range ka.Keys {
+			if !served[cacheKey(table, key)] {
+				keys = append(keys, key)
+			}
+		}
+		if len(keys) > 0 {
+			ka.Keys = keys
+			unprocessed[table] = ka
+		}
+	}
+
+	return &ddb.BatchGetItemOutput{Responses: responses, UnprocessedKeys: unprocessed}
+}
+
+// PutItem evicts the item it writes if c has cached items of its table, and
+// so knows the names of the table's key attributes.
+func (c *CachingDynamoDBClient) PutItem(ctx context.Context, input *ddb.PutItemInput, o ...func(*ddb.Options)) (*ddb.PutItemOutput, error) {
+	table := aws.ToString(input.TableName)
+	if names, ok := c.keys.Load(table); ok {
+		defer c.Store.Delete(cacheKey(table, project(input.Item, names.([]string))))
+	}
+
+	return c.DynamoDBClient.PutItem(ctx, input, o...)
+}
+
+func (c *CachingDynamoDBClient) DeleteItem(ctx context.Context, input *ddb.DeleteItemInput, o ...func(*ddb.Options)) (*ddb.DeleteItemOutput, error) {
+	defer c.Store.Delete(cacheKey(aws.ToString(input.TableName), input.Key))
+
+	return c.DynamoDBClient.DeleteItem(ctx, input, o...)
+}
+
+func (c *CachingDynamoDBClient) UpdateItem(ctx context.Context, input *ddb.UpdateItemInput, o ...func(*ddb.Options)) (*ddb.UpdateItemOutput, error) {
+	defer c.Store.Delete(cacheKey(aws.ToString(input.TableName), input.Key))
+
+	return c.DynamoDBClient.UpdateItem(ctx, input, o...)
+}
+
+// learn records names as the names of the key attributes of table.
+func (c *CachingDynamoDBClient) learn(table string, names []string) {
+	if len(names) > 0 {
+		c.keys.Store(table, names)
+	}
+}
+
+func (c *CachingDynamoDBClient) clock() time.Time {
+	if c.Clock != nil {
+		return c.Clock.Now()
+	}
+
+	return time.Now()
+}
+
+// fresh reports whether entry can be served without reading the item again.
+func (c *CachingDynamoDBClient) fresh(entry CacheEntry) bool {
+	return c.clock().Sub(entry.Stored) < c.TTL
+}
+
+// servable reports whether entry can be served in place of a read that
+// failed with err.
+func (c *CachingDynamoDBClient) servable(entry CacheEntry, err error) bool {
+	if !IsThrottlingError(err) {
+		return false
+	}
+
+	return c.StaleTTL <= 0 || c.clock().Sub(entry.Stored) < c.StaleTTL
+}
+
+// cacheKey encodes the table and key of an item as a string, independently
+// of the order of the key's attributes.
+func cacheKey(table string, key map[string]types.AttributeValue) string {
+	names := make([]string, 0, len(key))
+	for name := range key {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString(table)
+	for _, name := range names {
+		b.WriteByte(0)
+		b.WriteString(name)
+		b.WriteByte(0)
+		switch v := key[name].(type) {
+		case *types.AttributeValueMemberS:
+			b.WriteString("S:" + v.Value)
+		case *types.AttributeValueMemberN:
+			b.WriteString("N:" + v.Value)
+		case *types.AttributeValueMemberB:
+			b.WriteString("B:" + base64.StdEncoding.EncodeToString(v.Value))
+		}
+	}
+
+	return b.String()
+}
+
+// keyNames returns the names of the attributes making up keys, all of which
+// belong to the same table.
+func keyNames(keys []map[string]types.AttributeValue) []string {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(keys[0]))
+	for name := range keys[0] {
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// project returns the attributes of item with the given names.
+func project(item map[string]types.AttributeValue, names []string) map[string]types.AttributeValue {
+	key := make(map[string]types.AttributeValue, len(names))
+	for _, name := range names {
+		if v, ok := item[name]; ok {
+			key[name] = v
+		}
+	}
+
+	return key
+}
+
+// copyItem returns a deep copy of item, sharing no maps, slices or attribute
+// values with it.
+func copyItem(item map[string]types.AttributeValue) map[string]types.AttributeValue {
+	copied := make(map[string]types.AttributeValue, len(item))
+	for k, v := range item {
+		copied[k] = copyAttributeValue(v)
+	}
+
+	return copied
+}
+
+func copyAttributeValue(v types.AttributeValue) types.AttributeValue {
+	switch v := v.(type) {
+	case *types.AttributeValueMemberS:
+		return &types.AttributeValueMemberS{Value: v.Value}
+	case *types.AttributeValueMemberN:
+		return &types.AttributeValueMemberN{Value: v.Value}
+	case *types.AttributeValueMemberB:
+		return &types.AttributeValueMemberB{Value: append([]byte(nil), v.Value...)}
+	case *types.AttributeValueMemberBOOL:
+		return &types.AttributeValueMemberBOOL{Value: v.Value}
+	case *types.AttributeValueMemberNULL:
+		return &types.AttributeValueMemberNULL{Value: v.Value}
+	case *types.AttributeValueMemberSS:
+		return &types.AttributeValueMemberSS{Value: append([]string(nil), v.Value...)}
+	case *types.AttributeValueMemberNS:
+		return &types.AttributeValueMemberNS{Value: append([]string(nil), v.Value...)}
+	case *types.AttributeValueMemberBS:
+		values := make([][]byte, len(v.Value))
+		for i, b := range v.Value {
+			values[i] = append([]byte(nil), b...)
+		}
+		return &types.AttributeValueMemberBS{Value: values}
+	case *types.AttributeValueMemberL:
+		values := make([]types.AttributeValue, len(v.Value))
+		for i, e := range v.Value {
+			values[i] = copyAttributeValue(e)
+		}
+		return &types.AttributeValueMemberL{Value: values}
+	case *types.AttributeValueMemberM:
+		return &types.AttributeValueMemberM{Value: copyItem(v.Value)}
+	default:
+		return v
+	}
+}
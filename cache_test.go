@@ -0,0 +1,248 @@
+package ddbretry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry/ddbretrytest"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// itemsDynamoDBClient serves items from a single table, keyed by their "id"
+// attribute, failing every read with err when it is set.
+type itemsDynamoDBClient struct {
+	ddbretrytest.FakeClient
+	items map[string]map[string]types.AttributeValue
+	err   error
+	reads int
+	keys  int
+}
+
+func (c *itemsDynamoDBClient) GetItem(ctx context.Context, input *ddb.GetItemInput, o ...func(*ddb.Options)) (*ddb.GetItemOutput, error) {
+	c.reads++
+	c.keys++
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	return &ddb.GetItemOutput{Item: c.items[input.Key["id"].(*types.AttributeValueMemberS).Value]}, nil
+}
+
+func (c *itemsDynamoDBClient) BatchGetItem(ctx context.Context, input *ddb.BatchGetItemInput, o ...func(*ddb.Options)) (*ddb.BatchGetItemOutput, error) {
+	c.reads++
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	output := &ddb.BatchGetItemOutput{Responses: map[string][]map[string]types.AttributeValue{}}
+	for table, ka := range input.RequestItems {
+		for _, key := range ka.Keys {
+			c.keys++
+			if item, ok := c.items[key["id"].(*types.AttributeValueMemberS).Value]; ok {
+				output.Responses[table] = append(output.Responses[table], item)
+			}
+		}
+	}
+
+	return output, nil
+}
+
+func (c *itemsDynamoDBClient) PutItem(ctx context.Context, input *ddb.PutItemInput, o ...func(*ddb.Options)) (*ddb.PutItemOutput, error) {
+	c.items[input.Item["id"].(*types.AttributeValueMemberS).Value] = input.Item
+
+	return &ddb.PutItemOutput{}, nil
+}
+
+func testItem(id string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"id":   &types.AttributeValueMemberS{Value: id},
+		"name": &types.AttributeValueMemberS{Value: "name-" + id},
+	}
+}
+
+func testKey(id string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: id}}
+}
+
+func TestCachingDynamoDBClient_GetItem(t *testing.T) {
+	tests := []struct {
+		name           string
+		age            time.Duration
+		staleTTL       time.Duration
+		err            error
+		consistentRead bool
+		wantErr        bool
+		wantReads      int
+	}{
+		{
+			name:      "should serve fresh items without a request",
+			age:       time.Second,
+			wantReads: 0,
+		},
+		{
+			name:      "should read expired items again",
+			age:       time.Minute,
+			wantReads: 1,
+		},
+		{
+			name:      "should serve expired items when the read is throttled",
+			age:       time.Minute,
+			err:       &types.ProvisionedThroughputExceededException{},
+			wantReads: 1,
+		},
+		{
+			name:      "should not serve items older than StaleTTL",
+			age:       time.Hour,
+			staleTTL:  time.Minute,
+			err:       &types.ProvisionedThroughputExceededException{},
+			wantErr:   true,
+			wantReads: 1,
+		},
+		{
+			name:      "should not serve expired items for other errors",
+			age:       time.Minute,
+			err:       &types.InternalServerError{},
+			wantErr:   true,
+			wantReads: 1,
+		},
+		{
+			name:           "should not serve strongly consistent reads",
+			age:            time.Second,
+			consistentRead: true,
+			wantReads:      1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := ddbretrytest.NewFakeClock(time.Unix(1700000000, 0))
+			client := &itemsDynamoDBClient{items: map[string]map[string]types.AttributeValue{"1": testItem("1")}}
+			c := NewCachingDynamoDBClient(client, nil, 10*time.Second)
+			c.StaleTTL = tt.staleTTL
+			c.Clock = clock
+			input := &ddb.GetItemInput{TableName: aws.String("users"), Key: testKey("1")}
+
+			_, err := c.GetItem(context.Background(), input)
+			assert.NoError(t, err)
+			clock.Advance(tt.age)
+			client.err = tt.err
+			client.reads = 0
+
+			input.ConsistentRead = aws.Bool(tt.consistentRead)
+			output, err := c.GetItem(context.Background(), input)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.wantReads, client.reads)
+			if !tt.wantErr {
+				assert.Equal(t, testItem("1"), output.Item)
+			}
+		})
+	}
+}
+
+func TestCachingDynamoDBClient_BatchGetItem(t *testing.T) {
+	clock := ddbretrytest.NewFakeClock(time.Unix(1700000000, 0))
+	client := &itemsDynamoDBClient{items: map[string]map[string]types.AttributeValue{
+		"1": testItem("1"),
+		"2": testItem("2"),
+		"3": testItem("3"),
+	}}
+	c := NewCachingDynamoDBClient(client, nil, 10*time.Second)
+	c.Clock = clock
+	batch := func(ids ...string) *ddb.BatchGetItemInput {
+		var keys []map[string]types.AttributeValue
+		for _, id := range ids {
+			keys = append(keys, testKey(id))
+		}
+		return &ddb.BatchGetItemInput{RequestItems: map[string]types.KeysAndAttributes{"users": {Keys: keys}}}
+	}
+
+	_, err := c.BatchGetItem(context.Background(), batch("1", "2"))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, client.keys)
+
+	output, err := c.BatchGetItem(context.Background(), batch("1", "2", "3"))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, client.keys)
+	assert.ElementsMatch(t, []map[string]types.AttributeValue{testItem("1"), testItem("2"), testItem("3")}, output.Responses["users"])
+
+	clock.Advance(time.Minute)
+	client.err = &types.ProvisionedThroughputExceededException{}
+	client.items["4"] = testItem("4")
+	output, err = c.BatchGetItem(context.Background(), batch("1", "4"))
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]types.AttributeValue{testItem("1")}, output.Responses["users"])
+	assert.Equal(t, []map[string]types.AttributeValue{testKey("4")}, output.UnprocessedKeys["users"].Keys)
+
+	_, err = c.BatchGetItem(context.Background(), batch("4"))
+	assert.True(t, IsProvisionedThroughputExceededException(err))
+}
+
+func TestCachingDynamoDBClient_evict(t *testing.T) {
+	client := &itemsDynamoDBClient{items: map[string]map[string]types.AttributeValue{"1": testItem("1")}}
+	c := NewCachingDynamoDBClient(client, nil, time.Minute)
+	input := &ddb.GetItemInput{TableName: aws.String("users"), Key: testKey("1")}
+
+	_, err := c.GetItem(context.Background(), input)
+	assert.NoError(t, err)
+	_, err = c.UpdateItem(context.Background(), &ddb.UpdateItemInput{TableName: aws.String("users"), Key: testKey("1")})
+	assert.NoError(t, err)
+	_, err = c.GetItem(context.Background(), input)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, client.reads)
+}
+
+func TestCachingDynamoDBClient_PutItem(t *testing.T) {
+	client := &itemsDynamoDBClient{items: map[string]map[string]types.AttributeValue{"1": testItem("1")}}
+	c := NewCachingDynamoDBClient(client, nil, time.Minute)
+	input := &ddb.GetItemInput{TableName: aws.String("users"), Key: testKey("1")}
+
+	_, err := c.GetItem(context.Background(), input)
+	assert.NoError(t, err)
+
+	item := testItem("1")
+	item["name"] = &types.AttributeValueMemberS{Value: "renamed"}
+	_, err = c.PutItem(context.Background(), &ddb.PutItemInput{TableName: aws.String("users"), Item: item})
+	assert.NoError(t, err)
+
+	output, err := c.GetItem(context.Background(), input)
+	assert.NoError(t, err)
+	assert.Equal(t, item, output.Item)
+	assert.Equal(t, 2, client.reads)
+}
+
+func TestLRUCache(t *testing.T) {
+	c := NewLRUCache(2)
+	c.Set("a", CacheEntry{})
+	c.Set("b", CacheEntry{})
+	_, ok := c.Get("a")
+	assert.True(t, ok)
+
+	c.Set("c", CacheEntry{})
+	_, ok = c.Get("b")
+	assert.False(t, ok)
+	_, ok = c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 2, c.Len())
+
+	c.Delete("a")
+	_, ok = c.Get("a")
+	assert.False(t, ok)
+}
+
+func TestCacheKey(t *testing.T) {
+	a := map[string]types.AttributeValue{
+		"pk": &types.AttributeValueMemberS{Value: "user"},
+		"sk": &types.AttributeValueMemberN{Value: "1"},
+	}
+	b := map[string]types.AttributeValue{
+		"sk": &types.AttributeValueMemberN{Value: "1"},
+		"pk": &types.AttributeValueMemberS{Value: "user"},
+	}
+
+	assert.Equal(t, cacheKey("users", a), cacheKey("users", b))
+	assert.NotEqual(t, cacheKey("users", a), cacheKey("orders", a))
+	assert.NotEqual(t, cacheKey("users", a), cacheKey("users", testKey("user")))
+}
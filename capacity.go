@@ -0,0 +1,116 @@
+package ddbretry
+
+import (
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// consumedCapacity returns the capacity reported by an operation output, if
+// the input asked for it with ReturnConsumedCapacity.
+func consumedCapacity(output interface{}) *types.ConsumedCapacity {
+	switch output := output.(type) {
+	case *ddb.GetItemOutput:
+		if output != nil {
+			return output.ConsumedCapacity
+		}
+	case *ddb.DeleteItemOutput:
+		if output != nil {
+			return output.ConsumedCapacity
+		}
+	case *ddb.PutItemOutput:
+		if output != nil {
+			return output.ConsumedCapacity
+		}
+	case *ddb.UpdateItemOutput:
+		if output != nil {
+			return output.ConsumedCapacity
+		}
+	case *ddb.QueryOutput:
+		if output != nil {
+			return output.ConsumedCapacity
+		}
+	case *ddb.ScanOutput:
+		if output != nil {
+			return output.ConsumedCapacity
+		}
+	}
+
+	return nil
+}
+
+// addConsumedCapacity adds the units in c to total.
+func addConsumedCapacity(total *types.ConsumedCapacity, c *types.ConsumedCapacity) {
+	if total.TableName == nil {
+		total.TableName = c.TableName
+	}
+	total.CapacityUnits = addUnits(total.CapacityUnits, c.CapacityUnits)
+	total.ReadCapacityUnits = addUnits(total.ReadCapacityUnits, c.ReadCapacityUnits)
+	total.WriteCapacityUnits = addUnits(total.WriteCapacityUnits, c.WriteCapacityUnits)
+
+	if c.Table != nil {
+		if total.Table == nil {
+			total.Table = &types.Capacity{}
+		}
+		addCapacity(total.Table, c.Table)
+	}
+	total.GlobalSecondaryIndexes = addIndexCapacity(total.GlobalSecondaryIndexes, c.GlobalSecondaryIndexes)
+	total.LocalSecondaryIndexes = addIndexCapacity(total.LocalSecondaryIndexes, c.LocalSecondaryIndexes)
+}
+
+func addCapacity(total *types.Capacity, c *types.Capacity) {
+	total.CapacityUnits = addUnits(total.CapacityUnits, c.CapacityUnits)
+	total.ReadCapacityUnits = addUnits(total.ReadCapacityUnits, c.ReadCapacityUnits)
+	total.WriteCapacityUnits = addUnits(total.WriteCapacityUnits, c.WriteCapacityUnits)
+}
+
+func addIndexCapacity(total map[string]types.Capacity, c map[string]types.Capacity) map[string]types.Capacity {
+	if len(c) == 0 {
+		return total
+	}
+	if total == nil {
+		total = make(map[string]types.Capacity, len(c))
+	}
+	for index, capacity := range c {
+		sum := total[index]
+		addCapacity(&sum, &capacity)
+		total[index] = sum
+	}
+
+	return total
+}
+
+func addUnits(total *float64, units *float64) *float64 {
+	if units == nil {
+		return total
+	}
+	sum := *units
+	if total != nil {
+		sum += *total
+	}
+
+	return &sum
+}
+
+// recordConsumedCapacity accumulates the capacity reported by an attempt.
+func (s *retryState) recordConsumedCapacity(output interface{}) {
+	if s.client.OnConsumedCapacity == nil {
+		return
+	}
+	c := consumedCapacity(output)
+	if c == nil {
+		return
+	}
+
+	if s.capacity == nil {
+		s.capacity = &types.ConsumedCapacity{}
+	}
+	addConsumedCapacity(s.capacity, c)
+}
+
+// reportConsumedCapacity passes the capacity consumed by every attempt of the
+// call to OnConsumedCapacity, if any was reported.
+func (s *retryState) reportConsumedCapacity() {
+	if f := s.client.OnConsumedCapacity; f != nil && s.capacity != nil {
+		f(s.op, s.table, s.capacity, s.sent)
+	}
+}
@@ -0,0 +1,88 @@
+package ddbretry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddConsumedCapacity(t *testing.T) {
+	total := &types.ConsumedCapacity{}
+	c := &types.ConsumedCapacity{
+		TableName:     aws.String("users"),
+		CapacityUnits: aws.Float64(1.5),
+		Table: &types.Capacity{
+			CapacityUnits: aws.Float64(1),
+		},
+		GlobalSecondaryIndexes: map[string]types.Capacity{
+			"by-email": {CapacityUnits: aws.Float64(0.5)},
+		},
+	}
+
+	addConsumedCapacity(total, c)
+	addConsumedCapacity(total, c)
+
+	assert.Equal(t, &types.ConsumedCapacity{
+		TableName:     aws.String("users"),
+		CapacityUnits: aws.Float64(3),
+		Table: &types.Capacity{
+			CapacityUnits: aws.Float64(2),
+		},
+		GlobalSecondaryIndexes: map[string]types.Capacity{
+			"by-email": {CapacityUnits: aws.Float64(1)},
+		},
+	}, total)
+	assert.Equal(t, 1.5, *c.CapacityUnits)
+}
+
+// CapacityDynamoDBClient answers each PutItem with an output reporting the
+// next of Units, with a nil entry standing for a throttled attempt.
+type CapacityDynamoDBClient struct {
+	DynamoDBClient
+	Units []*float64
+}
+
+func (c *CapacityDynamoDBClient) PutItem(ctx context.Context, input *ddb.PutItemInput, o ...func(*ddb.Options)) (*ddb.PutItemOutput, error) {
+	units := c.Units[0]
+	c.Units = c.Units[1:]
+	if units == nil {
+		return nil, &types.ProvisionedThroughputExceededException{}
+	}
+
+	return &ddb.PutItemOutput{
+		ConsumedCapacity: &types.ConsumedCapacity{
+			TableName:     input.TableName,
+			CapacityUnits: units,
+		},
+	}, nil
+}
+
+func TestRetryDynamoDBClient_OnConsumedCapacity(t *testing.T) {
+	var total *types.ConsumedCapacity
+	var attempts int
+	c := &RetryDynamoDBClient{
+		DynamoDBClient: &CapacityDynamoDBClient{
+			Units: []*float64{nil, aws.Float64(2)},
+		},
+		Retries: 1,
+		OnConsumedCapacity: func(op string, table string, c *types.ConsumedCapacity, n int) {
+			assert.Equal(t, "PutItem", op)
+			assert.Equal(t, "users", table)
+			total, attempts = c, n
+		},
+	}
+
+	_, err := c.PutItem(context.Background(), &ddb.PutItemInput{
+		TableName:              aws.String("users"),
+		ReturnConsumedCapacity: types.ReturnConsumedCapacityTotal,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+	if assert.NotNil(t, total) {
+		assert.Equal(t, 2.0, *total.CapacityUnits)
+	}
+}
@@ -0,0 +1,166 @@
+package ddbretry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+)
+
+// RetryDecision is the outcome of classifying a failed attempt.
+type RetryDecision int
+
+const (
+	// DoNotRetry fails the call with the error.
+	DoNotRetry RetryDecision = iota
+	// Retry retries the call after the usual backoff.
+	Retry
+	// RetryWithLongBackOff retries the call after longBackOffMultiplier
+	// times the usual backoff.
+	RetryWithLongBackOff
+)
+
+const longBackOffMultiplier = 10
+
+// ErrorClassifier decides whether, and how, a failed attempt is retried.
+type ErrorClassifier interface {
+	Classify(err error) RetryDecision
+}
+
+type ErrorClassifierFunc func(err error) RetryDecision
+
+func (f ErrorClassifierFunc) Classify(err error) RetryDecision {
+	return f(err)
+}
+
+// ErrorKind is a coarse category of failure, used to count retries of
+// different causes separately.
+type ErrorKind int
+
+const (
+	OtherErrorKind ErrorKind = iota
+	ThrottlingErrorKind
+	InternalServerErrorKind
+	NetworkErrorKind
+	LimitExceededErrorKind
+	TransactionConflictErrorKind
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case ThrottlingErrorKind:
+		return "throttling"
+	case InternalServerErrorKind:
+		return "internal_server_error"
+	case NetworkErrorKind:
+		return "network"
+	case LimitExceededErrorKind:
+		return "limit_exceeded"
+	case TransactionConflictErrorKind:
+		return "transaction_conflict"
+	default:
+		return "other"
+	}
+}
+
+func ErrorKindOf(err error) ErrorKind {
+	switch {
+	case IsThrottlingError(err):
+		return ThrottlingErrorKind
+	case IsInternalServerError(err):
+		return InternalServerErrorKind
+	case IsNetworkError(err):
+		return NetworkErrorKind
+	case IsLimitExceededException(err):
+		return LimitExceededErrorKind
+	case IsTransactionConflictException(err):
+		return TransactionConflictErrorKind
+	default:
+		return OtherErrorKind
+	}
+}
+
+// IsNetworkError reports whether err is a transport failure that happened
+// before DynamoDB responded, such as a connection reset, refused connection
+// or network timeout. Context cancellations and deadlines are not network
+// errors, even though context.DeadlineExceeded implements net.Error.
+func IsNetworkError(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var connectionError interface{ ConnectionError() bool }
+	if errors.As(err, &connectionError) && connectionError.ConnectionError() {
+		return true
+	}
+
+	var netError net.Error
+	if errors.As(err, &netError) {
+		return true
+	}
+
+	return errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// DefaultErrorClassifier is used when a client has no Classifier set. It
+// retries throttling errors (see IsThrottlingError) and, when enabled,
+// InternalServerError, network errors and TransactionConflictException, which
+// concurrent transactional writes routinely hit. LimitExceededException,
+// returned by control-plane operations when too many are in progress, is
+// retried with a long backoff since those quotas take seconds rather than
+// milliseconds to recover.
+type DefaultErrorClassifier struct {
+	RetryInternalServerErrors bool
+	RetryNetworkErrors        bool
+	RetryTransactionConflicts bool
+}
+
+func (c DefaultErrorClassifier) Classify(err error) RetryDecision {
+	switch ErrorKindOf(err) {
+	case ThrottlingErrorKind:
+		return Retry
+	case LimitExceededErrorKind:
+		return RetryWithLongBackOff
+	case InternalServerErrorKind:
+		if c.RetryInternalServerErrors {
+			return Retry
+		}
+	case NetworkErrorKind:
+		if c.RetryNetworkErrors {
+			return Retry
+		}
+	case TransactionConflictErrorKind:
+		if c.RetryTransactionConflicts {
+			return Retry
+		}
+	}
+
+	return DoNotRetry
+}
+
+// classifier returns the ErrorClassifier used by the client.
+func (c *RetryDynamoDBClient) classifier() ErrorClassifier {
+	if c.Classifier != nil {
+		return c.Classifier
+	}
+
+	return DefaultErrorClassifier{
+		RetryInternalServerErrors: c.InternalServerErrorRetries > 0,
+		RetryNetworkErrors:        c.NetworkErrorRetries > 0,
+		RetryTransactionConflicts: c.RetryTransactionConflicts,
+	}
+}
+
+// IsRetryable reports whether DefaultErrorClassifier retries err, with none
+// of its optional error kinds enabled. Use RetryDynamoDBClient.IsRetryable to
+// follow a client's own classifier.
+func IsRetryable(err error) bool {
+	return err != nil && DefaultErrorClassifier{}.Classify(err) != DoNotRetry
+}
+
+// IsRetryable reports whether c's classifier would retry err, for code that
+// decides outside a call, such as a queue consumer choosing whether to
+// redeliver a message whose processing failed with err.
+func (c *RetryDynamoDBClient) IsRetryable(err error) bool {
+	return err != nil && c.classifier().Classify(err) != DoNotRetry
+}
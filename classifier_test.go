@@ -0,0 +1,339 @@
+package ddbretry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry/ddbretrytest"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultErrorClassifier(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want RetryDecision
+	}{
+		{
+			name: "should retry ProvisionedThroughputExceededException",
+			err:  &types.ProvisionedThroughputExceededException{},
+			want: Retry,
+		},
+		{
+			name: "should retry ThrottlingException",
+			err:  &smithy.GenericAPIError{Code: "ThrottlingException"},
+			want: Retry,
+		},
+		{
+			name: "should retry RequestLimitExceeded",
+			err:  &types.RequestLimitExceeded{},
+			want: Retry,
+		},
+		{
+			name: "should retry LimitExceededException with a long backoff",
+			err:  &types.LimitExceededException{},
+			want: RetryWithLongBackOff,
+		},
+		{
+			name: "should retry LimitExceededException from other services by its code",
+			err:  &smithy.GenericAPIError{Code: "LimitExceededException"},
+			want: RetryWithLongBackOff,
+		},
+		{
+			name: "should not retry other errors",
+			err:  errors.New("foo"),
+			want: DoNotRetry,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, DefaultErrorClassifier{}.Classify(tt.err))
+		})
+	}
+}
+
+func TestRetryDynamoDBClient_Classifier(t *testing.T) {
+	errRetryable := errors.New("retryable")
+	errSlow := errors.New("slow")
+	classifier := ErrorClassifierFunc(func(err error) RetryDecision {
+		switch {
+		case errors.Is(err, errRetryable):
+			return Retry
+		case errors.Is(err, errSlow):
+			return RetryWithLongBackOff
+		default:
+			return DoNotRetry
+		}
+	})
+
+	tests := []struct {
+		name        string
+		errs        []error
+		backOffTime time.Duration
+		wantErr     func(err error) bool
+	}{
+		{
+			name: "should retry errors classified as retryable",
+			errs: []error{errRetryable, errRetryable},
+			wantErr: func(err error) bool {
+				return err == nil
+			},
+		},
+		{
+			name: "should not retry errors the classifier rejects",
+			errs: []error{&types.ProvisionedThroughputExceededException{}},
+			wantErr: func(err error) bool {
+				return IsProvisionedThroughputExceededException(err)
+			},
+		},
+		{
+			name:        "should use a longer backoff when requested",
+			errs:        []error{errSlow},
+			backOffTime: time.Minute,
+			wantErr: func(err error) bool {
+				var backOffDeadlineError *BackOffDeadlineError
+				return errors.As(err, &backOffDeadlineError) && backOffDeadlineError.Delay == 10*time.Minute
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &RetryDynamoDBClient{
+				DynamoDBClient: ddbretrytest.NewFakeClient().Script("GetItem", tt.errs...),
+				Retries:        3,
+				BackOffTime:    tt.backOffTime,
+				Classifier:     classifier,
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			defer cancel()
+
+			_, err := c.GetItem(ctx, &ddb.GetItemInput{})
+			assert.True(t, tt.wantErr(err), "unexpected error: %v", err)
+		})
+	}
+}
+
+func TestRetryDynamoDBClient_InternalServerErrorRetries(t *testing.T) {
+	tests := []struct {
+		name                       string
+		retries                    int
+		internalServerErrorRetries int
+		errs                       []error
+		wantErr                    bool
+	}{
+		{
+			name:                       "should not retry InternalServerError by default",
+			retries:                    3,
+			internalServerErrorRetries: 0,
+			errs:                       []error{&types.InternalServerError{}},
+			wantErr:                    true,
+		},
+		{
+			name:                       "should retry InternalServerError when enabled",
+			retries:                    0,
+			internalServerErrorRetries: 2,
+			errs:                       []error{&types.InternalServerError{}, &types.InternalServerError{}},
+			wantErr:                    false,
+		},
+		{
+			name:                       "should give up once InternalServerErrorRetries is used up",
+			retries:                    5,
+			internalServerErrorRetries: 1,
+			errs:                       []error{&types.InternalServerError{}, &types.InternalServerError{}},
+			wantErr:                    true,
+		},
+		{
+			name:                       "should count throttles and InternalServerError separately",
+			retries:                    1,
+			internalServerErrorRetries: 1,
+			errs: []error{
+				&types.InternalServerError{},
+				&types.ProvisionedThroughputExceededException{},
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &RetryDynamoDBClient{
+				DynamoDBClient:             ddbretrytest.NewFakeClient().Script("PutItem", tt.errs...),
+				Retries:                    tt.retries,
+				InternalServerErrorRetries: tt.internalServerErrorRetries,
+			}
+
+			_, err := c.PutItem(context.Background(), &ddb.PutItemInput{})
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestErrorKindOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorKind
+	}{
+		{
+			name: "should classify throttling errors",
+			err:  &types.ProvisionedThroughputExceededException{},
+			want: ThrottlingErrorKind,
+		},
+		{
+			name: "should classify InternalServerError",
+			err:  &types.InternalServerError{},
+			want: InternalServerErrorKind,
+		},
+		{
+			name: "should classify request send errors as network errors",
+			err:  &smithyhttp.RequestSendError{Err: syscall.ECONNRESET},
+			want: NetworkErrorKind,
+		},
+		{
+			name: "should classify net.OpError as network error",
+			err:  &net.OpError{Op: "read", Err: syscall.ECONNRESET},
+			want: NetworkErrorKind,
+		},
+		{
+			name: "should classify unexpected EOF as network error",
+			err:  fmt.Errorf("reading response: %w", io.ErrUnexpectedEOF),
+			want: NetworkErrorKind,
+		},
+		{
+			name: "should not classify deadlines as network errors",
+			err:  fmt.Errorf("attempt: %w", context.DeadlineExceeded),
+			want: OtherErrorKind,
+		},
+		{
+			name: "should not classify cancellations as network errors",
+			err:  &smithyhttp.RequestSendError{Err: context.Canceled},
+			want: OtherErrorKind,
+		},
+		{
+			name: "should classify LimitExceededException",
+			err:  &types.LimitExceededException{},
+			want: LimitExceededErrorKind,
+		},
+		{
+			name: "should classify TransactionConflictException",
+			err:  &types.TransactionConflictException{},
+			want: TransactionConflictErrorKind,
+		},
+		{
+			name: "should classify TransactionConflictException by its code",
+			err:  &smithy.GenericAPIError{Code: "TransactionConflictException"},
+			want: TransactionConflictErrorKind,
+		},
+		{
+			name: "should classify anything else as other",
+			err:  errors.New("foo"),
+			want: OtherErrorKind,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ErrorKindOf(tt.err))
+		})
+	}
+}
+
+func TestRetryDynamoDBClient_NetworkErrorRetries(t *testing.T) {
+	networkError := &smithyhttp.RequestSendError{Err: syscall.ECONNRESET}
+	tests := []struct {
+		name                string
+		networkErrorRetries int
+		errs                []error
+		wantErr             bool
+	}{
+		{
+			name:                "should not retry network errors by default",
+			networkErrorRetries: 0,
+			errs:                []error{networkError},
+			wantErr:             true,
+		},
+		{
+			name:                "should retry network errors when enabled",
+			networkErrorRetries: 2,
+			errs:                []error{networkError, networkError},
+			wantErr:             false,
+		},
+		{
+			name:                "should give up once NetworkErrorRetries is used up",
+			networkErrorRetries: 1,
+			errs:                []error{networkError, networkError},
+			wantErr:             true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &RetryDynamoDBClient{
+				DynamoDBClient:      ddbretrytest.NewFakeClient().Script("GetItem", tt.errs...),
+				Retries:             3,
+				NetworkErrorRetries: tt.networkErrorRetries,
+			}
+
+			_, err := c.GetItem(context.Background(), &ddb.GetItemInput{})
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestRetryDynamoDBClient_RetryTransactionConflicts(t *testing.T) {
+	tests := []struct {
+		name                      string
+		retryTransactionConflicts bool
+		wantErr                   bool
+	}{
+		{
+			name:                      "should not retry TransactionConflictException by default",
+			retryTransactionConflicts: false,
+			wantErr:                   true,
+		},
+		{
+			name:                      "should retry TransactionConflictException when enabled",
+			retryTransactionConflicts: true,
+			wantErr:                   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &RetryDynamoDBClient{
+				DynamoDBClient:            ddbretrytest.NewFakeClient().Script("PutItem", &types.TransactionConflictException{}),
+				Retries:                   1,
+				RetryTransactionConflicts: tt.retryTransactionConflicts,
+			}
+
+			_, err := c.PutItem(context.Background(), &ddb.PutItemInput{})
+			assert.Equal(t, tt.wantErr, err != nil)
+			if err != nil {
+				assert.True(t, IsTransactionConflictException(err))
+			}
+		})
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	c := &RetryDynamoDBClient{RetryTransactionConflicts: true}
+	conflict := &types.TransactionConflictException{}
+
+	assert.True(t, IsRetryable(&types.ProvisionedThroughputExceededException{}))
+	assert.False(t, IsRetryable(conflict))
+	assert.False(t, IsRetryable(nil))
+	assert.True(t, c.IsRetryable(conflict))
+	assert.False(t, c.IsRetryable(errors.New("validation failed")))
+
+	c.Classifier = ErrorClassifierFunc(func(err error) RetryDecision {
+		return DoNotRetry
+	})
+	assert.False(t, c.IsRetryable(&types.ProvisionedThroughputExceededException{}))
+}
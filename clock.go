@@ -0,0 +1,56 @@
+package ddbretry
+
+import (
+	"context"
+	"time"
+)
+
+// Clock tells the time and waits out backoff delays for the retry loop, so
+// tests can replace the real clock with a fake one.
+type Clock interface {
+	Now() time.Time
+	// Sleep pauses for d, returning early with the context's error if ctx
+	// is done first.
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) Sleep(ctx context.Context, d time.Duration) error {
+	return sleep(ctx, d)
+}
+
+func (c *RetryDynamoDBClient) clock() Clock {
+	if c.Clock != nil {
+		return c.Clock
+	}
+
+	return realClock{}
+}
+
+// clockSource gives the components a client shares with other clients, such
+// as its CircuitBreaker, the time on the Clock of the client that last called
+// them, falling back to the real time before any has. Its methods must be
+// called with the component's lock held.
+type clockSource struct {
+	clock Clock
+}
+
+// use makes clock, unless nil, the source of the time.
+func (s *clockSource) use(clock Clock) {
+	if clock != nil {
+		s.clock = clock
+	}
+}
+
+func (s *clockSource) now() time.Time {
+	if s.clock != nil {
+		return s.clock.Now()
+	}
+
+	return time.Now()
+}
@@ -0,0 +1,60 @@
+package ddbretry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry/ddbretrytest"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryDynamoDBClient_Clock(t *testing.T) {
+	tests := []struct {
+		name           string
+		maxElapsedTime time.Duration
+		wantErr        bool
+		wantSleeps     []time.Duration
+	}{
+		{
+			name:       "should sleep the backoff schedule on the clock",
+			wantErr:    false,
+			wantSleeps: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second},
+		},
+		{
+			name:           "should measure MaxElapsedTime on the clock",
+			maxElapsedTime: 5 * time.Second,
+			wantErr:        true,
+			wantSleeps:     []time.Duration{time.Second, 2 * time.Second},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Unix(1700000000, 0)
+			clock := ddbretrytest.NewFakeClock(start)
+			c := &RetryDynamoDBClient{
+				DynamoDBClient:  ddbretrytest.NewFakeClient().Script("GetItem", ddbretrytest.Throttles(3)...),
+				Retries:         3,
+				BackOffTime:     time.Second,
+				BackOffStrategy: ExponentialBackOff,
+				MaxElapsedTime:  tt.maxElapsedTime,
+				Clock:           clock,
+			}
+
+			_, err := c.GetItem(context.Background(), &ddb.GetItemInput{})
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.wantSleeps, clock.Sleeps())
+			assert.Equal(t, sum(tt.wantSleeps), clock.Now().Sub(start))
+		})
+	}
+}
+
+func sum(ds []time.Duration) time.Duration {
+	var total time.Duration
+	for _, d := range ds {
+		total += d
+	}
+
+	return total
+}
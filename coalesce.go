@@ -0,0 +1,143 @@
+package ddbretry
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// Coalescer merges concurrent GetItem calls for the same item into a single
+// call, retries included, whose result they all share. This saves the read
+// capacity of the duplicates and stops them from multiplying the retries
+// sent while a hot key is throttled. Calls only merge when their inputs read
+// the item the same way, but the per-call options of the call that went
+// first apply to all of them. A call that merges into one already in flight
+// returns early if its own context ends, but otherwise shares that call's
+// outcome, including its cancellation.
+//
+// Window extends merging past the end of a call: for Window after a call
+// succeeds, calls for the same item share its result instead of reading the
+// item again. A few milliseconds is enough to absorb a stampede on a hot key
+// whose reads return faster than the callers arrive, at the cost of serving
+// results up to Window old. Failed calls are never shared after they end.
+type Coalescer struct {
+	Window time.Duration
+
+	mu        sync.Mutex
+	flights   map[string]*flight
+	coalesced int64
+}
+
+type flight struct {
+	done   chan struct{}
+	output *ddb.GetItemOutput
+	err    error
+}
+
+// NewCoalescer returns a Coalescer. The zero value is also ready to use.
+func NewCoalescer() *Coalescer {
+	return &Coalescer{
+		flights: map[string]*flight{},
+	}
+}
+
+// Coalesced returns the number of calls that shared the result of another.
+func (g *Coalescer) Coalesced() int64 {
+	return atomic.LoadInt64(&g.coalesced)
+}
+
+// getItem calls fn, unless a call for the same item as input is in flight or
+// succeeded within Window, in which case it shares that call's result.
+func (g *Coalescer) getItem(ctx context.Context, input *ddb.GetItemInput, fn func() (*ddb.GetItemOutput, error)) (*ddb.GetItemOutput, error) {
+	key := coalesceKey(input)
+
+	g.mu.Lock()
+	if g.flights == nil {
+		g.flights = map[string]*flight{}
+	}
+	if f, ok := g.flights[key]; ok {
+		g.mu.Unlock()
+		atomic.AddInt64(&g.coalesced, 1)
+		select {
+		case <-f.done:
+			return f.result()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	f := &flight{done: make(chan struct{})}
+	g.flights[key] = f
+	g.mu.Unlock()
+
+	f.output, f.err = fn()
+	close(f.done)
+
+	if g.Window > 0 && f.err == nil {
+		time.AfterFunc(g.Window, func() { g.land(key, f) })
+	} else {
+		g.land(key, f)
+	}
+
+	return f.result()
+}
+
+// land stops calls for key from sharing the result of f.
+func (g *Coalescer) land(key string, f *flight) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.flights[key] == f {
+		delete(g.flights, key)
+	}
+}
+
+// result returns a deep copy of the flight's output, so that the callers
+// sharing it, the one that made the call included, cannot see each other's
+// changes to its item.
+func (f *flight) result() (*ddb.GetItemOutput, error) {
+	if f.output == nil {
+		return nil, f.err
+	}
+
+	output := *f.output
+	if output.Item != nil {
+		output.Item = copyItem(output.Item)
+	}
+
+	return &output, f.err
+}
+
+// coalesceKey encodes the item input reads and the parts of input that
+// change what the read returns.
+func coalesceKey(input *ddb.GetItemInput) string {
+	var b strings.Builder
+	b.WriteString(cacheKey(aws.ToString(input.TableName), input.Key))
+	b.WriteByte(0)
+	if aws.ToBool(input.ConsistentRead) {
+		b.WriteString("consistent")
+	}
+	b.WriteByte(0)
+	b.WriteString(aws.ToString(input.ProjectionExpression))
+	b.WriteByte(0)
+	b.WriteString(strings.Join(input.AttributesToGet, "\x01"))
+	b.WriteByte(0)
+	b.WriteString(string(input.ReturnConsumedCapacity))
+
+	names := make([]string, 0, len(input.ExpressionAttributeNames))
+	for placeholder, name := range input.ExpressionAttributeNames {
+		names = append(names, placeholder+"="+name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		b.WriteByte(0)
+		b.WriteString(name)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,172 @@
+package ddbretry
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry/ddbretrytest"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// blockingDynamoDBClient counts GetItem calls, holding each of them until
+// release is closed. The first throttles calls are throttled.
+type blockingDynamoDBClient struct {
+	ddbretrytest.FakeClient
+	release   chan struct{}
+	calls     int64
+	throttles int64
+}
+
+func (c *blockingDynamoDBClient) GetItem(ctx context.Context, input *ddb.GetItemInput, o ...func(*ddb.Options)) (*ddb.GetItemOutput, error) {
+	<-c.release
+	if n := atomic.AddInt64(&c.calls, 1); n <= c.throttles {
+		return nil, &types.ProvisionedThroughputExceededException{}
+	}
+
+	return &ddb.GetItemOutput{Item: testItem(input.Key["id"].(*types.AttributeValueMemberS).Value)}, nil
+}
+
+func TestRetryDynamoDBClient_Coalescer(t *testing.T) {
+	const callers = 10
+	client := &blockingDynamoDBClient{release: make(chan struct{}), throttles: 2}
+	c := &RetryDynamoDBClient{
+		DynamoDBClient: client,
+		Retries:        3,
+		BackOffTime:    time.Millisecond,
+		Coalescer:      NewCoalescer(),
+	}
+	input := &ddb.GetItemInput{TableName: aws.String("users"), Key: testKey("1")}
+
+	var wg sync.WaitGroup
+	outputs := make([]*ddb.GetItemOutput, callers)
+	errs := make([]error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			outputs[i], errs[i] = c.GetItem(context.Background(), input)
+		}(i)
+	}
+	assert.Eventually(t, func() bool {
+		return c.Coalescer.Coalesced() == callers-1
+	}, time.Second, time.Millisecond)
+	close(client.release)
+	wg.Wait()
+
+	assert.Equal(t, int64(3), atomic.LoadInt64(&client.calls))
+	for i := 0; i < callers; i++ {
+		assert.NoError(t, errs[i])
+		assert.Equal(t, testItem("1"), outputs[i].Item)
+	}
+
+	_, err := c.GetItem(context.Background(), &ddb.GetItemInput{TableName: aws.String("users"), Key: testKey("2")})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), atomic.LoadInt64(&client.calls))
+}
+
+func TestCoalescer_contextDone(t *testing.T) {
+	g := NewCoalescer()
+	input := &ddb.GetItemInput{TableName: aws.String("users"), Key: testKey("1")}
+	release := make(chan struct{})
+	go g.getItem(context.Background(), input, func() (*ddb.GetItemOutput, error) {
+		<-release
+		return &ddb.GetItemOutput{}, nil
+	})
+	defer close(release)
+	assert.Eventually(t, func() bool {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		return len(g.flights) == 1
+	}, time.Second, time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := g.getItem(ctx, input, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestCoalesceKey(t *testing.T) {
+	base := ddb.GetItemInput{TableName: aws.String("users"), Key: testKey("1")}
+	consistent := base
+	consistent.ConsistentRead = aws.Bool(true)
+	projected := base
+	projected.ProjectionExpression = aws.String("#n")
+	projected.ExpressionAttributeNames = map[string]string{"#n": "name"}
+
+	assert.Equal(t, coalesceKey(&base), coalesceKey(&ddb.GetItemInput{TableName: aws.String("users"), Key: testKey("1")}))
+	assert.NotEqual(t, coalesceKey(&base), coalesceKey(&consistent))
+	assert.NotEqual(t, coalesceKey(&base), coalesceKey(&projected))
+}
+
+func TestCoalescer_window(t *testing.T) {
+	g := NewCoalescer()
+	g.Window = 50 * time.Millisecond
+	input := &ddb.GetItemInput{TableName: aws.String("users"), Key: testKey("1")}
+
+	var calls int
+	fn := func() (*ddb.GetItemOutput, error) {
+		calls++
+		if calls == 1 {
+			return nil, &types.ProvisionedThroughputExceededException{}
+		}
+		return &ddb.GetItemOutput{Item: testItem("1")}, nil
+	}
+
+	_, err := g.getItem(context.Background(), input, fn)
+	assert.True(t, IsProvisionedThroughputExceededException(err))
+
+	for i := 0; i < 3; i++ {
+		output, err := g.getItem(context.Background(), input, fn)
+		assert.NoError(t, err)
+		assert.Equal(t, testItem("1"), output.Item)
+	}
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, int64(2), g.Coalesced())
+
+	assert.Eventually(t, func() bool {
+		_, _ = g.getItem(context.Background(), input, fn)
+		return calls == 3
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestCoalescer_zeroValue(t *testing.T) {
+	g := &Coalescer{Window: time.Millisecond}
+	input := &ddb.GetItemInput{TableName: aws.String("users"), Key: testKey("1")}
+
+	output, err := g.getItem(context.Background(), input, func() (*ddb.GetItemOutput, error) {
+		return &ddb.GetItemOutput{Item: testItem("1")}, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, testItem("1"), output.Item)
+}
+
+func TestCoalescer_deepCopy(t *testing.T) {
+	g := &Coalescer{Window: time.Minute}
+	input := &ddb.GetItemInput{TableName: aws.String("users"), Key: testKey("1")}
+	fn := func() (*ddb.GetItemOutput, error) {
+		return &ddb.GetItemOutput{Item: map[string]types.AttributeValue{
+			"tags": &types.AttributeValueMemberL{Value: []types.AttributeValue{
+				&types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+					"name": &types.AttributeValueMemberS{Value: "a"},
+				}},
+			}},
+		}}, nil
+	}
+
+	first, err := g.getItem(context.Background(), input, fn)
+	assert.NoError(t, err)
+	tag := first.Item["tags"].(*types.AttributeValueMemberL).Value[0].(*types.AttributeValueMemberM)
+	tag.Value["name"] = &types.AttributeValueMemberS{Value: "changed"}
+
+	second, err := g.getItem(context.Background(), input, fn)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), g.Coalesced())
+	tag = second.Item["tags"].(*types.AttributeValueMemberL).Value[0].(*types.AttributeValueMemberM)
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "a"}, tag.Value["name"])
+}
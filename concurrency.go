@@ -0,0 +1,55 @@
+package ddbretry
+
+import (
+	"context"
+)
+
+// ConcurrencyLimiter caps the number of attempts a client has in flight at
+// once, so that a crowd of callers retrying together cannot keep a table
+// throttled. Attempts wait for a free slot before being sent and give it back
+// when they complete; calls backing off between attempts hold no slot. A
+// ConcurrencyLimiter can be shared by several clients.
+type ConcurrencyLimiter struct {
+	slots chan struct{}
+}
+
+func NewConcurrencyLimiter(max int) *ConcurrencyLimiter {
+	if max < 1 {
+		max = 1
+	}
+
+	return &ConcurrencyLimiter{
+		slots: make(chan struct{}, max),
+	}
+}
+
+// InFlight returns the number of attempts holding a slot.
+func (l *ConcurrencyLimiter) InFlight() int {
+	return len(l.slots)
+}
+
+// acquire waits for a free slot, returning the context's error if ctx is
+// done first.
+func (l *ConcurrencyLimiter) acquire(ctx context.Context) error {
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// tryAcquire takes a free slot without waiting, reporting false if there is
+// none.
+func (l *ConcurrencyLimiter) tryAcquire() bool {
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (l *ConcurrencyLimiter) release() {
+	<-l.slots
+}
@@ -0,0 +1,82 @@
+package ddbretry
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry/ddbretrytest"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// concurrentDynamoDBClient records the most GetItem calls it has seen in
+// flight at once, throttling every other call.
+type concurrentDynamoDBClient struct {
+	ddbretrytest.FakeClient
+	inFlight int64
+	peak     int64
+	calls    int64
+}
+
+func (c *concurrentDynamoDBClient) GetItem(ctx context.Context, input *ddb.GetItemInput, o ...func(*ddb.Options)) (*ddb.GetItemOutput, error) {
+	n := atomic.AddInt64(&c.inFlight, 1)
+	defer atomic.AddInt64(&c.inFlight, -1)
+	for {
+		peak := atomic.LoadInt64(&c.peak)
+		if n <= peak || atomic.CompareAndSwapInt64(&c.peak, peak, n) {
+			break
+		}
+	}
+	time.Sleep(time.Millisecond)
+
+	if atomic.AddInt64(&c.calls, 1)%2 == 0 {
+		return nil, &types.ProvisionedThroughputExceededException{}
+	}
+
+	return &ddb.GetItemOutput{}, nil
+}
+
+func TestRetryDynamoDBClient_ConcurrencyLimiter(t *testing.T) {
+	client := &concurrentDynamoDBClient{}
+	c := &RetryDynamoDBClient{
+		DynamoDBClient:     client,
+		Infinite:           true,
+		BackOffTime:        time.Millisecond,
+		ConcurrencyLimiter: NewConcurrencyLimiter(3),
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.GetItem(context.Background(), &ddb.GetItemInput{})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, atomic.LoadInt64(&client.peak), int64(3))
+	assert.Equal(t, 0, c.ConcurrencyLimiter.InFlight())
+}
+
+func TestConcurrencyLimiter_contextDone(t *testing.T) {
+	l := NewConcurrencyLimiter(1)
+	assert.NoError(t, l.acquire(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c := &RetryDynamoDBClient{
+		DynamoDBClient:     ddbretrytest.NewFakeClient(),
+		ConcurrencyLimiter: l,
+	}
+	_, err := c.GetItem(ctx, &ddb.GetItemInput{})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	l.release()
+	assert.Equal(t, 0, l.InFlight())
+}
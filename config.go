@@ -0,0 +1,135 @@
+package ddbretry
+
+import (
+	"errors"
+	"time"
+)
+
+// Config holds the plain retry settings of a RetryDynamoDBClient, for
+// services that load them from their own configuration. The fields have the
+// same meaning as their counterparts on RetryDynamoDBClient; in particular, a
+// Config with neither Retries nor BackOffTime set applies the defaults.
+type Config struct {
+	DisableRetries                bool
+	Infinite                      bool
+	Retries                       int
+	MaxAttempts                   int
+	BackOffTime                   time.Duration
+	BackOffStrategy               BackOffStrategy
+	MaxBackOff                    time.Duration
+	InitialBackOff                time.Duration
+	ImmediateRetry                bool
+	MaxElapsedTime                time.Duration
+	HonorRetryAfter               bool
+	AttemptTimeout                time.Duration
+	HedgeDelay                    time.Duration
+	InternalServerErrorRetries    int
+	NetworkErrorRetries           int
+	RetryTransactionConflicts     bool
+	DowngradeConsistentReadsAfter int
+	RequireIdempotentWrites       bool
+	ValidateItemSize              bool
+	AggregateErrors               bool
+	Shadow                        bool
+}
+
+// Validate reports every invalid setting in the Config, joined with
+// errors.Join. Negative Retries other than InfiniteRetries are reported as an
+// InvalidRetryError, a negative BackOffTime as an InvalidBackOffError and
+// anything else as an InvalidConfigError.
+func (cfg Config) Validate() error {
+	var errs []error
+	if cfg.Retries < 0 && cfg.Retries != InfiniteRetries {
+		errs = append(errs, NewInvalidRetryError(cfg.Retries))
+	}
+	if cfg.DisableRetries && cfg.Infinite {
+		errs = append(errs, NewInvalidConfigError("Infinite", "must not be set with DisableRetries"))
+	}
+	switch {
+	case cfg.MaxAttempts < 0:
+		errs = append(errs, NewInvalidConfigError("MaxAttempts", "must not be negative"))
+	case cfg.MaxAttempts > 0 && cfg.Retries != 0:
+		errs = append(errs, NewInvalidConfigError("MaxAttempts", "must not be set with Retries"))
+	case cfg.MaxAttempts > 0 && cfg.Infinite:
+		errs = append(errs, NewInvalidConfigError("MaxAttempts", "must not be set with Infinite"))
+	}
+	if cfg.BackOffTime < 0 {
+		errs = append(errs, NewInvalidBackOffError(cfg.BackOffTime))
+	}
+	if cfg.BackOffStrategy < ConstantBackOff || cfg.BackOffStrategy > ExponentialBackOff {
+		errs = append(errs, NewInvalidConfigError("BackOffStrategy", "unknown strategy"))
+	}
+
+	durations := []struct {
+		field string
+		value time.Duration
+	}{
+		{"MaxBackOff", cfg.MaxBackOff},
+		{"InitialBackOff", cfg.InitialBackOff},
+		{"MaxElapsedTime", cfg.MaxElapsedTime},
+		{"AttemptTimeout", cfg.AttemptTimeout},
+		{"HedgeDelay", cfg.HedgeDelay},
+	}
+	for _, d := range durations {
+		if d.value < 0 {
+			errs = append(errs, NewInvalidConfigError(d.field, "must not be negative"))
+		}
+	}
+	if cfg.MaxBackOff > 0 && cfg.MaxBackOff < cfg.BackOffTime {
+		errs = append(errs, NewInvalidConfigError("MaxBackOff", "must not be less than BackOffTime"))
+	}
+	if cfg.InternalServerErrorRetries < 0 {
+		errs = append(errs, NewInvalidConfigError("InternalServerErrorRetries", "must not be negative"))
+	}
+	if cfg.NetworkErrorRetries < 0 {
+		errs = append(errs, NewInvalidConfigError("NetworkErrorRetries", "must not be negative"))
+	}
+	if cfg.DowngradeConsistentReadsAfter < 0 {
+		errs = append(errs, NewInvalidConfigError("DowngradeConsistentReadsAfter", "must not be negative"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// NewFromConfig wraps client with the settings in cfg, returning the errors
+// reported by Validate if cfg is invalid, or a NestedClientError if client
+// already retries.
+func NewFromConfig(client DynamoDBClient, cfg Config) (*RetryDynamoDBClient, error) {
+	if nested(client) {
+		return nil, NewNestedClientError()
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	c := &RetryDynamoDBClient{DynamoDBClient: client}
+	cfg.apply(c)
+	c.freeze()
+
+	return c, nil
+}
+
+// apply copies the settings in cfg to c.
+func (cfg Config) apply(c *RetryDynamoDBClient) {
+	c.DisableRetries = cfg.DisableRetries
+	c.Infinite = cfg.Infinite
+	c.Retries = cfg.Retries
+	c.MaxAttempts = cfg.MaxAttempts
+	c.BackOffTime = cfg.BackOffTime
+	c.BackOffStrategy = cfg.BackOffStrategy
+	c.MaxBackOff = cfg.MaxBackOff
+	c.InitialBackOff = cfg.InitialBackOff
+	c.ImmediateRetry = cfg.ImmediateRetry
+	c.MaxElapsedTime = cfg.MaxElapsedTime
+	c.HonorRetryAfter = cfg.HonorRetryAfter
+	c.AttemptTimeout = cfg.AttemptTimeout
+	c.HedgeDelay = cfg.HedgeDelay
+	c.InternalServerErrorRetries = cfg.InternalServerErrorRetries
+	c.NetworkErrorRetries = cfg.NetworkErrorRetries
+	c.RetryTransactionConflicts = cfg.RetryTransactionConflicts
+	c.DowngradeConsistentReadsAfter = cfg.DowngradeConsistentReadsAfter
+	c.RequireIdempotentWrites = cfg.RequireIdempotentWrites
+	c.ValidateItemSize = cfg.ValidateItemSize
+	c.AggregateErrors = cfg.AggregateErrors
+	c.Shadow = cfg.Shadow
+}
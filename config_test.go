@@ -0,0 +1,122 @@
+package ddbretry
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry/ddbretrytest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want []error
+	}{
+		{
+			name: "should accept the zero Config",
+			cfg:  Config{},
+		},
+		{
+			name: "should accept infinite retries",
+			cfg: Config{
+				Retries:     -1,
+				BackOffTime: time.Second,
+				MaxBackOff:  time.Minute,
+			},
+		},
+		{
+			name: "should reject invalid retries and backoff",
+			cfg: Config{
+				Retries:     -2,
+				BackOffTime: -1,
+			},
+			want: []error{
+				NewInvalidRetryError(-2),
+				NewInvalidBackOffError(-1),
+			},
+		},
+		{
+			name: "should reject negative durations and limits",
+			cfg: Config{
+				BackOffStrategy:     BackOffStrategy(10),
+				AttemptTimeout:      -time.Second,
+				NetworkErrorRetries: -1,
+			},
+			want: []error{
+				NewInvalidConfigError("BackOffStrategy", "unknown strategy"),
+				NewInvalidConfigError("AttemptTimeout", "must not be negative"),
+				NewInvalidConfigError("NetworkErrorRetries", "must not be negative"),
+			},
+		},
+		{
+			name: "should reject other negative retries and conflicting modes",
+			cfg: Config{
+				Retries:        -3,
+				DisableRetries: true,
+				Infinite:       true,
+			},
+			want: []error{
+				NewInvalidRetryError(-3),
+				NewInvalidConfigError("Infinite", "must not be set with DisableRetries"),
+			},
+		},
+		{
+			name: "should reject MaxAttempts alongside Retries",
+			cfg: Config{
+				Retries:     3,
+				MaxAttempts: 4,
+			},
+			want: []error{
+				NewInvalidConfigError("MaxAttempts", "must not be set with Retries"),
+			},
+		},
+		{
+			name: "should reject a MaxBackOff below BackOffTime",
+			cfg: Config{
+				BackOffTime: time.Second,
+				MaxBackOff:  time.Millisecond,
+			},
+			want: []error{
+				NewInvalidConfigError("MaxBackOff", "must not be less than BackOffTime"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if len(tt.want) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Equal(t, errors.Join(tt.want...), err)
+		})
+	}
+}
+
+func TestNewFromConfig(t *testing.T) {
+	client := ddbretrytest.NewFakeClient()
+
+	c, err := NewFromConfig(client, Config{
+		Retries:         5,
+		BackOffTime:     time.Second,
+		BackOffStrategy: FullJitterBackOff,
+		AggregateErrors: true,
+	})
+	assert.NoError(t, err)
+	want := &RetryDynamoDBClient{
+		DynamoDBClient:  client,
+		Retries:         5,
+		BackOffTime:     time.Second,
+		BackOffStrategy: FullJitterBackOff,
+		AggregateErrors: true,
+	}
+	want.freeze()
+	assert.Equal(t, want, c)
+
+	c, err = NewFromConfig(client, Config{Retries: -5})
+	assert.Nil(t, c)
+	assert.True(t, IsInvalidRetryError(err))
+}
@@ -0,0 +1,136 @@
+package ddbretry
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// configFile is the encoded form of a Config, with durations written as
+// strings such as "100ms" or "2s".
+type configFile struct {
+	DisableRetries                bool            `json:"disable_retries,omitempty" yaml:"disable_retries,omitempty"`
+	Infinite                      bool            `json:"infinite,omitempty" yaml:"infinite,omitempty"`
+	Retries                       int             `json:"retries,omitempty" yaml:"retries,omitempty"`
+	MaxAttempts                   int             `json:"max_attempts,omitempty" yaml:"max_attempts,omitempty"`
+	BackOffTime                   configDuration  `json:"backoff,omitempty" yaml:"backoff,omitempty"`
+	BackOffStrategy               BackOffStrategy `json:"strategy" yaml:"strategy"`
+	MaxBackOff                    configDuration  `json:"max_backoff,omitempty" yaml:"max_backoff,omitempty"`
+	InitialBackOff                configDuration  `json:"initial_backoff,omitempty" yaml:"initial_backoff,omitempty"`
+	ImmediateRetry                bool            `json:"immediate_retry,omitempty" yaml:"immediate_retry,omitempty"`
+	MaxElapsedTime                configDuration  `json:"max_elapsed_time,omitempty" yaml:"max_elapsed_time,omitempty"`
+	HonorRetryAfter               bool            `json:"honor_retry_after,omitempty" yaml:"honor_retry_after,omitempty"`
+	AttemptTimeout                configDuration  `json:"attempt_timeout,omitempty" yaml:"attempt_timeout,omitempty"`
+	HedgeDelay                    configDuration  `json:"hedge_delay,omitempty" yaml:"hedge_delay,omitempty"`
+	InternalServerErrorRetries    int             `json:"internal_server_error_retries,omitempty" yaml:"internal_server_error_retries,omitempty"`
+	NetworkErrorRetries           int             `json:"network_error_retries,omitempty" yaml:"network_error_retries,omitempty"`
+	RetryTransactionConflicts     bool            `json:"retry_transaction_conflicts,omitempty" yaml:"retry_transaction_conflicts,omitempty"`
+	DowngradeConsistentReadsAfter int             `json:"downgrade_consistent_reads_after,omitempty" yaml:"downgrade_consistent_reads_after,omitempty"`
+	RequireIdempotentWrites       bool            `json:"require_idempotent_writes,omitempty" yaml:"require_idempotent_writes,omitempty"`
+	ValidateItemSize              bool            `json:"validate_item_size,omitempty" yaml:"validate_item_size,omitempty"`
+	AggregateErrors               bool            `json:"aggregate_errors,omitempty" yaml:"aggregate_errors,omitempty"`
+	Shadow                        bool            `json:"shadow,omitempty" yaml:"shadow,omitempty"`
+}
+
+// configDuration is a time.Duration encoded in time.ParseDuration syntax.
+type configDuration time.Duration
+
+func (d configDuration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *configDuration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = configDuration(parsed)
+
+	return nil
+}
+
+func (cfg Config) file() configFile {
+	return configFile{
+		DisableRetries:                cfg.DisableRetries,
+		Infinite:                      cfg.Infinite,
+		Retries:                       cfg.Retries,
+		MaxAttempts:                   cfg.MaxAttempts,
+		BackOffTime:                   configDuration(cfg.BackOffTime),
+		BackOffStrategy:               cfg.BackOffStrategy,
+		MaxBackOff:                    configDuration(cfg.MaxBackOff),
+		InitialBackOff:                configDuration(cfg.InitialBackOff),
+		ImmediateRetry:                cfg.ImmediateRetry,
+		MaxElapsedTime:                configDuration(cfg.MaxElapsedTime),
+		HonorRetryAfter:               cfg.HonorRetryAfter,
+		AttemptTimeout:                configDuration(cfg.AttemptTimeout),
+		HedgeDelay:                    configDuration(cfg.HedgeDelay),
+		InternalServerErrorRetries:    cfg.InternalServerErrorRetries,
+		NetworkErrorRetries:           cfg.NetworkErrorRetries,
+		RetryTransactionConflicts:     cfg.RetryTransactionConflicts,
+		DowngradeConsistentReadsAfter: cfg.DowngradeConsistentReadsAfter,
+		RequireIdempotentWrites:       cfg.RequireIdempotentWrites,
+		ValidateItemSize:              cfg.ValidateItemSize,
+		AggregateErrors:               cfg.AggregateErrors,
+		Shadow:                        cfg.Shadow,
+	}
+}
+
+func (f configFile) config() Config {
+	return Config{
+		DisableRetries:                f.DisableRetries,
+		Infinite:                      f.Infinite,
+		Retries:                       f.Retries,
+		MaxAttempts:                   f.MaxAttempts,
+		BackOffTime:                   time.Duration(f.BackOffTime),
+		BackOffStrategy:               f.BackOffStrategy,
+		MaxBackOff:                    time.Duration(f.MaxBackOff),
+		InitialBackOff:                time.Duration(f.InitialBackOff),
+		ImmediateRetry:                f.ImmediateRetry,
+		MaxElapsedTime:                time.Duration(f.MaxElapsedTime),
+		HonorRetryAfter:               f.HonorRetryAfter,
+		AttemptTimeout:                time.Duration(f.AttemptTimeout),
+		HedgeDelay:                    time.Duration(f.HedgeDelay),
+		InternalServerErrorRetries:    f.InternalServerErrorRetries,
+		NetworkErrorRetries:           f.NetworkErrorRetries,
+		RetryTransactionConflicts:     f.RetryTransactionConflicts,
+		DowngradeConsistentReadsAfter: f.DowngradeConsistentReadsAfter,
+		RequireIdempotentWrites:       f.RequireIdempotentWrites,
+		ValidateItemSize:              f.ValidateItemSize,
+		AggregateErrors:               f.AggregateErrors,
+		Shadow:                        f.Shadow,
+	}
+}
+
+// MarshalJSON encodes cfg with snake_case keys, durations as strings such as
+// "100ms" and the strategy by name, omitting settings left at their zero
+// value.
+func (cfg Config) MarshalJSON() ([]byte, error) {
+	return json.Marshal(cfg.file())
+}
+
+// UnmarshalJSON decodes the form written by MarshalJSON. Settings missing
+// from data are left unchanged; call Validate once the Config is loaded.
+func (cfg *Config) UnmarshalJSON(data []byte) error {
+	f := cfg.file()
+	if err := json.Unmarshal(data, &f); err != nil {
+		return err
+	}
+	*cfg = f.config()
+
+	return nil
+}
+
+// MarshalYAML and UnmarshalYAML encode cfg in YAML under the same keys as
+// MarshalJSON, for gopkg.in/yaml.v2 and gopkg.in/yaml.v3.
+func (cfg Config) MarshalYAML() (interface{}, error) {
+	return cfg.file(), nil
+}
+
+func (cfg *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	f := cfg.file()
+	if err := unmarshal(&f); err != nil {
+		return err
+	}
+	*cfg = f.config()
+
+	return nil
+}
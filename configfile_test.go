@@ -0,0 +1,66 @@
+package ddbretry
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestConfig_JSON(t *testing.T) {
+	cfg := Config{
+		Retries:         5,
+		BackOffTime:     100 * time.Millisecond,
+		BackOffStrategy: FullJitterBackOff,
+		MaxBackOff:      2 * time.Second,
+		AggregateErrors: true,
+	}
+
+	data, err := json.Marshal(cfg)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"retries": 5,
+		"backoff": "100ms",
+		"strategy": "full-jitter",
+		"max_backoff": "2s",
+		"aggregate_errors": true
+	}`, string(data))
+
+	var got Config
+	assert.NoError(t, json.Unmarshal(data, &got))
+	assert.Equal(t, cfg, got)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"backoff": "soon"}`), &got))
+	assert.True(t, IsInvalidConfigError(json.Unmarshal([]byte(`{"strategy": "linear"}`), &got)))
+}
+
+func TestConfig_YAML(t *testing.T) {
+	var service struct {
+		Table string `yaml:"table"`
+		Retry Config `yaml:"retry"`
+	}
+	err := yaml.Unmarshal([]byte(`
+table: users
+retry:
+  retries: 5
+  backoff: 250ms
+  strategy: exponential
+  attempt_timeout: 1s
+`), &service)
+	assert.NoError(t, err)
+	assert.Equal(t, Config{
+		Retries:         5,
+		BackOffTime:     250 * time.Millisecond,
+		BackOffStrategy: ExponentialBackOff,
+		AttemptTimeout:  time.Second,
+	}, service.Retry)
+
+	data, err := yaml.Marshal(service.Retry)
+	assert.NoError(t, err)
+
+	var got Config
+	assert.NoError(t, yaml.Unmarshal(data, &got))
+	assert.Equal(t, service.Retry, got)
+}
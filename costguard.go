@@ -0,0 +1,88 @@
+package ddbretry
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// CapacityBudget caps the capacity units a client consumes in each window of
+// time, to keep retry storms from running up an on-demand bill. Once the
+// units consumed in the current window reach the budget, calls stop being
+// retried, failing with a CapacityBudgetExhaustedError, and, if the budget
+// blocks requests, new calls fail with one without being sent, until the
+// next window starts. Only the capacity DynamoDB reports is counted, so
+// calls must set ReturnConsumedCapacity to be accounted for. A CapacityBudget
+// can be shared by several clients.
+type CapacityBudget struct {
+	mu sync.Mutex
+	clockSource
+	units         float64
+	window        time.Duration
+	blockRequests bool
+	start         time.Time
+	consumed      float64
+}
+
+func NewCapacityBudget(units float64, window time.Duration, blockRequests bool) *CapacityBudget {
+	return &CapacityBudget{
+		units:         units,
+		window:        window,
+		blockRequests: blockRequests,
+	}
+}
+
+// Consumed returns the capacity units consumed in the current window.
+func (b *CapacityBudget) Consumed() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.roll()
+
+	return b.consumed
+}
+
+// Exhausted reports whether the budget of the current window is used up.
+func (b *CapacityBudget) Exhausted() bool {
+	return b.exhausted(nil)
+}
+
+func (b *CapacityBudget) exhausted(clock Clock) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.use(clock)
+	b.roll()
+
+	return b.consumed >= b.units
+}
+
+// roll starts a new window if the current one has ended.
+func (b *CapacityBudget) roll() {
+	if now := b.now(); b.window > 0 && now.Sub(b.start) >= b.window {
+		b.start = now
+		b.consumed = 0
+	}
+}
+
+// record adds the capacity reported by an attempt to the current window.
+func (b *CapacityBudget) record(c *types.ConsumedCapacity, clock Clock) {
+	units := aws.ToFloat64(c.CapacityUnits)
+	if c.CapacityUnits == nil {
+		units = aws.ToFloat64(c.ReadCapacityUnits) + aws.ToFloat64(c.WriteCapacityUnits)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.use(clock)
+	b.roll()
+	b.consumed += units
+}
+
+// allowRequest reports whether a new call may be sent.
+func (b *CapacityBudget) allowRequest(clock Clock) bool {
+	return !b.blockRequests || !b.exhausted(clock)
+}
@@ -0,0 +1,72 @@
+package ddbretry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry/ddbretrytest"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryDynamoDBClient_CapacityBudget(t *testing.T) {
+	tests := []struct {
+		name          string
+		blockRequests bool
+		wantCalls     int
+		wantThrottled bool
+	}{
+		{
+			name:          "should stop retrying once the budget is used up",
+			blockRequests: false,
+			wantCalls:     3,
+			wantThrottled: true,
+		},
+		{
+			name:          "should stop sending requests once the budget is used up",
+			blockRequests: true,
+			wantCalls:     2,
+			wantThrottled: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := ddbretrytest.NewFakeClock(time.Unix(1700000000, 0))
+			budget := NewCapacityBudget(2, time.Minute, tt.blockRequests)
+			fake := ddbretrytest.NewFakeClient().
+				SetOutput("GetItem", &ddb.GetItemOutput{
+					ConsumedCapacity: &types.ConsumedCapacity{CapacityUnits: aws.Float64(1)},
+				}).
+				Script("GetItem", nil, nil, ddbretrytest.Throttled())
+			c := &RetryDynamoDBClient{
+				DynamoDBClient: fake,
+				Retries:        3,
+				BackOffTime:    time.Millisecond,
+				CapacityBudget: budget,
+				Clock:          clock,
+			}
+			ctx := context.Background()
+			input := &ddb.GetItemInput{ReturnConsumedCapacity: types.ReturnConsumedCapacityTotal}
+
+			for i := 0; i < 2; i++ {
+				_, err := c.GetItem(ctx, input)
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, float64(2), budget.Consumed())
+			assert.True(t, budget.Exhausted())
+
+			_, err := c.GetItem(ctx, input)
+			assert.True(t, IsCapacityBudgetExhaustedError(err))
+			assert.Equal(t, tt.wantThrottled, IsThrottlingError(err))
+			assert.Equal(t, tt.wantCalls, fake.CallCount("GetItem"))
+
+			clock.Advance(time.Minute)
+			assert.False(t, budget.Exhausted())
+			_, err = c.GetItem(ctx, input)
+			assert.NoError(t, err)
+		})
+	}
+}
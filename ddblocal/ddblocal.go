@@ -0,0 +1,238 @@
+// Package ddblocal runs integration tests of ddbretry against DynamoDB
+// Local. It starts DynamoDB Local in Docker, or points at an instance named
+// by the DYNAMODB_LOCAL_ENDPOINT environment variable, creates throw-away
+// tables with tiny provisioned capacity, and enforces that capacity with a
+// Throttler, since DynamoDB Local accepts requests at any rate. Requests over
+// capacity fail with a ProvisionedThroughputExceededException returned
+// through the SDK's middleware stack, so the retry behavior under test sees
+// the same errors it would see from DynamoDB.
+package ddblocal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// EndpointEnv names the environment variable holding the endpoint of a
+// running DynamoDB Local instance, such as "http://localhost:8000".
+const EndpointEnv = "DYNAMODB_LOCAL_ENDPOINT"
+
+// Image is the Docker image started by Start.
+const Image = "amazon/dynamodb-local"
+
+// KeyAttribute is the name of the string hash key of the tables created by
+// CreateTable.
+const KeyAttribute = "id"
+
+// NewClient returns a DynamoDB client sending requests to endpoint with
+// static credentials and the SDK's own retries disabled, so that every
+// throttled request reaches the code under test.
+func NewClient(endpoint string, optFns ...func(*ddb.Options)) *ddb.Client {
+	return ddb.New(ddb.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(endpoint),
+		Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: "local", SecretAccessKey: "local"}, nil
+		}),
+		Retryer: aws.NopRetryer{},
+	}, optFns...)
+}
+
+// Container is a DynamoDB Local instance running in Docker.
+type Container struct {
+	Endpoint string
+
+	id string
+}
+
+// Start runs Image in Docker on a free local port, returning once the
+// instance answers requests or ctx is done.
+func Start(ctx context.Context) (*Container, error) {
+	id, err := docker(ctx, "run", "-d", "--rm", "-p", "127.0.0.1::8000", Image)
+	if err != nil {
+		return nil, err
+	}
+	c := &Container{id: id}
+
+	addr, err := docker(ctx, "port", id, "8000/tcp")
+	if err != nil {
+		_ = c.Stop(context.Background())
+		return nil, err
+	}
+	c.Endpoint = "http://" + strings.SplitN(addr, "\n", 2)[0]
+
+	if err := ping(ctx, NewClient(c.Endpoint)); err != nil {
+		_ = c.Stop(context.Background())
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Stop stops and removes the container.
+func (c *Container) Stop(ctx context.Context) error {
+	_, err := docker(ctx, "stop", c.id)
+
+	return err
+}
+
+func docker(ctx context.Context, args ...string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("docker %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+// ping waits for client's endpoint to answer a ListTables request.
+func ping(ctx context.Context, client *ddb.Client) error {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		attemptCtx, cancel := context.WithTimeout(ctx, time.Second)
+		_, err := client.ListTables(attemptCtx, &ddb.ListTablesInput{Limit: aws.Int32(1)})
+		cancel()
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return errors.Join(ctx.Err(), err)
+		}
+	}
+}
+
+// Harness is a DynamoDB Local instance for the duration of a test.
+//
+// Client sends requests through Throttler, which only limits the tables
+// created with CreateTable. Wrap it in a RetryDynamoDBClient to test retries
+// against real requests.
+type Harness struct {
+	Client    *ddb.Client
+	Endpoint  string
+	Throttler *Throttler
+
+	tb testing.TB
+}
+
+var tables int64
+
+// New returns a Harness using the instance named by EndpointEnv, or one it
+// starts with Start and stops when the test ends. The test is skipped if
+// neither is available, so integration tests can live alongside unit tests
+// and only run where DynamoDB Local or Docker is.
+func New(tb testing.TB) *Harness {
+	tb.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	endpoint := os.Getenv(EndpointEnv)
+	if endpoint == "" {
+		if _, err := exec.LookPath("docker"); err != nil {
+			tb.Skipf("ddblocal: %s is not set and docker is not available", EndpointEnv)
+		}
+		c, err := Start(ctx)
+		if err != nil {
+			tb.Skipf("ddblocal: starting DynamoDB Local: %v", err)
+		}
+		tb.Cleanup(func() { _ = c.Stop(context.Background()) })
+		endpoint = c.Endpoint
+	} else if err := ping(ctx, NewClient(endpoint)); err != nil {
+		tb.Skipf("ddblocal: DynamoDB Local at %s is not answering: %v", endpoint, err)
+	}
+
+	throttler := NewThrottler()
+
+	return &Harness{
+		Client: NewClient(endpoint, func(o *ddb.Options) {
+			o.APIOptions = append(o.APIOptions, throttler.AddMiddleware)
+		}),
+		Endpoint:  endpoint,
+		Throttler: throttler,
+		tb:        tb,
+	}
+}
+
+// CreateTable creates a table with a string hash key named KeyAttribute and
+// the given provisioned capacity, which Throttler enforces, returning its
+// name. The table is deleted when the test ends.
+func (h *Harness) CreateTable(readCapacity, writeCapacity int64) string {
+	h.tb.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	name := fmt.Sprintf("ddbretry-%d-%d", time.Now().UnixNano(), atomic.AddInt64(&tables, 1))
+	_, err := h.Client.CreateTable(ctx, &ddb.CreateTableInput{
+		TableName: aws.String(name),
+		AttributeDefinitions: []types.AttributeDefinition{
+			{AttributeName: aws.String(KeyAttribute), AttributeType: types.ScalarAttributeTypeS},
+		},
+		KeySchema: []types.KeySchemaElement{
+			{AttributeName: aws.String(KeyAttribute), KeyType: types.KeyTypeHash},
+		},
+		ProvisionedThroughput: &types.ProvisionedThroughput{
+			ReadCapacityUnits:  aws.Int64(readCapacity),
+			WriteCapacityUnits: aws.Int64(writeCapacity),
+		},
+	})
+	if err != nil {
+		h.tb.Fatalf("ddblocal: creating table: %v", err)
+	}
+	h.tb.Cleanup(func() {
+		_, _ = h.Client.DeleteTable(context.Background(), &ddb.DeleteTableInput{TableName: aws.String(name)})
+		h.Throttler.Remove(name)
+	})
+
+	waiter := ddb.NewTableExistsWaiter(h.Client)
+	if err := waiter.Wait(ctx, &ddb.DescribeTableInput{TableName: aws.String(name)}, time.Minute); err != nil {
+		h.tb.Fatalf("ddblocal: waiting for table: %v", err)
+	}
+	h.Throttler.Provision(name, float64(readCapacity), float64(writeCapacity))
+
+	return name
+}
+
+// Exhaust uses up the read and write capacity of table, so that requests to
+// it are throttled until the capacity refills.
+func (h *Harness) Exhaust(table string) {
+	h.Throttler.Exhaust(table)
+}
+
+// Item returns an item of a table created by CreateTable with the given key
+// and attributes.
+func Item(key string, attributes map[string]types.AttributeValue) map[string]types.AttributeValue {
+	item := map[string]types.AttributeValue{
+		KeyAttribute: &types.AttributeValueMemberS{Value: key},
+	}
+	for name, value := range attributes {
+		item[name] = value
+	}
+
+	return item
+}
+
+// Key returns the key of an item of a table created by CreateTable.
+func Key(key string) map[string]types.AttributeValue {
+	return Item(key, nil)
+}
@@ -0,0 +1,53 @@
+package ddblocal
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHarness(t *testing.T) {
+	h := New(t)
+	table := h.CreateTable(5, 1)
+	h.Exhaust(table)
+
+	var mu sync.Mutex
+	var retries int
+	client := &ddbretry.RetryDynamoDBClient{
+		DynamoDBClient:  h.Client,
+		Retries:         20,
+		BackOffTime:     100 * time.Millisecond,
+		BackOffStrategy: ddbretry.ConstantBackOff,
+		OnRetry: func(op string, table string, attempt int, err error, delay time.Duration) {
+			mu.Lock()
+			retries++
+			mu.Unlock()
+		},
+	}
+
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	errs := make([]error, 3)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = client.PutItem(ctx, &ddb.PutItemInput{TableName: aws.String(table), Item: Item("1", nil)})
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		assert.NoError(t, err)
+	}
+	assert.Greater(t, retries, 0)
+
+	output, err := client.GetItem(ctx, &ddb.GetItemInput{TableName: aws.String(table), Key: Key("1")})
+	assert.NoError(t, err)
+	assert.Equal(t, Key("1"), output.Item)
+}
@@ -0,0 +1,184 @@
+package ddblocal
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// ThrottlerID identifies the Throttler middleware in a smithy-go middleware
+// stack.
+const ThrottlerID = "ddblocal.Throttler"
+
+// Throttler emulates the provisioned throughput of tables, failing requests
+// that exceed it with a ProvisionedThroughputExceededException before they
+// are sent. Each table's read and write capacity refill at their provisioned
+// rate per second, up to one second's worth. Every item read or written
+// costs one unit, as if it were under 4 KB to read and 1 KB to write, and
+// eventually consistent reads cost half a unit. A batch is throttled as a
+// whole if any of its tables lacks the capacity for it. Requests to tables
+// that are not provisioned are never throttled.
+type Throttler struct {
+	mu     sync.Mutex
+	tables map[string]*capacity
+	now    func() time.Time
+}
+
+type capacity struct {
+	read  bucket
+	write bucket
+}
+
+type bucket struct {
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+func NewThrottler() *Throttler {
+	return &Throttler{
+		tables: map[string]*capacity{},
+		now:    time.Now,
+	}
+}
+
+// Provision enforces the given read and write capacity units per second on
+// table, starting with full capacity.
+func (t *Throttler) Provision(table string, readCapacity, writeCapacity float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	t.tables[table] = &capacity{
+		read:  bucket{rate: readCapacity, tokens: readCapacity, last: now},
+		write: bucket{rate: writeCapacity, tokens: writeCapacity, last: now},
+	}
+}
+
+// Remove stops enforcing the capacity of table.
+func (t *Throttler) Remove(table string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.tables, table)
+}
+
+// Exhaust uses up the capacity of table.
+func (t *Throttler) Exhaust(table string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if c, ok := t.tables[table]; ok {
+		now := t.now()
+		c.read.tokens, c.read.last = 0, now
+		c.write.tokens, c.write.last = 0, now
+	}
+}
+
+// AddMiddleware adds the Throttler to the serialize step of stack, which
+// runs on every attempt made by the SDK's retryer and by ddbretry's
+// middleware alike.
+func (t *Throttler) AddMiddleware(stack *middleware.Stack) error {
+	return stack.Serialize.Add(middleware.SerializeMiddlewareFunc(ThrottlerID, t.handleSerialize), middleware.Before)
+}
+
+func (t *Throttler) handleSerialize(ctx context.Context, in middleware.SerializeInput, next middleware.SerializeHandler) (middleware.SerializeOutput, middleware.Metadata, error) {
+	if err := t.consume(in.Parameters); err != nil {
+		return middleware.SerializeOutput{}, middleware.Metadata{}, err
+	}
+
+	return next.HandleSerialize(ctx, in)
+}
+
+// consume takes the capacity a request with the given input uses from its
+// tables, or none of it if any of them lacks the capacity.
+func (t *Throttler) consume(input interface{}) error {
+	reads, writes := cost(input)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	for table, units := range reads {
+		if c, ok := t.tables[table]; ok && !c.read.available(now, units) {
+			return throttled(table)
+		}
+	}
+	for table, units := range writes {
+		if c, ok := t.tables[table]; ok && !c.write.available(now, units) {
+			return throttled(table)
+		}
+	}
+	for table, units := range reads {
+		if c, ok := t.tables[table]; ok {
+			c.read.tokens -= units
+		}
+	}
+	for table, units := range writes {
+		if c, ok := t.tables[table]; ok {
+			c.write.tokens -= units
+		}
+	}
+
+	return nil
+}
+
+// available refills b up to now and reports whether it holds units.
+func (b *bucket) available(now time.Time, units float64) bool {
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.rate {
+		b.tokens = b.rate
+	}
+	b.last = now
+
+	return b.tokens >= units
+}
+
+func throttled(table string) error {
+	return &types.ProvisionedThroughputExceededException{
+		Message: aws.String("The level of configured provisioned throughput for the table " + table + " was exceeded."),
+	}
+}
+
+// cost returns the read and write capacity units a request with the given
+// input uses, by table.
+func cost(input interface{}) (reads, writes map[string]float64) {
+	reads, writes = map[string]float64{}, map[string]float64{}
+	read := func(table *string, consistent *bool, items int) {
+		units := float64(items)
+		if !aws.ToBool(consistent) {
+			units /= 2
+		}
+		reads[aws.ToString(table)] += units
+	}
+
+	switch in := input.(type) {
+	case *ddb.GetItemInput:
+		read(in.TableName, in.ConsistentRead, 1)
+	case *ddb.QueryInput:
+		read(in.TableName, in.ConsistentRead, 1)
+	case *ddb.ScanInput:
+		read(in.TableName, in.ConsistentRead, 1)
+	case *ddb.BatchGetItemInput:
+		for table, ka := range in.RequestItems {
+			read(aws.String(table), ka.ConsistentRead, len(ka.Keys))
+		}
+	case *ddb.PutItemInput:
+		writes[aws.ToString(in.TableName)]++
+	case *ddb.UpdateItemInput:
+		writes[aws.ToString(in.TableName)]++
+	case *ddb.DeleteItemInput:
+		writes[aws.ToString(in.TableName)]++
+	case *ddb.BatchWriteItemInput:
+		for table, requests := range in.RequestItems {
+			writes[table] += float64(len(requests))
+		}
+	}
+
+	return reads, writes
+}
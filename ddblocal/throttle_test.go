@@ -0,0 +1,107 @@
+package ddblocal
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingHTTPClient answers every request with an empty output.
+type countingHTTPClient struct {
+	requests int
+}
+
+func (h *countingHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	h.requests++
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/x-amz-json-1.0"}},
+		Body:       io.NopCloser(strings.NewReader(`{}`)),
+		Request:    req,
+	}, nil
+}
+
+func TestThrottler(t *testing.T) {
+	now := time.Unix(0, 0)
+	throttler := NewThrottler()
+	throttler.now = func() time.Time { return now }
+	throttler.Provision("users", 2, 1)
+
+	httpClient := &countingHTTPClient{}
+	client := NewClient("http://localhost:8000", func(o *ddb.Options) {
+		o.HTTPClient = httpClient
+		o.APIOptions = append(o.APIOptions, throttler.AddMiddleware)
+	})
+	ctx := context.Background()
+	put := func(table string) error {
+		_, err := client.PutItem(ctx, &ddb.PutItemInput{TableName: aws.String(table), Item: Item("1", nil)})
+		return err
+	}
+	get := func(consistent bool) error {
+		_, err := client.GetItem(ctx, &ddb.GetItemInput{TableName: aws.String("users"), Key: Key("1"), ConsistentRead: aws.Bool(consistent)})
+		return err
+	}
+
+	assert.NoError(t, put("users"))
+	err := put("users")
+	assert.True(t, ddbretry.IsProvisionedThroughputExceededException(err))
+	assert.NoError(t, put("orders"))
+
+	assert.NoError(t, get(true))
+	assert.NoError(t, get(false))
+	assert.NoError(t, get(false))
+	assert.Error(t, get(false))
+	assert.Equal(t, 5, httpClient.requests)
+
+	now = now.Add(500 * time.Millisecond)
+	assert.NoError(t, get(true))
+	assert.Error(t, put("users"))
+
+	now = now.Add(time.Hour)
+	throttler.Exhaust("users")
+	assert.Error(t, put("users"))
+	_, err = client.BatchWriteItem(ctx, &ddb.BatchWriteItemInput{RequestItems: map[string][]types.WriteRequest{
+		"orders": {{PutRequest: &types.PutRequest{Item: Item("1", nil)}}},
+		"users":  {{PutRequest: &types.PutRequest{Item: Item("1", nil)}}},
+	}})
+	assert.Error(t, err)
+	assert.Equal(t, 6, httpClient.requests)
+
+	throttler.Remove("users")
+	assert.NoError(t, put("users"))
+}
+
+func TestThrottler_retried(t *testing.T) {
+	throttler := NewThrottler()
+	throttler.Provision("users", 1, 1)
+	throttler.Exhaust("users")
+
+	var attempts int
+	httpClient := &countingHTTPClient{}
+	client := &ddbretry.RetryDynamoDBClient{
+		DynamoDBClient: NewClient("http://localhost:8000", func(o *ddb.Options) {
+			o.HTTPClient = httpClient
+			o.APIOptions = append(o.APIOptions, throttler.AddMiddleware)
+		}),
+		Retries:         10,
+		BackOffTime:     200 * time.Millisecond,
+		BackOffStrategy: ddbretry.ConstantBackOff,
+		OnSuccess: func(op string, table string, n int) {
+			attempts = n
+		},
+	}
+	_, err := client.PutItem(context.Background(), &ddb.PutItemInput{TableName: aws.String("users"), Item: Item("1", nil)})
+	assert.NoError(t, err)
+	assert.Greater(t, attempts, 1)
+	assert.Equal(t, 1, httpClient.requests)
+}
@@ -3,113 +3,472 @@ package ddbretry
 import (
 	"context"
 	"errors"
+	"sync/atomic"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
 )
 
-type DynamoDBClient interface {
+// DynamoDBReader and DynamoDBWriter split the single-table item operations
+// of DynamoDBClient, for code that only reads or only writes and would rather
+// depend on, and fake, less. RetryDynamoDBClient implements both.
+type DynamoDBReader interface {
 	GetItem(context.Context, *ddb.GetItemInput, ...func(*ddb.Options)) (*ddb.GetItemOutput, error)
-	DeleteItem(context.Context, *ddb.DeleteItemInput, ...func(*ddb.Options)) (*ddb.DeleteItemOutput, error)
+	Query(context.Context, *ddb.QueryInput, ...func(*ddb.Options)) (*ddb.QueryOutput, error)
+	Scan(context.Context, *ddb.ScanInput, ...func(*ddb.Options)) (*ddb.ScanOutput, error)
+}
+
+type DynamoDBWriter interface {
 	PutItem(context.Context, *ddb.PutItemInput, ...func(*ddb.Options)) (*ddb.PutItemOutput, error)
+	DeleteItem(context.Context, *ddb.DeleteItemInput, ...func(*ddb.Options)) (*ddb.DeleteItemOutput, error)
+	UpdateItem(context.Context, *ddb.UpdateItemInput, ...func(*ddb.Options)) (*ddb.UpdateItemOutput, error)
+}
+
+// DynamoDBClient is the subset of *dynamodb.Client that RetryDynamoDBClient
+// wraps. Operations RetryDynamoDBClient does not retry yet, currently those
+// other than the DynamoDBReader and DynamoDBWriter ones, are passed through
+// to the wrapped client as they are.
+type DynamoDBClient interface {
+	DynamoDBReader
+	DynamoDBWriter
+	BatchGetItem(context.Context, *ddb.BatchGetItemInput, ...func(*ddb.Options)) (*ddb.BatchGetItemOutput, error)
+	BatchWriteItem(context.Context, *ddb.BatchWriteItemInput, ...func(*ddb.Options)) (*ddb.BatchWriteItemOutput, error)
+	TransactGetItems(context.Context, *ddb.TransactGetItemsInput, ...func(*ddb.Options)) (*ddb.TransactGetItemsOutput, error)
+	TransactWriteItems(context.Context, *ddb.TransactWriteItemsInput, ...func(*ddb.Options)) (*ddb.TransactWriteItemsOutput, error)
 }
 
+var (
+	_ DynamoDBClient = (*ddb.Client)(nil)
+	_ DynamoDBReader = (*RetryDynamoDBClient)(nil)
+	_ DynamoDBWriter = (*RetryDynamoDBClient)(nil)
+)
+
+// RetryDynamoDBClient wraps a DynamoDBClient, retrying calls that fail with
+// retryable errors. Its fields can be set directly on a struct literal, but
+// clients built by a constructor, such as NewRetryDynamoDBClient,
+// NewFromConfig or Builder.Build, freeze the fields that make up their own
+// RetryPolicy, from Retries to AttemptTimeout: changing them afterwards has
+// no effect, which keeps calls free of data races. Use With to derive a
+// client with different settings, or UpdateConfig to change them in place.
 type RetryDynamoDBClient struct {
 	DynamoDBClient
-	Retries     int
-	BackOffTime time.Duration
+	// DisableRetries sends each call once, whatever Retries is set to.
+	DisableRetries bool
+	Infinite       bool
+	// Retries counts the attempts made after the first. MaxAttempts, when
+	// set, replaces it with a limit on attempts in total, first attempt
+	// included, as the SDK's option of the same name does; the two must not
+	// be set together.
+	Retries           int
+	MaxAttempts       int
+	BackOffTime       time.Duration
+	BackOffStrategy   BackOffStrategy
+	MaxBackOff        time.Duration
+	BackOff           BackOff
+	BackOffFunc       func(attempt int) time.Duration
+	BackOffDecay      *BackOffDecay
+	InitialBackOff    time.Duration
+	ImmediateRetry    bool
+	MaxElapsedTime    time.Duration
+	HonorRetryAfter   bool
+	AttemptTimeout    time.Duration
+	RetryBudget       *RetryTokenBucket
+	CapacityBudget    *CapacityBudget
+	RateLimiter       *AdaptiveRateLimiter
+	CircuitBreaker    *CircuitBreaker
+	ThrottleMonitor   *ThrottleMonitor
+	Incidents         *IncidentRecorder
+	HedgeDelay        time.Duration
+	Coalescer         *Coalescer
+	ReadPolicy        *RetryPolicy
+	WritePolicy       *RetryPolicy
+	OperationPolicies map[string]RetryPolicy
+	TablePolicies     map[string]RetryPolicy
+	RetryRateLimiter  *RetryRateLimiter
+	Classifier        ErrorClassifier
+	Rand              *Rand
+	// InternalServerErrorRetries and NetworkErrorRetries opt in to retrying
+	// InternalServerError and network errors (see IsNetworkError) up to the
+	// given number of times, independently of Retries.
+	InternalServerErrorRetries int
+	NetworkErrorRetries        int
+	RetryTransactionConflicts  bool
+	// DowngradeConsistentReadsAfter, when positive, sends the attempts of
+	// strongly consistent GetItem and Query calls made after that many
+	// throttled attempts with ConsistentRead turned off, trading consistency
+	// for availability. ConsistentReadDowngraded reports whether an output
+	// was read that way.
+	DowngradeConsistentReadsAfter int
+	// RequireIdempotentWrites stops writes from being retried after
+	// ambiguous failures, such as network errors or InternalServerError,
+	// unless the input carries a condition or the call is made with
+	// WithIdempotent. Writes rejected by throttling are always retried, as
+	// they cannot have been applied.
+	RequireIdempotentWrites bool
+	// ValidateItemSize fails PutItem and BatchWriteItem calls holding an item
+	// larger than MaxItemSize with an ItemTooLargeError before they are
+	// sent, rather than leaving DynamoDB to reject them.
+	ValidateItemSize bool
+	// AggregateErrors makes calls that give up fail with the errors from
+	// every failed attempt, oldest first, joined with errors.Join.
+	AggregateErrors bool
+	// ConcurrencyLimiter, when set, caps the attempts in flight at once.
+	ConcurrencyLimiter *ConcurrencyLimiter
+	// Bulkheads, when set, give each table its own concurrency limit and
+	// retry budget.
+	Bulkheads *Bulkheads
+	// Clock, when set, replaces the real clock used to time attempts and
+	// wait out backoff delays.
+	Clock Clock
+	// Logger, when set, receives a debug record for each retry and a warning
+	// when a call gives up. Metrics and Tracer, when set, are told about
+	// every attempt.
+	Logger  Logger
+	Metrics MetricsRecorder
+	Tracer  Tracer
+	// LogSampler, when set, limits the retry and DebugDump records logged for
+	// each table. Records of calls giving up are never sampled.
+	LogSampler *LogSampler
+	// DebugDump logs the key and expressions of every throttled request to
+	// Logger, with attribute values replaced by hashes, to help find the
+	// access patterns behind hot partitions without logging item data.
+	DebugDump bool
+	// OnRetry, OnGiveUp and OnSuccess are called, when set, before each
+	// retry, when a call fails for good and when a call succeeds. attempts
+	// is the number of attempts sent for the call so far. RequestID and
+	// RequestIDs extract the AWS request IDs from err.
+	OnRetry   func(op string, table string, attempt int, err error, delay time.Duration)
+	OnGiveUp  func(op string, table string, attempts int, err error)
+	OnSuccess func(op string, table string, attempts int)
+	// OnConsumedCapacity is called when a call ends with the total capacity
+	// reported by all of its attempts, when the input sets
+	// ReturnConsumedCapacity. Only attempts that return an output report
+	// capacity; throttled attempts consume none.
+	OnConsumedCapacity func(op string, table string, total *types.ConsumedCapacity, attempts int)
+	// Shadow stops the client from retrying, but still works out whether and
+	// after what delay each failed attempt would have been retried, reporting
+	// it to OnShadowRetry, to Logger and to Metrics if it implements
+	// ShadowMetricsRecorder. Only the first retry of a call can be predicted,
+	// as the call fails once it is skipped.
+	Shadow        bool
+	OnShadowRetry func(op string, table string, attempt int, err error, decision RetryDecision, delay time.Duration)
+
+	stats  stats
+	frozen atomic.Value // *RetryPolicy
 }
 
-func NewRetryDynamoDBClient(client DynamoDBClient, retries int, backOff time.Duration) *RetryDynamoDBClient {
-	return &RetryDynamoDBClient{
+// NewRetryDynamoDBClient wraps client, returning an InvalidRetryError if
+// retries is negative other than InfiniteRetries, an InvalidBackOffError if
+// backOff is negative or a NestedClientError if client already retries (see
+// nested). Passing zero retries disables retrying rather than applying the
+// defaults.
+func NewRetryDynamoDBClient(client DynamoDBClient, retries int, backOff time.Duration) (*RetryDynamoDBClient, error) {
+	if nested(client) {
+		return nil, NewNestedClientError()
+	}
+	if retries < 0 && retries != InfiniteRetries {
+		return nil, NewInvalidRetryError(retries)
+	}
+	if backOff < 0 {
+		return nil, NewInvalidBackOffError(backOff)
+	}
+
+	c := &RetryDynamoDBClient{
 		DynamoDBClient: client,
+		DisableRetries: retries == 0,
+		Infinite:       retries == InfiniteRetries,
 		Retries:        retries,
 		BackOffTime:    backOff,
 	}
+	c.freeze()
+
+	return c, nil
+}
+
+// Unwrap returns the client c wraps, for operations RetryDynamoDBClient does
+// not cover. Calls made on it directly are not retried.
+func (c *RetryDynamoDBClient) Unwrap() DynamoDBClient {
+	return c.DynamoDBClient
+}
+
+// nested reports whether client is a RetryDynamoDBClient, or a decorator
+// whose chain of Unwrap methods leads to one, in which case wrapping it would
+// multiply the attempts made for each call.
+func nested(client DynamoDBClient) bool {
+	for client != nil {
+		if _, ok := client.(*RetryDynamoDBClient); ok {
+			return true
+		}
+		u, ok := client.(interface{ Unwrap() DynamoDBClient })
+		if !ok {
+			return false
+		}
+		client = u.Unwrap()
+	}
+
+	return false
 }
 
-func (c *RetryDynamoDBClient) GetItem(ctx context.Context, input *ddb.GetItemInput, o ...func(*ddb.Options)) (output *ddb.GetItemOutput, err error) {
-	retries := c.Retries
-	infinite := retries == -1
-	for retries >= 0 || infinite {
-		output, err = c.DynamoDBClient.GetItem(ctx, input, o...)
-		if err != nil {
-			if IsProvisionedThroughputExceededException(err) {
-				if retries > 0 {
-					retries--
-					time.Sleep(c.BackOffTime)
-				} else if infinite {
-					time.Sleep(c.BackOffTime)
-				} else {
-					return
-				}
-			} else {
-				return
-			}
-		} else {
-			return
+// With returns a copy of c with opts applied in order, leaving c unchanged,
+// and is the way to change the retry settings of a client built by a
+// constructor. The copy wraps the same DynamoDBClient and shares c's hooks
+// and stateful components, such as its RetryBudget, RateLimiter and
+// CircuitBreaker, but keeps its own Stats. With is safe to call while c is
+// in use, including alongside UpdateConfig.
+func (c *RetryDynamoDBClient) With(opts ...Option) (*RetryDynamoDBClient, error) {
+	d := c.clone()
+	for _, opt := range opts {
+		if err := opt(d); err != nil {
+			return nil, err
 		}
 	}
+	d.freeze()
+
+	return d, nil
+}
+
+// clone returns a copy of c's settings, with fresh Stats. The copy's fields
+// hold the retry settings in effect for c, including any applied by
+// UpdateConfig since c was built. The other fields are copied one by one,
+// leaving out c's Stats and frozen policy, which calls update as they run.
+func (c *RetryDynamoDBClient) clone() *RetryDynamoDBClient {
+	d := policyClient(c.policy())
+	d.DynamoDBClient = c.DynamoDBClient
+	d.BackOffDecay = c.BackOffDecay
+	d.HonorRetryAfter = c.HonorRetryAfter
+	d.RetryBudget = c.RetryBudget
+	d.CapacityBudget = c.CapacityBudget
+	d.RateLimiter = c.RateLimiter
+	d.CircuitBreaker = c.CircuitBreaker
+	d.ThrottleMonitor = c.ThrottleMonitor
+	d.Incidents = c.Incidents
+	d.HedgeDelay = c.HedgeDelay
+	d.Coalescer = c.Coalescer
+	d.ReadPolicy = c.ReadPolicy
+	d.WritePolicy = c.WritePolicy
+	d.OperationPolicies = c.OperationPolicies
+	d.TablePolicies = c.TablePolicies
+	d.RetryRateLimiter = c.RetryRateLimiter
+	d.InternalServerErrorRetries = c.InternalServerErrorRetries
+	d.NetworkErrorRetries = c.NetworkErrorRetries
+	d.RetryTransactionConflicts = c.RetryTransactionConflicts
+	d.DowngradeConsistentReadsAfter = c.DowngradeConsistentReadsAfter
+	d.RequireIdempotentWrites = c.RequireIdempotentWrites
+	d.ValidateItemSize = c.ValidateItemSize
+	d.AggregateErrors = c.AggregateErrors
+	d.ConcurrencyLimiter = c.ConcurrencyLimiter
+	d.Bulkheads = c.Bulkheads
+	d.Clock = c.Clock
+	d.Logger = c.Logger
+	d.Metrics = c.Metrics
+	d.Tracer = c.Tracer
+	d.LogSampler = c.LogSampler
+	d.DebugDump = c.DebugDump
+	d.OnRetry = c.OnRetry
+	d.OnGiveUp = c.OnGiveUp
+	d.OnSuccess = c.OnSuccess
+	d.OnConsumedCapacity = c.OnConsumedCapacity
+	d.Shadow = c.Shadow
+	d.OnShadowRetry = c.OnShadowRetry
+
+	return d
+}
+
+func (c *RetryDynamoDBClient) GetItem(ctx context.Context, input *ddb.GetItemInput, o ...func(*ddb.Options)) (*ddb.GetItemOutput, error) {
+	if g := c.Coalescer; g != nil {
+		return g.getItem(ctx, input, func() (*ddb.GetItemOutput, error) {
+			return c.retryGetItem(ctx, input, o...)
+		})
+	}
+
+	return c.retryGetItem(ctx, input, o...)
+}
 
-	return nil, NewInvalidRetryError(retries)
-}
-
-func (c *RetryDynamoDBClient) DeleteItem(ctx context.Context, input *ddb.DeleteItemInput, o ...func(*ddb.Options)) (output *ddb.DeleteItemOutput, err error) {
-	retries := c.Retries
-	infinite := retries == -1
-	for retries >= 0 || infinite {
-		output, err = c.DynamoDBClient.DeleteItem(ctx, input, o...)
-		if err != nil {
-			if IsProvisionedThroughputExceededException(err) {
-				if retries > 0 {
-					retries--
-					time.Sleep(c.BackOffTime)
-				} else if infinite {
-					time.Sleep(c.BackOffTime)
-				} else {
-					return
-				}
-			} else {
-				return
-			}
-		} else {
-			return
+func (c *RetryDynamoDBClient) retryGetItem(ctx context.Context, input *ddb.GetItemInput, o ...func(*ddb.Options)) (*ddb.GetItemOutput, error) {
+	return executeWithRetry(ctx, c, "GetItem", input, o, func(ctx context.Context, s *retryState) (*ddb.GetItemOutput, error) {
+		output, err := c.getItem(ctx, s.getItemInput(input), o...)
+		if err == nil {
+			s.markDowngraded(&output.ResultMetadata)
+		}
+		return output, err
+	})
+}
+
+func (c *RetryDynamoDBClient) DeleteItem(ctx context.Context, input *ddb.DeleteItemInput, o ...func(*ddb.Options)) (*ddb.DeleteItemOutput, error) {
+	return executeWithRetry(ctx, c, "DeleteItem", input, o, func(ctx context.Context, _ *retryState) (*ddb.DeleteItemOutput, error) {
+		return c.DynamoDBClient.DeleteItem(ctx, input, o...)
+	})
+}
+
+func (c *RetryDynamoDBClient) PutItem(ctx context.Context, input *ddb.PutItemInput, o ...func(*ddb.Options)) (*ddb.PutItemOutput, error) {
+	if c.ValidateItemSize {
+		if err := validateItemSize(aws.ToString(input.TableName), input.Item); err != nil {
+			return nil, err
 		}
 	}
 
-	return nil, NewInvalidRetryError(retries)
-}
-
-func (c *RetryDynamoDBClient) PutItem(ctx context.Context, input *ddb.PutItemInput, o ...func(*ddb.Options)) (output *ddb.PutItemOutput, err error) {
-	retries := c.Retries
-	infinite := retries == -1
-	for retries >= 0 || infinite {
-		output, err = c.DynamoDBClient.PutItem(ctx, input, o...)
-		if err != nil {
-			if IsProvisionedThroughputExceededException(err) {
-				if retries > 0 {
-					retries--
-					time.Sleep(c.BackOffTime)
-				} else if infinite {
-					time.Sleep(c.BackOffTime)
-				} else {
-					return
-				}
-			} else {
-				return
-			}
-		} else {
-			return
+	return executeWithRetry(ctx, c, "PutItem", input, o, func(ctx context.Context, _ *retryState) (*ddb.PutItemOutput, error) {
+		return c.DynamoDBClient.PutItem(ctx, input, o...)
+	})
+}
+
+func (c *RetryDynamoDBClient) UpdateItem(ctx context.Context, input *ddb.UpdateItemInput, o ...func(*ddb.Options)) (*ddb.UpdateItemOutput, error) {
+	return executeWithRetry(ctx, c, "UpdateItem", input, o, func(ctx context.Context, _ *retryState) (*ddb.UpdateItemOutput, error) {
+		return c.DynamoDBClient.UpdateItem(ctx, input, o...)
+	})
+}
+
+func (c *RetryDynamoDBClient) Query(ctx context.Context, input *ddb.QueryInput, o ...func(*ddb.Options)) (*ddb.QueryOutput, error) {
+	return executeWithRetry(ctx, c, "Query", input, o, func(ctx context.Context, s *retryState) (*ddb.QueryOutput, error) {
+		output, err := c.DynamoDBClient.Query(ctx, s.queryInput(input), o...)
+		if err == nil {
+			s.markDowngraded(&output.ResultMetadata)
+		}
+		return output, err
+	})
+}
+
+func (c *RetryDynamoDBClient) Scan(ctx context.Context, input *ddb.ScanInput, o ...func(*ddb.Options)) (*ddb.ScanOutput, error) {
+	return executeWithRetry(ctx, c, "Scan", input, o, func(ctx context.Context, _ *retryState) (*ddb.ScanOutput, error) {
+		return c.DynamoDBClient.Scan(ctx, input, o...)
+	})
+}
+
+// BatchWriteItem passes the call on to the wrapped client without retrying
+// it, checking the size of its items first if ValidateItemSize is set.
+func (c *RetryDynamoDBClient) BatchWriteItem(ctx context.Context, input *ddb.BatchWriteItemInput, o ...func(*ddb.Options)) (*ddb.BatchWriteItemOutput, error) {
+	if c.ValidateItemSize {
+		if err := validateWriteRequests(input.RequestItems); err != nil {
+			return nil, err
 		}
 	}
 
-	return nil, NewInvalidRetryError(retries)
+	return c.DynamoDBClient.BatchWriteItem(ctx, input, o...)
 }
 
+// IsProvisionedThroughputExceededException reports whether err is a
+// ProvisionedThroughputExceededException, either as the concrete type or as
+// any smithy.APIError carrying its error code.
 func IsProvisionedThroughputExceededException(err error) bool {
 	var provisionedThroughputExceededException *types.ProvisionedThroughputExceededException
 	ok := errors.As(err, &provisionedThroughputExceededException)
 
-	return ok
+	return ok || hasErrorCode(err, "ProvisionedThroughputExceededException")
+}
+
+func IsTransactionConflictException(err error) bool {
+	var transactionConflictException *types.TransactionConflictException
+	ok := errors.As(err, &transactionConflictException)
+
+	return ok || hasErrorCode(err, "TransactionConflictException")
+}
+
+func IsThrottlingException(err error) bool {
+	return hasErrorCode(err, "ThrottlingException")
+}
+
+func IsRequestLimitExceeded(err error) bool {
+	var requestLimitExceeded *types.RequestLimitExceeded
+	ok := errors.As(err, &requestLimitExceeded)
+
+	return ok || hasErrorCode(err, "RequestLimitExceeded")
+}
+
+// IsThrottlingError reports whether err is any of the errors DynamoDB uses to
+// signal throttling: ProvisionedThroughputExceededException for provisioned
+// tables, ThrottlingException for on-demand tables and control-plane calls,
+// and RequestLimitExceeded for account-level limits.
+func IsThrottlingError(err error) bool {
+	return IsProvisionedThroughputExceededException(err) || IsThrottlingException(err) || IsRequestLimitExceeded(err)
+}
+
+// hasErrorCode reports whether err carries one of the given AWS error codes.
+func hasErrorCode(err error, codes ...string) bool {
+	var apiError smithy.APIError
+	if !errors.As(err, &apiError) {
+		return false
+	}
+
+	code := apiError.ErrorCode()
+	for _, c := range codes {
+		if code == c {
+			return true
+		}
+	}
+
+	return false
+}
+
+func IsInternalServerError(err error) bool {
+	var internalServerError *types.InternalServerError
+	ok := errors.As(err, &internalServerError)
+
+	return ok || hasErrorCode(err, "InternalServerError")
+}
+
+func IsLimitExceededException(err error) bool {
+	var limitExceededException *types.LimitExceededException
+	ok := errors.As(err, &limitExceededException)
+
+	return ok || hasErrorCode(err, "LimitExceededException")
+}
+
+func IsConditionalCheckFailed(err error) bool {
+	var conditionalCheckFailedException *types.ConditionalCheckFailedException
+	ok := errors.As(err, &conditionalCheckFailedException)
+
+	return ok || hasErrorCode(err, "ConditionalCheckFailedException")
+}
+
+func IsResourceNotFound(err error) bool {
+	var resourceNotFoundException *types.ResourceNotFoundException
+	ok := errors.As(err, &resourceNotFoundException)
+
+	return ok || hasErrorCode(err, "ResourceNotFoundException")
+}
+
+func IsItemCollectionSizeLimitExceeded(err error) bool {
+	var itemCollectionSizeLimitExceededException *types.ItemCollectionSizeLimitExceededException
+	ok := errors.As(err, &itemCollectionSizeLimitExceededException)
+
+	return ok || hasErrorCode(err, "ItemCollectionSizeLimitExceededException")
+}
+
+func IsTransactionCanceled(err error) bool {
+	var transactionCanceledException *types.TransactionCanceledException
+	ok := errors.As(err, &transactionCanceledException)
+
+	return ok || hasErrorCode(err, "TransactionCanceledException")
+}
+
+// CancellationReasons returns the reasons a transaction was canceled, one per
+// item in the request and in the same order, if err is a
+// TransactionCanceledException.
+func CancellationReasons(err error) []types.CancellationReason {
+	var transactionCanceledException *types.TransactionCanceledException
+	if !errors.As(err, &transactionCanceledException) {
+		return nil
+	}
+
+	return transactionCanceledException.CancellationReasons
+}
+
+// CancellationReasonCodes returns the codes of the reasons returned by
+// CancellationReasons, such as "ConditionalCheckFailed", with "None" for
+// items that did not cause the cancellation.
+func CancellationReasonCodes(err error) []string {
+	reasons := CancellationReasons(err)
+	if reasons == nil {
+		return nil
+	}
+
+	codes := make([]string, len(reasons))
+	for i, reason := range reasons {
+		codes[i] = aws.ToString(reason.Code)
+	}
+
+	return codes
 }
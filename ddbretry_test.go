@@ -3,11 +3,15 @@ package ddbretry
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/Thumbscrew/ddbretry/ddbretrytest"
+	"github.com/aws/aws-sdk-go-v2/aws"
 	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -27,6 +31,19 @@ func TestIsProvisionedThroughputExceededException(t *testing.T) {
 			},
 			want: true,
 		},
+		{
+			name: "should return true when error only carries the ProvisionedThroughputExceededException code",
+			args: args{
+				err: &smithy.OperationError{
+					ServiceID:     "DynamoDB",
+					OperationName: "GetItem",
+					Err: &smithy.GenericAPIError{
+						Code: "ProvisionedThroughputExceededException",
+					},
+				},
+			},
+			want: true,
+		},
 		{
 			name: "should return false when error is not ProvisionedThroughputExceededException",
 			args: args{
@@ -42,67 +59,303 @@ func TestIsProvisionedThroughputExceededException(t *testing.T) {
 	}
 }
 
-type SuccessfulDynamoDBClient struct {
-	ThroughputExceededCount int
+func TestIsThrottlingError(t *testing.T) {
+	type args struct {
+		err error
+	}
+	tests := []struct {
+		name                     string
+		args                     args
+		wantThrottlingException  bool
+		wantRequestLimitExceeded bool
+		wantThrottlingError      bool
+	}{
+		{
+			name: "should detect ProvisionedThroughputExceededException as throttling error",
+			args: args{
+				err: &types.ProvisionedThroughputExceededException{},
+			},
+			wantThrottlingError: true,
+		},
+		{
+			name: "should detect ThrottlingException by error code",
+			args: args{
+				err: &smithy.GenericAPIError{Code: "ThrottlingException"},
+			},
+			wantThrottlingException: true,
+			wantThrottlingError:     true,
+		},
+		{
+			name: "should detect RequestLimitExceeded",
+			args: args{
+				err: fmt.Errorf("wrapped: %w", &types.RequestLimitExceeded{}),
+			},
+			wantRequestLimitExceeded: true,
+			wantThrottlingError:      true,
+		},
+		{
+			name: "should detect RequestLimitExceeded by error code",
+			args: args{
+				err: &smithy.GenericAPIError{Code: "RequestLimitExceeded"},
+			},
+			wantRequestLimitExceeded: true,
+			wantThrottlingError:      true,
+		},
+		{
+			name: "should return false for other errors",
+			args: args{
+				err: &smithy.GenericAPIError{Code: "ValidationException"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantThrottlingException, IsThrottlingException(tt.args.err))
+			assert.Equal(t, tt.wantRequestLimitExceeded, IsRequestLimitExceeded(tt.args.err))
+			assert.Equal(t, tt.wantThrottlingError, IsThrottlingError(tt.args.err))
+		})
+	}
 }
 
-func (c *SuccessfulDynamoDBClient) GetItem(ctx context.Context, input *ddb.GetItemInput, o ...func(*ddb.Options)) (*ddb.GetItemOutput, error) {
-	for c.ThroughputExceededCount > 0 {
-		c.ThroughputExceededCount--
-		return nil, &types.ProvisionedThroughputExceededException{}
+func TestErrorPredicates(t *testing.T) {
+	tests := []struct {
+		name      string
+		predicate func(error) bool
+		err       error
+	}{
+		{
+			name:      "IsConditionalCheckFailed",
+			predicate: IsConditionalCheckFailed,
+			err:       &types.ConditionalCheckFailedException{},
+		},
+		{
+			name:      "IsResourceNotFound",
+			predicate: IsResourceNotFound,
+			err:       &types.ResourceNotFoundException{},
+		},
+		{
+			name:      "IsItemCollectionSizeLimitExceeded",
+			predicate: IsItemCollectionSizeLimitExceeded,
+			err:       &types.ItemCollectionSizeLimitExceededException{},
+		},
+		{
+			name:      "IsTransactionCanceled",
+			predicate: IsTransactionCanceled,
+			err:       &types.TransactionCanceledException{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.True(t, tt.predicate(tt.err))
+			assert.True(t, tt.predicate(fmt.Errorf("wrapped: %w", tt.err)))
+			assert.True(t, tt.predicate(&smithy.GenericAPIError{Code: tt.err.(smithy.APIError).ErrorCode()}))
+			assert.False(t, tt.predicate(errors.New("foo")))
+		})
 	}
+}
 
-	return &ddb.GetItemOutput{}, nil
+func TestCancellationReasons(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", &types.TransactionCanceledException{
+		CancellationReasons: []types.CancellationReason{
+			{Code: aws.String("None")},
+			{Code: aws.String("ConditionalCheckFailed"), Message: aws.String("The conditional request failed")},
+		},
+	})
+
+	assert.Len(t, CancellationReasons(err), 2)
+	assert.Equal(t, []string{"None", "ConditionalCheckFailed"}, CancellationReasonCodes(err))
+	assert.Nil(t, CancellationReasons(errors.New("foo")))
+	assert.Nil(t, CancellationReasonCodes(errors.New("foo")))
 }
 
-func (c *SuccessfulDynamoDBClient) DeleteItem(ctx context.Context, input *ddb.DeleteItemInput, o ...func(*ddb.Options)) (*ddb.DeleteItemOutput, error) {
-	for c.ThroughputExceededCount > 0 {
-		c.ThroughputExceededCount--
-		return nil, &types.ProvisionedThroughputExceededException{}
+func TestNewRetryDynamoDBClient(t *testing.T) {
+	tests := []struct {
+		name      string
+		retries   int
+		backOff   time.Duration
+		wantErrFn func(error) bool
+	}{
+		{
+			name:    "should create client with valid settings",
+			retries: 3,
+			backOff: time.Second,
+		},
+		{
+			name: "should create client without retries",
+		},
+		{
+			name:    "should create client with infinite retries",
+			retries: InfiniteRetries,
+		},
+		{
+			name:      "should return InvalidRetryError when retries value is invalid",
+			retries:   -2,
+			wantErrFn: IsInvalidRetryError,
+		},
+		{
+			name:      "should return InvalidRetryError for any other negative retries value",
+			retries:   -100,
+			wantErrFn: IsInvalidRetryError,
+		},
+		{
+			name:      "should return InvalidBackOffError when backoff is negative",
+			retries:   3,
+			backOff:   -time.Second,
+			wantErrFn: IsInvalidBackOffError,
+		},
 	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewRetryDynamoDBClient(ddbretrytest.NewFakeClient(), tt.retries, tt.backOff)
+			if tt.wantErrFn != nil {
+				assert.True(t, tt.wantErrFn(err))
+				assert.Nil(t, c)
+				return
+			}
 
-	return &ddb.DeleteItemOutput{}, nil
+			assert.NoError(t, err)
+			assert.Equal(t, tt.retries, c.Retries)
+			assert.Equal(t, tt.backOff, c.BackOffTime)
+			assert.Equal(t, tt.retries, c.policy().Retries)
+		})
+	}
 }
 
-func (c *SuccessfulDynamoDBClient) PutItem(ctx context.Context, input *ddb.PutItemInput, o ...func(*ddb.Options)) (*ddb.PutItemOutput, error) {
-	for c.ThroughputExceededCount > 0 {
-		c.ThroughputExceededCount--
-		return nil, &types.ProvisionedThroughputExceededException{}
+func TestRetryDynamoDBClient_ReaderWriter(t *testing.T) {
+	newClient := func(op string) *RetryDynamoDBClient {
+		return &RetryDynamoDBClient{
+			DynamoDBClient: ddbretrytest.NewFakeClient().Script(op, ddbretrytest.Throttles(2)...),
+			Retries:        2,
+		}
 	}
+	ctx := context.Background()
+
+	var r DynamoDBReader = newClient("Query")
+	_, err := r.Query(ctx, &ddb.QueryInput{})
+	assert.NoError(t, err)
+	r = newClient("Scan")
+	_, err = r.Scan(ctx, &ddb.ScanInput{})
+	assert.NoError(t, err)
 
-	return &ddb.PutItemOutput{}, nil
+	var w DynamoDBWriter = newClient("UpdateItem")
+	_, err = w.UpdateItem(ctx, &ddb.UpdateItemInput{})
+	assert.NoError(t, err)
+
+	c := newClient("Query")
+	c.Retries = 1
+	_, err = c.Query(ctx, &ddb.QueryInput{})
+	assert.True(t, IsProvisionedThroughputExceededException(err))
+	assert.Equal(t, int64(2), c.Stats().Attempts)
 }
 
-type FailingDynamoDBClient struct {
-	ThroughputExceededCount int
-	Err                     error
+func TestRetryDynamoDBClient_frozenSettings(t *testing.T) {
+	c, err := NewRetryDynamoDBClient(ddbretrytest.NewFakeClient().Script("GetItem", ddbretrytest.Throttled()), 0, 0)
+	assert.NoError(t, err)
+
+	c.DisableRetries = false
+	c.Retries = 3
+	_, err = c.GetItem(context.Background(), &ddb.GetItemInput{})
+	assert.True(t, IsProvisionedThroughputExceededException(err))
+
+	d, err := c.With(WithRetries(3))
+	assert.NoError(t, err)
+	d.DynamoDBClient = ddbretrytest.NewFakeClient().Script("GetItem", ddbretrytest.Throttled())
+	_, err = d.GetItem(context.Background(), &ddb.GetItemInput{})
+	assert.NoError(t, err)
 }
 
-func (c *FailingDynamoDBClient) GetItem(ctx context.Context, input *ddb.GetItemInput, o ...func(*ddb.Options)) (*ddb.GetItemOutput, error) {
-	for c.ThroughputExceededCount > 0 {
-		c.ThroughputExceededCount--
-		return nil, &types.ProvisionedThroughputExceededException{}
-	}
+type unwrappingDynamoDBClient struct {
+	DynamoDBClient
+}
 
-	return nil, c.Err
+func (c *unwrappingDynamoDBClient) Unwrap() DynamoDBClient {
+	return c.DynamoDBClient
 }
 
-func (c *FailingDynamoDBClient) DeleteItem(ctx context.Context, input *ddb.DeleteItemInput, o ...func(*ddb.Options)) (*ddb.DeleteItemOutput, error) {
-	for c.ThroughputExceededCount > 0 {
-		c.ThroughputExceededCount--
-		return nil, &types.ProvisionedThroughputExceededException{}
+func TestNewRetryDynamoDBClient_nested(t *testing.T) {
+	inner, err := NewRetryDynamoDBClient(ddbretrytest.NewFakeClient(), 3, time.Second)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		client DynamoDBClient
+		want   bool
+	}{
+		{
+			name:   "should accept a plain client",
+			client: ddbretrytest.NewFakeClient(),
+		},
+		{
+			name:   "should accept a decorator of a plain client",
+			client: &unwrappingDynamoDBClient{ddbretrytest.NewFakeClient()},
+		},
+		{
+			name:   "should reject a RetryDynamoDBClient",
+			client: inner,
+			want:   true,
+		},
+		{
+			name:   "should reject a decorator of a RetryDynamoDBClient",
+			client: &unwrappingDynamoDBClient{&unwrappingDynamoDBClient{inner}},
+			want:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewRetryDynamoDBClient(tt.client, 3, time.Second)
+			assert.Equal(t, tt.want, IsNestedClientError(err))
+			assert.Equal(t, tt.want, c == nil)
+
+			c, err = NewFromConfig(tt.client, Config{})
+			assert.Equal(t, tt.want, IsNestedClientError(err))
+			assert.Equal(t, tt.want, c == nil)
+		})
 	}
+}
+
+func TestRetryDynamoDBClient_Unwrap(t *testing.T) {
+	client := ddbretrytest.NewFakeClient()
+	c := &RetryDynamoDBClient{DynamoDBClient: client}
 
-	return nil, c.Err
+	assert.Same(t, client, c.Unwrap())
 }
 
-func (c *FailingDynamoDBClient) PutItem(ctx context.Context, input *ddb.PutItemInput, o ...func(*ddb.Options)) (*ddb.PutItemOutput, error) {
-	for c.ThroughputExceededCount > 0 {
-		c.ThroughputExceededCount--
-		return nil, &types.ProvisionedThroughputExceededException{}
+func TestRetryDynamoDBClient_With(t *testing.T) {
+	client := ddbretrytest.NewFakeClient().Script("GetItem", ddbretrytest.Throttled())
+	breaker := NewCircuitBreaker(10, time.Second, 1)
+	c := &RetryDynamoDBClient{
+		DynamoDBClient: client,
+		Retries:        3,
+		BackOffTime:    time.Millisecond,
+		CircuitBreaker: breaker,
 	}
+	_, err := c.GetItem(context.Background(), &ddb.GetItemInput{})
+	assert.NoError(t, err)
 
-	return nil, c.Err
+	background, err := c.With(WithRetries(10), WithBackOff(time.Second), WithMaxBackOff(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, 10, background.Retries)
+	assert.Equal(t, time.Second, background.BackOffTime)
+	assert.Equal(t, time.Minute, background.MaxBackOff)
+	assert.Same(t, client, background.DynamoDBClient)
+	assert.Same(t, breaker, background.CircuitBreaker)
+	assert.Equal(t, int64(0), background.Stats().Attempts)
+
+	assert.Equal(t, 3, c.Retries)
+	assert.Equal(t, time.Millisecond, c.BackOffTime)
+	assert.Equal(t, int64(2), c.Stats().Attempts)
+
+	background, err = c.With(WithMaxAttempts(2))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, background.policy().Retries)
+
+	background, err = c.With(WithRetries(-2))
+	assert.Nil(t, background)
+	assert.True(t, IsInvalidRetryError(err))
+
+	background, err = c.With(WithMaxAttempts(0))
+	assert.Nil(t, background)
+	assert.True(t, IsInvalidConfigError(err))
 }
 
 func TestRetryDynamoDBClient(t *testing.T) {
@@ -134,9 +387,9 @@ func TestRetryDynamoDBClient(t *testing.T) {
 		{
 			name: "should receive output from successful call in DynamoDBClient",
 			fields: fields{
-				GetItemDynamoDBClient:    &SuccessfulDynamoDBClient{},
-				DeleteItemDynamoDBClient: &SuccessfulDynamoDBClient{},
-				PutItemDynamoDBClient:    &SuccessfulDynamoDBClient{},
+				GetItemDynamoDBClient:    ddbretrytest.NewFakeClient(),
+				DeleteItemDynamoDBClient: ddbretrytest.NewFakeClient(),
+				PutItemDynamoDBClient:    ddbretrytest.NewFakeClient(),
 			},
 			args: args{
 				ctx:             ctx,
@@ -152,15 +405,9 @@ func TestRetryDynamoDBClient(t *testing.T) {
 		{
 			name: "should receive error from failed call in DynamoDBClient",
 			fields: fields{
-				GetItemDynamoDBClient: &FailingDynamoDBClient{
-					Err: errors.New("foo"),
-				},
-				DeleteItemDynamoDBClient: &FailingDynamoDBClient{
-					Err: errors.New("foo"),
-				},
-				PutItemDynamoDBClient: &FailingDynamoDBClient{
-					Err: errors.New("foo"),
-				},
+				GetItemDynamoDBClient:    ddbretrytest.NewFakeClient().SetError("GetItem", errors.New("foo")),
+				DeleteItemDynamoDBClient: ddbretrytest.NewFakeClient().SetError("DeleteItem", errors.New("foo")),
+				PutItemDynamoDBClient:    ddbretrytest.NewFakeClient().SetError("PutItem", errors.New("foo")),
 			},
 			args: args{
 				ctx:             ctx,
@@ -176,16 +423,10 @@ func TestRetryDynamoDBClient(t *testing.T) {
 		{
 			name: "should receive output when retries is higher than number of throughput exceptions",
 			fields: fields{
-				GetItemDynamoDBClient: &SuccessfulDynamoDBClient{
-					ThroughputExceededCount: 2,
-				},
-				DeleteItemDynamoDBClient: &SuccessfulDynamoDBClient{
-					ThroughputExceededCount: 2,
-				},
-				PutItemDynamoDBClient: &SuccessfulDynamoDBClient{
-					ThroughputExceededCount: 2,
-				},
-				Retries: 3,
+				GetItemDynamoDBClient:    ddbretrytest.NewFakeClient().Script("GetItem", ddbretrytest.Throttles(2)...),
+				DeleteItemDynamoDBClient: ddbretrytest.NewFakeClient().Script("DeleteItem", ddbretrytest.Throttles(2)...),
+				PutItemDynamoDBClient:    ddbretrytest.NewFakeClient().Script("PutItem", ddbretrytest.Throttles(2)...),
+				Retries:                  3,
 			},
 			args: args{
 				ctx:             ctx,
@@ -201,16 +442,10 @@ func TestRetryDynamoDBClient(t *testing.T) {
 		{
 			name: "should receive throughput exception when number of throughput exceptions is higher than retries",
 			fields: fields{
-				GetItemDynamoDBClient: &SuccessfulDynamoDBClient{
-					ThroughputExceededCount: 3,
-				},
-				DeleteItemDynamoDBClient: &SuccessfulDynamoDBClient{
-					ThroughputExceededCount: 3,
-				},
-				PutItemDynamoDBClient: &SuccessfulDynamoDBClient{
-					ThroughputExceededCount: 3,
-				},
-				Retries: 2,
+				GetItemDynamoDBClient:    ddbretrytest.NewFakeClient().Script("GetItem", ddbretrytest.Throttles(3)...),
+				DeleteItemDynamoDBClient: ddbretrytest.NewFakeClient().Script("DeleteItem", ddbretrytest.Throttles(3)...),
+				PutItemDynamoDBClient:    ddbretrytest.NewFakeClient().Script("PutItem", ddbretrytest.Throttles(3)...),
+				Retries:                  2,
 			},
 			args: args{
 				ctx:             ctx,
@@ -221,24 +456,15 @@ func TestRetryDynamoDBClient(t *testing.T) {
 			wantGetItemOutput:    nil,
 			wantDeleteItemOutput: nil,
 			wantPutItemOutput:    nil,
-			wantErr:              &types.ProvisionedThroughputExceededException{},
+			wantErr:              ddbretrytest.Throttled(),
 		},
 		{
 			name: "should receive error after throughput exceptions when retries is higher",
 			fields: fields{
-				GetItemDynamoDBClient: &FailingDynamoDBClient{
-					ThroughputExceededCount: 2,
-					Err:                     errors.New("foo"),
-				},
-				DeleteItemDynamoDBClient: &FailingDynamoDBClient{
-					ThroughputExceededCount: 2,
-					Err:                     errors.New("foo"),
-				},
-				PutItemDynamoDBClient: &FailingDynamoDBClient{
-					ThroughputExceededCount: 2,
-					Err:                     errors.New("foo"),
-				},
-				Retries: 3,
+				GetItemDynamoDBClient:    ddbretrytest.NewFakeClient().Script("GetItem", ddbretrytest.Throttles(2)...).SetError("GetItem", errors.New("foo")),
+				DeleteItemDynamoDBClient: ddbretrytest.NewFakeClient().Script("DeleteItem", ddbretrytest.Throttles(2)...).SetError("DeleteItem", errors.New("foo")),
+				PutItemDynamoDBClient:    ddbretrytest.NewFakeClient().Script("PutItem", ddbretrytest.Throttles(2)...).SetError("PutItem", errors.New("foo")),
+				Retries:                  3,
 			},
 			args: args{
 				ctx:             ctx,
@@ -254,16 +480,10 @@ func TestRetryDynamoDBClient(t *testing.T) {
 		{
 			name: "should receive output after throughput exceptions when retries is infinite",
 			fields: fields{
-				GetItemDynamoDBClient: &SuccessfulDynamoDBClient{
-					ThroughputExceededCount: 10,
-				},
-				DeleteItemDynamoDBClient: &SuccessfulDynamoDBClient{
-					ThroughputExceededCount: 10,
-				},
-				PutItemDynamoDBClient: &SuccessfulDynamoDBClient{
-					ThroughputExceededCount: 10,
-				},
-				Retries: -1,
+				GetItemDynamoDBClient:    ddbretrytest.NewFakeClient().Script("GetItem", ddbretrytest.Throttles(10)...),
+				DeleteItemDynamoDBClient: ddbretrytest.NewFakeClient().Script("DeleteItem", ddbretrytest.Throttles(10)...),
+				PutItemDynamoDBClient:    ddbretrytest.NewFakeClient().Script("PutItem", ddbretrytest.Throttles(10)...),
+				Retries:                  -1,
 			},
 			args: args{
 				ctx:             ctx,
@@ -279,16 +499,10 @@ func TestRetryDynamoDBClient(t *testing.T) {
 		{
 			name: "should receive InvalidRetryError when retries value is invalid",
 			fields: fields{
-				GetItemDynamoDBClient: &SuccessfulDynamoDBClient{
-					ThroughputExceededCount: 10,
-				},
-				DeleteItemDynamoDBClient: &SuccessfulDynamoDBClient{
-					ThroughputExceededCount: 10,
-				},
-				PutItemDynamoDBClient: &SuccessfulDynamoDBClient{
-					ThroughputExceededCount: 10,
-				},
-				Retries: -2,
+				GetItemDynamoDBClient:    ddbretrytest.NewFakeClient().Script("GetItem", ddbretrytest.Throttles(10)...),
+				DeleteItemDynamoDBClient: ddbretrytest.NewFakeClient().Script("DeleteItem", ddbretrytest.Throttles(10)...),
+				PutItemDynamoDBClient:    ddbretrytest.NewFakeClient().Script("PutItem", ddbretrytest.Throttles(10)...),
+				Retries:                  -2,
 			},
 			args: args{
 				ctx:             ctx,
@@ -0,0 +1,33 @@
+package ddbretrytest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ ddbretry.Clock = (*FakeClock)(nil)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	clock := NewFakeClock(start)
+	c := &ddbretry.RetryDynamoDBClient{
+		DynamoDBClient:  NewFakeClient().Script("Query", Throttles(2)...),
+		Retries:         2,
+		BackOffTime:     time.Second,
+		BackOffStrategy: ddbretry.ExponentialBackOff,
+		Clock:           clock,
+	}
+
+	_, err := c.Query(context.Background(), &ddb.QueryInput{})
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, clock.Sleeps())
+	assert.Equal(t, start.Add(3*time.Second), clock.Now())
+
+	clock.Advance(time.Minute)
+	assert.Equal(t, start.Add(63*time.Second), clock.Now())
+}
@@ -0,0 +1,286 @@
+// Package ddbretrytest provides a scriptable fake DynamoDB client for testing
+// code that uses ddbretry.
+package ddbretrytest
+
+import (
+	"context"
+	"sync"
+
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Call records an operation received by a FakeClient.
+type Call struct {
+	Operation string
+	Input     interface{}
+}
+
+// FakeClient is a ddbretry.DynamoDBClient whose responses are scripted per
+// operation. Each call takes the next error queued for its operation with
+// Script, failing with it if it is not nil. Once the queue is empty, calls
+// fail with the error set with SetError, if any, or succeed. Successful calls
+// return the output set with SetOutput, or an empty output. FakeClient is
+// safe for concurrent use.
+type FakeClient struct {
+	mu      sync.Mutex
+	scripts map[string][]error
+	errs    map[string]error
+	outputs map[string]interface{}
+	calls   []Call
+}
+
+// NewFakeClient returns a FakeClient. The zero value is also ready to use.
+func NewFakeClient() *FakeClient {
+	return &FakeClient{
+		scripts: map[string][]error{},
+		errs:    map[string]error{},
+		outputs: map[string]interface{}{},
+	}
+}
+
+// Script queues errs for the named operation, such as "GetItem", after any
+// already queued. A nil error makes that call succeed.
+func (f *FakeClient) Script(op string, errs ...error) *FakeClient {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.scripts == nil {
+		f.scripts = map[string][]error{}
+	}
+	f.scripts[op] = append(f.scripts[op], errs...)
+
+	return f
+}
+
+// SetError makes calls of the named operation fail with err once the errors
+// queued for it with Script run out, or succeed again if err is nil.
+func (f *FakeClient) SetError(op string, err error) *FakeClient {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.errs == nil {
+		f.errs = map[string]error{}
+	}
+	f.errs[op] = err
+
+	return f
+}
+
+// SetOutput sets the output returned by successful calls of the named
+// operation. output must be a pointer to the operation's output type, such
+// as *dynamodb.GetItemOutput.
+func (f *FakeClient) SetOutput(op string, output interface{}) *FakeClient {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.outputs == nil {
+		f.outputs = map[string]interface{}{}
+	}
+	f.outputs[op] = output
+
+	return f
+}
+
+// Calls returns every call received so far, in order.
+func (f *FakeClient) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]Call(nil), f.calls...)
+}
+
+// CallCount returns the number of calls received for the named operation.
+func (f *FakeClient) CallCount(op string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	n := 0
+	for _, call := range f.calls {
+		if call.Operation == op {
+			n++
+		}
+	}
+
+	return n
+}
+
+// Inputs returns the inputs received for the named operation, in order.
+func (f *FakeClient) Inputs(op string) []interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var inputs []interface{}
+	for _, call := range f.calls {
+		if call.Operation == op {
+			inputs = append(inputs, call.Input)
+		}
+	}
+
+	return inputs
+}
+
+// Throttles returns n ProvisionedThroughputExceededExceptions, for scripting
+// a run of throttled attempts:
+//
+//	fake.Script("GetItem", ddbretrytest.Throttles(3)...)
+func Throttles(n int) []error {
+	errs := make([]error, n)
+	for i := range errs {
+		errs[i] = Throttled()
+	}
+
+	return errs
+}
+
+func Throttled() error {
+	return &types.ProvisionedThroughputExceededException{
+		Message: stringPtr("The level of configured provisioned throughput for the table was exceeded."),
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+// call records a call and returns the output it should succeed with, or the
+// error it should fail with.
+func (f *FakeClient) call(op string, input interface{}) (interface{}, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = append(f.calls, Call{Operation: op, Input: input})
+	if script := f.scripts[op]; len(script) > 0 {
+		err := script[0]
+		f.scripts[op] = script[1:]
+		if err != nil {
+			return nil, err
+		}
+		return f.outputs[op], nil
+	}
+	if err := f.errs[op]; err != nil {
+		return nil, err
+	}
+
+	return f.outputs[op], nil
+}
+
+func (f *FakeClient) GetItem(ctx context.Context, input *ddb.GetItemInput, o ...func(*ddb.Options)) (*ddb.GetItemOutput, error) {
+	output, err := f.call("GetItem", input)
+	if err != nil {
+		return nil, err
+	}
+	if output, ok := output.(*ddb.GetItemOutput); ok {
+		return output, nil
+	}
+
+	return &ddb.GetItemOutput{}, nil
+}
+
+func (f *FakeClient) DeleteItem(ctx context.Context, input *ddb.DeleteItemInput, o ...func(*ddb.Options)) (*ddb.DeleteItemOutput, error) {
+	output, err := f.call("DeleteItem", input)
+	if err != nil {
+		return nil, err
+	}
+	if output, ok := output.(*ddb.DeleteItemOutput); ok {
+		return output, nil
+	}
+
+	return &ddb.DeleteItemOutput{}, nil
+}
+
+func (f *FakeClient) PutItem(ctx context.Context, input *ddb.PutItemInput, o ...func(*ddb.Options)) (*ddb.PutItemOutput, error) {
+	output, err := f.call("PutItem", input)
+	if err != nil {
+		return nil, err
+	}
+	if output, ok := output.(*ddb.PutItemOutput); ok {
+		return output, nil
+	}
+
+	return &ddb.PutItemOutput{}, nil
+}
+
+func (f *FakeClient) UpdateItem(ctx context.Context, input *ddb.UpdateItemInput, o ...func(*ddb.Options)) (*ddb.UpdateItemOutput, error) {
+	output, err := f.call("UpdateItem", input)
+	if err != nil {
+		return nil, err
+	}
+	if output, ok := output.(*ddb.UpdateItemOutput); ok {
+		return output, nil
+	}
+
+	return &ddb.UpdateItemOutput{}, nil
+}
+
+func (f *FakeClient) Query(ctx context.Context, input *ddb.QueryInput, o ...func(*ddb.Options)) (*ddb.QueryOutput, error) {
+	output, err := f.call("Query", input)
+	if err != nil {
+		return nil, err
+	}
+	if output, ok := output.(*ddb.QueryOutput); ok {
+		return output, nil
+	}
+
+	return &ddb.QueryOutput{}, nil
+}
+
+func (f *FakeClient) Scan(ctx context.Context, input *ddb.ScanInput, o ...func(*ddb.Options)) (*ddb.ScanOutput, error) {
+	output, err := f.call("Scan", input)
+	if err != nil {
+		return nil, err
+	}
+	if output, ok := output.(*ddb.ScanOutput); ok {
+		return output, nil
+	}
+
+	return &ddb.ScanOutput{}, nil
+}
+
+func (f *FakeClient) BatchGetItem(ctx context.Context, input *ddb.BatchGetItemInput, o ...func(*ddb.Options)) (*ddb.BatchGetItemOutput, error) {
+	output, err := f.call("BatchGetItem", input)
+	if err != nil {
+		return nil, err
+	}
+	if output, ok := output.(*ddb.BatchGetItemOutput); ok {
+		return output, nil
+	}
+
+	return &ddb.BatchGetItemOutput{}, nil
+}
+
+func (f *FakeClient) BatchWriteItem(ctx context.Context, input *ddb.BatchWriteItemInput, o ...func(*ddb.Options)) (*ddb.BatchWriteItemOutput, error) {
+	output, err := f.call("BatchWriteItem", input)
+	if err != nil {
+		return nil, err
+	}
+	if output, ok := output.(*ddb.BatchWriteItemOutput); ok {
+		return output, nil
+	}
+
+	return &ddb.BatchWriteItemOutput{}, nil
+}
+
+func (f *FakeClient) TransactGetItems(ctx context.Context, input *ddb.TransactGetItemsInput, o ...func(*ddb.Options)) (*ddb.TransactGetItemsOutput, error) {
+	output, err := f.call("TransactGetItems", input)
+	if err != nil {
+		return nil, err
+	}
+	if output, ok := output.(*ddb.TransactGetItemsOutput); ok {
+		return output, nil
+	}
+
+	return &ddb.TransactGetItemsOutput{}, nil
+}
+
+func (f *FakeClient) TransactWriteItems(ctx context.Context, input *ddb.TransactWriteItemsInput, o ...func(*ddb.Options)) (*ddb.TransactWriteItemsOutput, error) {
+	output, err := f.call("TransactWriteItems", input)
+	if err != nil {
+		return nil, err
+	}
+	if output, ok := output.(*ddb.TransactWriteItemsOutput); ok {
+		return output, nil
+	}
+
+	return &ddb.TransactWriteItemsOutput{}, nil
+}
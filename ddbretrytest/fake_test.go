@@ -0,0 +1,64 @@
+package ddbretrytest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ ddbretry.DynamoDBClient = (*FakeClient)(nil)
+
+func TestFakeClient(t *testing.T) {
+	validation := errors.New("validation failed")
+	fake := NewFakeClient().
+		Script("GetItem", Throttles(2)...).
+		Script("PutItem", Throttled(), validation).
+		SetOutput("GetItem", &ddb.GetItemOutput{Item: nil, ConsumedCapacity: nil})
+	c, err := ddbretry.NewRetryDynamoDBClient(fake, 3, time.Millisecond)
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = c.GetItem(ctx, &ddb.GetItemInput{TableName: aws.String("users")})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, fake.CallCount("GetItem"))
+
+	_, err = c.PutItem(ctx, &ddb.PutItemInput{TableName: aws.String("orders")})
+	assert.ErrorIs(t, err, validation)
+	assert.Equal(t, 2, fake.CallCount("PutItem"))
+
+	_, err = c.PutItem(ctx, &ddb.PutItemInput{TableName: aws.String("orders")})
+	assert.NoError(t, err)
+
+	assert.Len(t, fake.Calls(), 6)
+	inputs := fake.Inputs("PutItem")
+	if assert.Len(t, inputs, 3) {
+		assert.Equal(t, "orders", aws.ToString(inputs[0].(*ddb.PutItemInput).TableName))
+	}
+	assert.True(t, ddbretry.IsThrottlingError(Throttled()))
+}
+
+func TestFakeClient_SetError(t *testing.T) {
+	validation := errors.New("validation failed")
+	var fake FakeClient
+	fake.Script("GetItem", Throttled(), nil).SetError("GetItem", validation)
+	ctx := context.Background()
+
+	_, err := fake.GetItem(ctx, &ddb.GetItemInput{})
+	assert.True(t, ddbretry.IsThrottlingError(err))
+	_, err = fake.GetItem(ctx, &ddb.GetItemInput{})
+	assert.NoError(t, err)
+	_, err = fake.GetItem(ctx, &ddb.GetItemInput{})
+	assert.ErrorIs(t, err, validation)
+	_, err = fake.PutItem(ctx, &ddb.PutItemInput{})
+	assert.NoError(t, err)
+
+	fake.SetError("GetItem", nil)
+	_, err = fake.GetItem(ctx, &ddb.GetItemInput{})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,117 @@
+package ddbstreams
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Thumbscrew/ddbretry"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ShardEnd is the checkpoint of a shard that has been read to its end.
+const ShardEnd = "SHARD_END"
+
+// CheckpointStore holds the sequence number of the last record processed in
+// each shard, or ShardEnd once a shard has been read to its end.
+// Implementations must be safe for concurrent use.
+type CheckpointStore interface {
+	// GetCheckpoint returns the checkpoint of the shard, or "" if it has
+	// none.
+	GetCheckpoint(ctx context.Context, streamARN string, shardID string) (string, error)
+	SetCheckpoint(ctx context.Context, streamARN string, shardID string, checkpoint string) error
+}
+
+// MemoryCheckpointStore is a CheckpointStore holding checkpoints in memory,
+// for consumers that can afford to reread a stream when they restart.
+type MemoryCheckpointStore struct {
+	mu          sync.Mutex
+	checkpoints map[string]string
+}
+
+func NewMemoryCheckpointStore() *MemoryCheckpointStore {
+	return &MemoryCheckpointStore{
+		checkpoints: map[string]string{},
+	}
+}
+
+func (s *MemoryCheckpointStore) GetCheckpoint(_ context.Context, streamARN string, shardID string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.checkpoints[checkpointKey(streamARN, shardID)], nil
+}
+
+func (s *MemoryCheckpointStore) SetCheckpoint(_ context.Context, streamARN string, shardID string, checkpoint string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.checkpoints[checkpointKey(streamARN, shardID)] = checkpoint
+
+	return nil
+}
+
+// TableKeyAttribute and TableCheckpointAttribute name the attributes of the
+// items a TableCheckpointStore writes: the string hash key of its table and
+// the checkpoint.
+const (
+	TableKeyAttribute        = "shard"
+	TableCheckpointAttribute = "checkpoint"
+)
+
+// TableCheckpointStore is a CheckpointStore holding checkpoints in a
+// DynamoDB table with a string hash key named TableKeyAttribute, one item per
+// shard. Its Client is usually a RetryDynamoDBClient, so checkpoints survive
+// throttling of the table.
+type TableCheckpointStore struct {
+	Client ddbretry.DynamoDBClient
+	Table  string
+}
+
+func NewTableCheckpointStore(client ddbretry.DynamoDBClient, table string) *TableCheckpointStore {
+	return &TableCheckpointStore{
+		Client: client,
+		Table:  table,
+	}
+}
+
+func (s *TableCheckpointStore) GetCheckpoint(ctx context.Context, streamARN string, shardID string) (string, error) {
+	output, err := s.Client.GetItem(ctx, &ddb.GetItemInput{
+		TableName:      aws.String(s.Table),
+		Key:            s.key(streamARN, shardID),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	checkpoint, _ := output.Item[TableCheckpointAttribute].(*types.AttributeValueMemberS)
+	if checkpoint == nil {
+		return "", nil
+	}
+
+	return checkpoint.Value, nil
+}
+
+func (s *TableCheckpointStore) SetCheckpoint(ctx context.Context, streamARN string, shardID string, checkpoint string) error {
+	item := s.key(streamARN, shardID)
+	item[TableCheckpointAttribute] = &types.AttributeValueMemberS{Value: checkpoint}
+
+	_, err := s.Client.PutItem(ctx, &ddb.PutItemInput{
+		TableName: aws.String(s.Table),
+		Item:      item,
+	})
+
+	return err
+}
+
+func (s *TableCheckpointStore) key(streamARN string, shardID string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		TableKeyAttribute: &types.AttributeValueMemberS{Value: checkpointKey(streamARN, shardID)},
+	}
+}
+
+func checkpointKey(streamARN string, shardID string) string {
+	return streamARN + "/" + shardID
+}
@@ -0,0 +1,51 @@
+package ddbstreams
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Thumbscrew/ddbretry/ddbretrytest"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryCheckpointStore(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryCheckpointStore()
+
+	checkpoint, err := s.GetCheckpoint(ctx, testStreamARN, "shard")
+	assert.NoError(t, err)
+	assert.Empty(t, checkpoint)
+
+	assert.NoError(t, s.SetCheckpoint(ctx, testStreamARN, "shard", "42"))
+	checkpoint, err = s.GetCheckpoint(ctx, testStreamARN, "shard")
+	assert.NoError(t, err)
+	assert.Equal(t, "42", checkpoint)
+}
+
+func TestTableCheckpointStore(t *testing.T) {
+	ctx := context.Background()
+	fake := ddbretrytest.NewFakeClient()
+	s := NewTableCheckpointStore(fake, "checkpoints")
+
+	checkpoint, err := s.GetCheckpoint(ctx, testStreamARN, "shard")
+	assert.NoError(t, err)
+	assert.Empty(t, checkpoint)
+
+	assert.NoError(t, s.SetCheckpoint(ctx, testStreamARN, "shard", "42"))
+	put := fake.Inputs("PutItem")[0].(*ddb.PutItemInput)
+	assert.Equal(t, "checkpoints", aws.ToString(put.TableName))
+	assert.Equal(t, map[string]ddbtypes.AttributeValue{
+		TableKeyAttribute:        &ddbtypes.AttributeValueMemberS{Value: testStreamARN + "/shard"},
+		TableCheckpointAttribute: &ddbtypes.AttributeValueMemberS{Value: "42"},
+	}, put.Item)
+
+	fake.SetOutput("GetItem", &ddb.GetItemOutput{Item: put.Item})
+	checkpoint, err = s.GetCheckpoint(ctx, testStreamARN, "shard")
+	assert.NoError(t, err)
+	assert.Equal(t, "42", checkpoint)
+	get := fake.Inputs("GetItem")[1].(*ddb.GetItemInput)
+	assert.True(t, aws.ToBool(get.ConsistentRead))
+}
@@ -0,0 +1,85 @@
+// Package ddbstreams consumes DynamoDB Streams with ddbretry's retry
+// handling. RetryStreamsClient retries Streams calls under a RetryPolicy or
+// the settings of a RetryDynamoDBClient, and Consumer builds a consumption loop on top of it:
+// it discovers the shards of a stream, reads each of them from its last
+// checkpoint, hands their records to a Handler and checkpoints them in a
+// CheckpointStore.
+package ddbstreams
+
+import (
+	"context"
+
+	"github.com/Thumbscrew/ddbretry"
+	streams "github.com/aws/aws-sdk-go-v2/service/dynamodbstreams"
+)
+
+// StreamsClient is the subset of the DynamoDB Streams client used by this
+// package.
+type StreamsClient interface {
+	DescribeStream(context.Context, *streams.DescribeStreamInput, ...func(*streams.Options)) (*streams.DescribeStreamOutput, error)
+	GetShardIterator(context.Context, *streams.GetShardIteratorInput, ...func(*streams.Options)) (*streams.GetShardIteratorOutput, error)
+	GetRecords(context.Context, *streams.GetRecordsInput, ...func(*streams.Options)) (*streams.GetRecordsOutput, error)
+	ListStreams(context.Context, *streams.ListStreamsInput, ...func(*streams.Options)) (*streams.ListStreamsOutput, error)
+}
+
+var _ StreamsClient = (*streams.Client)(nil)
+
+// RetryStreamsClient retries the calls of a StreamsClient under Policy with
+// ddbretry.DoWithPolicy. Retrier, when set, is used instead, retrying calls
+// with ddbretry.Do under its settings for the operation called, so Streams
+// calls share its policies, hooks, metrics and limits; set OperationPolicies
+// on it to give GetRecords, which DynamoDB Streams throttles with a
+// LimitExceededException, its own policy. Retrier's embedded DynamoDBClient
+// is not used.
+type RetryStreamsClient struct {
+	StreamsClient
+	Policy  ddbretry.RetryPolicy
+	Retrier *ddbretry.RetryDynamoDBClient
+}
+
+var _ StreamsClient = (*RetryStreamsClient)(nil)
+
+func NewRetryStreamsClient(client StreamsClient, policy ddbretry.RetryPolicy) *RetryStreamsClient {
+	return &RetryStreamsClient{
+		StreamsClient: client,
+		Policy:        policy,
+	}
+}
+
+// Unwrap returns the client c wraps.
+func (c *RetryStreamsClient) Unwrap() StreamsClient {
+	return c.StreamsClient
+}
+
+func (c *RetryStreamsClient) DescribeStream(ctx context.Context, input *streams.DescribeStreamInput, o ...func(*streams.Options)) (*streams.DescribeStreamOutput, error) {
+	return do(ctx, c, "DescribeStream", func(ctx context.Context, input *streams.DescribeStreamInput) (*streams.DescribeStreamOutput, error) {
+		return c.StreamsClient.DescribeStream(ctx, input, o...)
+	}, input)
+}
+
+func (c *RetryStreamsClient) GetShardIterator(ctx context.Context, input *streams.GetShardIteratorInput, o ...func(*streams.Options)) (*streams.GetShardIteratorOutput, error) {
+	return do(ctx, c, "GetShardIterator", func(ctx context.Context, input *streams.GetShardIteratorInput) (*streams.GetShardIteratorOutput, error) {
+		return c.StreamsClient.GetShardIterator(ctx, input, o...)
+	}, input)
+}
+
+func (c *RetryStreamsClient) GetRecords(ctx context.Context, input *streams.GetRecordsInput, o ...func(*streams.Options)) (*streams.GetRecordsOutput, error) {
+	return do(ctx, c, "GetRecords", func(ctx context.Context, input *streams.GetRecordsInput) (*streams.GetRecordsOutput, error) {
+		return c.StreamsClient.GetRecords(ctx, input, o...)
+	}, input)
+}
+
+func (c *RetryStreamsClient) ListStreams(ctx context.Context, input *streams.ListStreamsInput, o ...func(*streams.Options)) (*streams.ListStreamsOutput, error) {
+	return do(ctx, c, "ListStreams", func(ctx context.Context, input *streams.ListStreamsInput) (*streams.ListStreamsOutput, error) {
+		return c.StreamsClient.ListStreams(ctx, input, o...)
+	}, input)
+}
+
+// do retries fn under c's Retrier, if it has one, or its Policy.
+func do[TIn any, TOut any](ctx context.Context, c *RetryStreamsClient, op string, fn func(context.Context, TIn) (TOut, error), input TIn) (TOut, error) {
+	if c.Retrier != nil {
+		return ddbretry.Do(ctx, c.Retrier, op, fn, input)
+	}
+
+	return ddbretry.DoWithPolicy(ctx, c.Policy, fn, input)
+}
@@ -0,0 +1,296 @@
+package ddbstreams
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	streams "github.com/aws/aws-sdk-go-v2/service/dynamodbstreams"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodbstreams/types"
+)
+
+const (
+	DefaultPollInterval      = time.Second
+	DefaultDiscoveryInterval = 10 * time.Second
+)
+
+// Handler processes records read from a shard, in the order they were
+// written. Returning an error stops the Consumer without checkpointing the
+// records, so they are handed over again when it restarts.
+type Handler func(ctx context.Context, shardID string, records []types.Record) error
+
+// Consumer reads every shard of a stream, handing the records it reads to
+// Handler and checkpointing the last of them in Checkpoints once Handler
+// returns. Shards are read concurrently, each from its checkpoint or from
+// the oldest record kept if it has none, and a shard is only read once its
+// parent has been read to its end, so the changes to an item are handled in
+// order.
+//
+// Client is usually a RetryStreamsClient, so throttled and failing calls are
+// retried before the Consumer gives up. A shard whose iterator expires is
+// read again from its checkpoint, and one whose records were trimmed before
+// they could be read from the oldest record kept.
+//
+// Shards without records are polled every PollInterval, and new shards are
+// looked for every DiscoveryInterval.
+type Consumer struct {
+	Client            StreamsClient
+	StreamARN         string
+	Checkpoints       CheckpointStore
+	Handler           Handler
+	Limit             int32
+	PollInterval      time.Duration
+	DiscoveryInterval time.Duration
+
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+func NewConsumer(client StreamsClient, streamARN string, checkpoints CheckpointStore, handler Handler) *Consumer {
+	return &Consumer{
+		Client:            client,
+		StreamARN:         streamARN,
+		Checkpoints:       checkpoints,
+		Handler:           handler,
+		PollInterval:      DefaultPollInterval,
+		DiscoveryInterval: DefaultDiscoveryInterval,
+	}
+}
+
+// Run consumes the stream until ctx is done or a shard fails, returning the
+// error that stopped it. Run returns no error once every shard of a disabled
+// stream has been read to its end.
+func (c *Consumer) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	finished := make(chan string)
+	failed := make(chan error, 1)
+	running := map[string]bool{}
+	done := map[string]bool{}
+
+	discover := time.NewTicker(c.discoveryInterval())
+	defer discover.Stop()
+
+	for {
+		shards, enabled, err := c.shards(ctx)
+		if err != nil {
+			return err
+		}
+		for _, shard := range readable(shards, running, done) {
+			running[shard] = true
+			wg.Add(1)
+			go func(shard string) {
+				defer wg.Done()
+				if err := c.consumeShard(ctx, shard); err != nil {
+					select {
+					case failed <- err:
+					default:
+					}
+					cancel()
+					return
+				}
+				select {
+				case finished <- shard:
+				case <-ctx.Done():
+				}
+			}(shard)
+		}
+		if !enabled && len(running) == 0 {
+			return nil
+		}
+
+		select {
+		case shard := <-finished:
+			delete(running, shard)
+			done[shard] = true
+		case <-discover.C:
+		case err := <-failed:
+			return err
+		case <-ctx.Done():
+			select {
+			case err := <-failed:
+				return err
+			default:
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+// readable returns the shards that are neither running nor done and whose
+// parent, if it is still in the stream, is done.
+func readable(shards []types.Shard, running map[string]bool, done map[string]bool) []string {
+	inStream := make(map[string]bool, len(shards))
+	for _, shard := range shards {
+		inStream[aws.ToString(shard.ShardId)] = true
+	}
+
+	var ready []string
+	for _, shard := range shards {
+		id, parent := aws.ToString(shard.ShardId), aws.ToString(shard.ParentShardId)
+		if running[id] || done[id] {
+			continue
+		}
+		if parent != "" && inStream[parent] && !done[parent] {
+			continue
+		}
+		ready = append(ready, id)
+	}
+
+	return ready
+}
+
+// shards lists the shards of the stream and reports whether it is still
+// enabled, and so may gain new shards.
+func (c *Consumer) shards(ctx context.Context) ([]types.Shard, bool, error) {
+	var shards []types.Shard
+	input := &streams.DescribeStreamInput{StreamArn: aws.String(c.StreamARN)}
+	for {
+		output, err := c.Client.DescribeStream(ctx, input)
+		if err != nil {
+			return nil, false, err
+		}
+		description := output.StreamDescription
+		if description == nil {
+			return shards, false, nil
+		}
+		shards = append(shards, description.Shards...)
+		if description.LastEvaluatedShardId == nil {
+			enabled := description.StreamStatus != types.StreamStatusDisabled && description.StreamStatus != types.StreamStatusDisabling
+			return shards, enabled, nil
+		}
+		input.ExclusiveStartShardId = description.LastEvaluatedShardId
+	}
+}
+
+// consumeShard reads shard from its checkpoint to its end.
+func (c *Consumer) consumeShard(ctx context.Context, shard string) error {
+	checkpoint, err := c.Checkpoints.GetCheckpoint(ctx, c.StreamARN, shard)
+	if err != nil || checkpoint == ShardEnd {
+		return err
+	}
+
+	iterator, err := c.iterator(ctx, shard, checkpoint)
+	if err != nil {
+		return err
+	}
+
+	for {
+		input := &streams.GetRecordsInput{ShardIterator: iterator}
+		if c.Limit > 0 {
+			input.Limit = aws.Int32(c.Limit)
+		}
+		output, err := c.Client.GetRecords(ctx, input)
+		switch {
+		case isExpiredIterator(err):
+			iterator, err = c.iterator(ctx, shard, checkpoint)
+			if err != nil {
+				return err
+			}
+			continue
+		case isTrimmedDataAccess(err):
+			checkpoint = ""
+			iterator, err = c.iterator(ctx, shard, checkpoint)
+			if err != nil {
+				return err
+			}
+			continue
+		case err != nil:
+			return err
+		}
+
+		if records := output.Records; len(records) > 0 {
+			if err := c.Handler(ctx, shard, records); err != nil {
+				return err
+			}
+			if last := records[len(records)-1].Dynamodb; last != nil {
+				checkpoint = aws.ToString(last.SequenceNumber)
+			}
+			if err := c.Checkpoints.SetCheckpoint(ctx, c.StreamARN, shard, checkpoint); err != nil {
+				return err
+			}
+		}
+
+		iterator = output.NextShardIterator
+		if iterator == nil {
+			return c.Checkpoints.SetCheckpoint(ctx, c.StreamARN, shard, ShardEnd)
+		}
+		if len(output.Records) == 0 {
+			if err := c.wait(ctx, c.pollInterval()); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// iterator returns an iterator reading shard after checkpoint, or from the
+// oldest record kept if checkpoint is empty.
+func (c *Consumer) iterator(ctx context.Context, shard string, checkpoint string) (*string, error) {
+	input := &streams.GetShardIteratorInput{
+		StreamArn:         aws.String(c.StreamARN),
+		ShardId:           aws.String(shard),
+		ShardIteratorType: types.ShardIteratorTypeTrimHorizon,
+	}
+	if checkpoint != "" {
+		input.ShardIteratorType = types.ShardIteratorTypeAfterSequenceNumber
+		input.SequenceNumber = aws.String(checkpoint)
+	}
+
+	output, err := c.Client.GetShardIterator(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return output.ShardIterator, nil
+}
+
+func (c *Consumer) wait(ctx context.Context, d time.Duration) error {
+	if c.sleep != nil {
+		return c.sleep(ctx, d)
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Consumer) pollInterval() time.Duration {
+	if c.PollInterval > 0 {
+		return c.PollInterval
+	}
+
+	return DefaultPollInterval
+}
+
+func (c *Consumer) discoveryInterval() time.Duration {
+	if c.DiscoveryInterval > 0 {
+		return c.DiscoveryInterval
+	}
+
+	return DefaultDiscoveryInterval
+}
+
+func isExpiredIterator(err error) bool {
+	var expiredIteratorException *types.ExpiredIteratorException
+	ok := errors.As(err, &expiredIteratorException)
+
+	return ok
+}
+
+func isTrimmedDataAccess(err error) bool {
+	var trimmedDataAccessException *types.TrimmedDataAccessException
+	ok := errors.As(err, &trimmedDataAccessException)
+
+	return ok
+}
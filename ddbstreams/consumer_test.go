@@ -0,0 +1,260 @@
+package ddbstreams
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	streams "github.com/aws/aws-sdk-go-v2/service/dynamodbstreams"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodbstreams/types"
+	"github.com/stretchr/testify/assert"
+)
+
+const testStreamARN = "arn:aws:dynamodb:us-east-1:123456789012:table/users/stream/2024-01-01T00:00:00.000"
+
+// fakeStreamsClient serves the records of closed shards two at a time, with
+// iterators of the form "shard/index". Iterators in expire fail once with an
+// ExpiredIteratorException, and the first throttles GetRecords calls fail
+// with a LimitExceededException.
+type fakeStreamsClient struct {
+	mu        sync.Mutex
+	shards    []types.Shard
+	records   map[string][]types.Record
+	expire    map[string]bool
+	throttles int
+	getCalls  int
+}
+
+func newFakeStreamsClient() *fakeStreamsClient {
+	return &fakeStreamsClient{
+		records: map[string][]types.Record{},
+		expire:  map[string]bool{},
+	}
+}
+
+func (f *fakeStreamsClient) addShard(id string, parent string, sequenceNumbers ...int) {
+	shard := types.Shard{ShardId: aws.String(id)}
+	if parent != "" {
+		shard.ParentShardId = aws.String(parent)
+	}
+	f.shards = append(f.shards, shard)
+	for _, n := range sequenceNumbers {
+		f.records[id] = append(f.records[id], types.Record{
+			Dynamodb: &types.StreamRecord{SequenceNumber: aws.String(strconv.Itoa(n))},
+		})
+	}
+}
+
+func (f *fakeStreamsClient) DescribeStream(_ context.Context, input *streams.DescribeStreamInput, _ ...func(*streams.Options)) (*streams.DescribeStreamOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Serve one shard per page to exercise pagination.
+	start := 0
+	if id := aws.ToString(input.ExclusiveStartShardId); id != "" {
+		for i, shard := range f.shards {
+			if aws.ToString(shard.ShardId) == id {
+				start = i + 1
+			}
+		}
+	}
+	description := &types.StreamDescription{
+		StreamArn:    input.StreamArn,
+		StreamStatus: types.StreamStatusDisabled,
+		Shards:       f.shards[start : start+1],
+	}
+	if start+1 < len(f.shards) {
+		description.LastEvaluatedShardId = f.shards[start].ShardId
+	}
+
+	return &streams.DescribeStreamOutput{StreamDescription: description}, nil
+}
+
+func (f *fakeStreamsClient) GetShardIterator(_ context.Context, input *streams.GetShardIteratorInput, _ ...func(*streams.Options)) (*streams.GetShardIteratorOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	shard := aws.ToString(input.ShardId)
+	index := 0
+	if input.ShardIteratorType == types.ShardIteratorTypeAfterSequenceNumber {
+		for i, record := range f.records[shard] {
+			if aws.ToString(record.Dynamodb.SequenceNumber) == aws.ToString(input.SequenceNumber) {
+				index = i + 1
+			}
+		}
+	}
+
+	return &streams.GetShardIteratorOutput{ShardIterator: aws.String(fmt.Sprintf("%s/%d", shard, index))}, nil
+}
+
+func (f *fakeStreamsClient) GetRecords(_ context.Context, input *streams.GetRecordsInput, _ ...func(*streams.Options)) (*streams.GetRecordsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.getCalls++
+	if f.getCalls <= f.throttles {
+		return nil, &types.LimitExceededException{Message: aws.String("throttled")}
+	}
+
+	iterator := aws.ToString(input.ShardIterator)
+	if f.expire[iterator] {
+		delete(f.expire, iterator)
+		return nil, &types.ExpiredIteratorException{Message: aws.String("expired")}
+	}
+
+	shard, position, _ := strings.Cut(iterator, "/")
+	index, _ := strconv.Atoi(position)
+	records := f.records[shard]
+	end := index + 2
+	if end > len(records) {
+		end = len(records)
+	}
+
+	output := &streams.GetRecordsOutput{Records: records[index:end]}
+	if end < len(records) {
+		output.NextShardIterator = aws.String(fmt.Sprintf("%s/%d", shard, end))
+	}
+
+	return output, nil
+}
+
+func (f *fakeStreamsClient) ListStreams(context.Context, *streams.ListStreamsInput, ...func(*streams.Options)) (*streams.ListStreamsOutput, error) {
+	return &streams.ListStreamsOutput{}, nil
+}
+
+// recorder is a Handler collecting the sequence numbers it is handed.
+type recorder struct {
+	mu       sync.Mutex
+	sequence []string
+	err      error
+}
+
+func (r *recorder) handle(_ context.Context, _ string, records []types.Record) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return r.err
+	}
+	for _, record := range records {
+		r.sequence = append(r.sequence, aws.ToString(record.Dynamodb.SequenceNumber))
+	}
+
+	return nil
+}
+
+func TestConsumer_Run(t *testing.T) {
+	client := newFakeStreamsClient()
+	client.addShard("child", "parent", 4, 5)
+	client.addShard("parent", "", 1, 2, 3)
+	client.expire["parent/2"] = true
+
+	checkpoints := NewMemoryCheckpointStore()
+	r := &recorder{}
+	c := NewConsumer(client, testStreamARN, checkpoints, r.handle)
+
+	assert.NoError(t, c.Run(context.Background()))
+	assert.Equal(t, []string{"1", "2", "3", "4", "5"}, r.sequence)
+	for _, shard := range []string{"parent", "child"} {
+		checkpoint, err := checkpoints.GetCheckpoint(context.Background(), testStreamARN, shard)
+		assert.NoError(t, err)
+		assert.Equal(t, ShardEnd, checkpoint)
+	}
+
+	r.sequence = nil
+	assert.NoError(t, c.Run(context.Background()))
+	assert.Empty(t, r.sequence)
+}
+
+func TestConsumer_Run_checkpoint(t *testing.T) {
+	client := newFakeStreamsClient()
+	client.addShard("shard", "", 1, 2, 3)
+
+	checkpoints := NewMemoryCheckpointStore()
+	assert.NoError(t, checkpoints.SetCheckpoint(context.Background(), testStreamARN, "shard", "1"))
+	r := &recorder{}
+
+	assert.NoError(t, NewConsumer(client, testStreamARN, checkpoints, r.handle).Run(context.Background()))
+	assert.Equal(t, []string{"2", "3"}, r.sequence)
+}
+
+func TestConsumer_Run_handlerError(t *testing.T) {
+	client := newFakeStreamsClient()
+	client.addShard("shard", "", 1, 2, 3)
+
+	handlerErr := errors.New("handler failed")
+	checkpoints := NewMemoryCheckpointStore()
+	r := &recorder{err: handlerErr}
+
+	err := NewConsumer(client, testStreamARN, checkpoints, r.handle).Run(context.Background())
+	assert.ErrorIs(t, err, handlerErr)
+	checkpoint, _ := checkpoints.GetCheckpoint(context.Background(), testStreamARN, "shard")
+	assert.Empty(t, checkpoint)
+}
+
+func TestConsumer_Run_contextDone(t *testing.T) {
+	client := newFakeStreamsClient()
+	client.addShard("shard", "")
+
+	// An open shard without records is polled until the context ends.
+	open := &openShardClient{fakeStreamsClient: client}
+	c := NewConsumer(open, testStreamARN, NewMemoryCheckpointStore(), (&recorder{}).handle)
+	c.PollInterval = time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, c.Run(ctx), context.DeadlineExceeded)
+}
+
+// openShardClient returns a next iterator from every GetRecords call, as
+// DynamoDB Streams does for shards that are still open.
+type openShardClient struct {
+	*fakeStreamsClient
+}
+
+func (c *openShardClient) GetRecords(ctx context.Context, input *streams.GetRecordsInput, o ...func(*streams.Options)) (*streams.GetRecordsOutput, error) {
+	return &streams.GetRecordsOutput{NextShardIterator: input.ShardIterator}, nil
+}
+
+func TestRetryStreamsClient(t *testing.T) {
+	client := newFakeStreamsClient()
+	client.addShard("shard", "", 1, 2)
+	client.throttles = 2
+
+	policy := ddbretry.RetryPolicy{Retries: 3, BackOffTime: time.Millisecond}
+	r := &recorder{}
+	c := NewConsumer(NewRetryStreamsClient(client, policy), testStreamARN, NewMemoryCheckpointStore(), r.handle)
+
+	assert.NoError(t, c.Run(context.Background()))
+	assert.Equal(t, []string{"1", "2"}, r.sequence)
+	assert.Equal(t, 3, client.getCalls)
+}
+
+func TestRetryStreamsClient_Retrier(t *testing.T) {
+	client := newFakeStreamsClient()
+	client.addShard("shard", "", 1, 2)
+	client.throttles = 2
+
+	var retried []string
+	streamsClient := NewRetryStreamsClient(client, ddbretry.RetryPolicy{})
+	streamsClient.Retrier = &ddbretry.RetryDynamoDBClient{
+		Retries:     3,
+		BackOffTime: time.Millisecond,
+		OnRetry: func(op string, table string, attempt int, err error, delay time.Duration) {
+			retried = append(retried, op)
+		},
+	}
+	r := &recorder{}
+	c := NewConsumer(streamsClient, testStreamARN, NewMemoryCheckpointStore(), r.handle)
+
+	assert.NoError(t, c.Run(context.Background()))
+	assert.Equal(t, []string{"1", "2"}, r.sequence)
+	assert.Equal(t, []string{"GetRecords", "GetRecords"}, retried)
+}
@@ -0,0 +1,68 @@
+package ddbretry
+
+import (
+	"sync"
+)
+
+// BackOffDecay carries backoff progress from one call to the next, so that
+// calls made during a throttling episode start backing off where earlier
+// calls left off instead of from the first attempt. Once requests succeed
+// again the carried attempt level is halved after every Successes consecutive
+// successful attempts, letting the client return to normal latency shortly
+// after the episode ends.
+//
+// It is mostly useful with infinite retries, where long-running calls would
+// otherwise each climb the backoff curve independently.
+type BackOffDecay struct {
+	mu        sync.Mutex
+	level     int
+	streak    int
+	successes int
+}
+
+func NewBackOffDecay(successes int) *BackOffDecay {
+	if successes < 1 {
+		successes = 1
+	}
+
+	return &BackOffDecay{
+		successes: successes,
+	}
+}
+
+// Level returns the attempt level new calls start backing off from.
+func (d *BackOffDecay) Level() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.level
+}
+
+// raise records that a call has backed off at the given attempt level.
+func (d *BackOffDecay) raise(level int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if level > d.level {
+		d.level = level
+	}
+	d.streak = 0
+}
+
+// record updates the success streak with the outcome of an attempt, decaying
+// the level once the streak is long enough.
+func (d *BackOffDecay) record(success bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !success {
+		d.streak = 0
+		return
+	}
+
+	d.streak++
+	if d.streak >= d.successes {
+		d.level /= 2
+		d.streak = 0
+	}
+}
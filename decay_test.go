@@ -0,0 +1,52 @@
+package ddbretry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Thumbscrew/ddbretry/ddbretrytest"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackOffDecay(t *testing.T) {
+	d := NewBackOffDecay(2)
+
+	d.raise(8)
+	d.raise(3)
+	assert.Equal(t, 8, d.Level())
+
+	d.record(true)
+	assert.Equal(t, 8, d.Level())
+	d.record(false)
+	d.record(true)
+	assert.Equal(t, 8, d.Level())
+	d.record(true)
+	assert.Equal(t, 4, d.Level())
+
+	for i := 0; i < 10; i++ {
+		d.record(true)
+	}
+	assert.Equal(t, 0, d.Level())
+}
+
+func TestRetryDynamoDBClient_BackOffDecay(t *testing.T) {
+	backOff := &recordingBackOff{}
+	decay := NewBackOffDecay(1)
+	c := &RetryDynamoDBClient{
+		DynamoDBClient: ddbretrytest.NewFakeClient().Script("GetItem", ddbretrytest.Throttles(3)...),
+		Retries:        -1,
+		BackOff:        backOff,
+		BackOffDecay:   decay,
+	}
+
+	_, err := c.GetItem(context.Background(), &ddb.GetItemInput{})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, backOff.attempts)
+	assert.Equal(t, 1, decay.Level())
+
+	c.DynamoDBClient = ddbretrytest.NewFakeClient().Script("GetItem", ddbretrytest.Throttled())
+	_, err = c.GetItem(context.Background(), &ddb.GetItemInput{})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3, 2}, backOff.attempts)
+}
@@ -0,0 +1,88 @@
+package ddbretry
+
+import (
+	"context"
+	"sync/atomic"
+
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+var defaultClient atomic.Pointer[RetryDynamoDBClient]
+
+// SetDefault sets the client used by the package-level operation functions,
+// such as GetItem, for programs that would rather not pass a client around.
+// Passing nil clears it.
+func SetDefault(c *RetryDynamoDBClient) {
+	defaultClient.Store(c)
+}
+
+// Default returns the client set by SetDefault, or nil if there is none.
+func Default() *RetryDynamoDBClient {
+	return defaultClient.Load()
+}
+
+// GetItem calls GetItem on the default client, returning a NoDefaultClientError
+// if SetDefault has not been called.
+func GetItem(ctx context.Context, input *ddb.GetItemInput, o ...func(*ddb.Options)) (*ddb.GetItemOutput, error) {
+	c := Default()
+	if c == nil {
+		return nil, NewNoDefaultClientError()
+	}
+
+	return c.GetItem(ctx, input, o...)
+}
+
+// PutItem calls PutItem on the default client, returning a NoDefaultClientError
+// if SetDefault has not been called.
+func PutItem(ctx context.Context, input *ddb.PutItemInput, o ...func(*ddb.Options)) (*ddb.PutItemOutput, error) {
+	c := Default()
+	if c == nil {
+		return nil, NewNoDefaultClientError()
+	}
+
+	return c.PutItem(ctx, input, o...)
+}
+
+// DeleteItem calls DeleteItem on the default client, returning a NoDefaultClientError
+// if SetDefault has not been called.
+func DeleteItem(ctx context.Context, input *ddb.DeleteItemInput, o ...func(*ddb.Options)) (*ddb.DeleteItemOutput, error) {
+	c := Default()
+	if c == nil {
+		return nil, NewNoDefaultClientError()
+	}
+
+	return c.DeleteItem(ctx, input, o...)
+}
+
+// UpdateItem calls UpdateItem on the default client, returning a NoDefaultClientError
+// if SetDefault has not been called.
+func UpdateItem(ctx context.Context, input *ddb.UpdateItemInput, o ...func(*ddb.Options)) (*ddb.UpdateItemOutput, error) {
+	c := Default()
+	if c == nil {
+		return nil, NewNoDefaultClientError()
+	}
+
+	return c.UpdateItem(ctx, input, o...)
+}
+
+// Query calls Query on the default client, returning a NoDefaultClientError
+// if SetDefault has not been called.
+func Query(ctx context.Context, input *ddb.QueryInput, o ...func(*ddb.Options)) (*ddb.QueryOutput, error) {
+	c := Default()
+	if c == nil {
+		return nil, NewNoDefaultClientError()
+	}
+
+	return c.Query(ctx, input, o...)
+}
+
+// Scan calls Scan on the default client, returning a NoDefaultClientError
+// if SetDefault has not been called.
+func Scan(ctx context.Context, input *ddb.ScanInput, o ...func(*ddb.Options)) (*ddb.ScanOutput, error) {
+	c := Default()
+	if c == nil {
+		return nil, NewNoDefaultClientError()
+	}
+
+	return c.Scan(ctx, input, o...)
+}
@@ -0,0 +1,41 @@
+package ddbretry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Thumbscrew/ddbretry/ddbretrytest"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefault(t *testing.T) {
+	t.Cleanup(func() {
+		SetDefault(nil)
+	})
+	ctx := context.Background()
+
+	assert.Nil(t, Default())
+	_, err := GetItem(ctx, &ddb.GetItemInput{})
+	assert.True(t, IsNoDefaultClientError(err))
+
+	c := &RetryDynamoDBClient{
+		DynamoDBClient: ddbretrytest.NewFakeClient().Script("GetItem", ddbretrytest.Throttled()),
+	}
+	SetDefault(c)
+	assert.Same(t, c, Default())
+
+	_, err = GetItem(ctx, &ddb.GetItemInput{})
+	assert.NoError(t, err)
+	_, err = PutItem(ctx, &ddb.PutItemInput{})
+	assert.NoError(t, err)
+	_, err = DeleteItem(ctx, &ddb.DeleteItemInput{})
+	assert.NoError(t, err)
+	_, err = UpdateItem(ctx, &ddb.UpdateItemInput{})
+	assert.NoError(t, err)
+	_, err = Query(ctx, &ddb.QueryInput{})
+	assert.NoError(t, err)
+	_, err = Scan(ctx, &ddb.ScanInput{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), c.Stats().Attempts)
+}
@@ -0,0 +1,160 @@
+package ddbretry
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// policyJSON is the encoded form of a RetryPolicy, naming the strategy and
+// the types of any custom backoff or classifier rather than encoding them.
+type policyJSON struct {
+	Retries         int            `json:"retries"`
+	BackOffTime     configDuration `json:"backoff"`
+	BackOffStrategy string         `json:"strategy"`
+	BackOff         string         `json:"custom_backoff,omitempty"`
+	MaxBackOff      configDuration `json:"max_backoff,omitempty"`
+	InitialBackOff  configDuration `json:"initial_backoff,omitempty"`
+	ImmediateRetry  bool           `json:"immediate_retry,omitempty"`
+	MaxElapsedTime  configDuration `json:"max_elapsed_time,omitempty"`
+	AttemptTimeout  configDuration `json:"attempt_timeout,omitempty"`
+	Classifier      string         `json:"classifier"`
+}
+
+// MarshalJSON describes p for diagnostics, such as logging the retry
+// settings in effect at startup. It cannot be decoded back into a
+// RetryPolicy.
+func (p RetryPolicy) MarshalJSON() ([]byte, error) {
+	j := policyJSON{
+		Retries:         p.Retries,
+		BackOffTime:     configDuration(p.BackOffTime),
+		BackOffStrategy: p.BackOffStrategy.String(),
+		MaxBackOff:      configDuration(p.MaxBackOff),
+		InitialBackOff:  configDuration(p.InitialBackOff),
+		ImmediateRetry:  p.ImmediateRetry,
+		MaxElapsedTime:  configDuration(p.MaxElapsedTime),
+		AttemptTimeout:  configDuration(p.AttemptTimeout),
+		Classifier:      classifierName(DefaultErrorClassifier{}),
+	}
+	switch {
+	case p.BackOff != nil:
+		j.BackOff = fmt.Sprintf("%T", p.BackOff)
+	case p.BackOffFunc != nil:
+		j.BackOff = "func"
+	}
+	if p.Classifier != nil {
+		j.Classifier = classifierName(p.Classifier)
+	}
+
+	return json.Marshal(j)
+}
+
+// String returns p as encoded by MarshalJSON.
+func (p RetryPolicy) String() string {
+	return jsonString(p)
+}
+
+// String returns cfg as encoded by MarshalJSON.
+func (cfg Config) String() string {
+	return jsonString(cfg)
+}
+
+// clientJSON is the encoded form of a RetryDynamoDBClient's settings.
+type clientJSON struct {
+	Policy                        RetryPolicy            `json:"policy"`
+	ReadPolicy                    *RetryPolicy           `json:"read_policy,omitempty"`
+	WritePolicy                   *RetryPolicy           `json:"write_policy,omitempty"`
+	OperationPolicies             map[string]RetryPolicy `json:"operation_policies,omitempty"`
+	TablePolicies                 map[string]RetryPolicy `json:"table_policies,omitempty"`
+	HonorRetryAfter               bool                   `json:"honor_retry_after,omitempty"`
+	HedgeDelay                    configDuration         `json:"hedge_delay,omitempty"`
+	InternalServerErrorRetries    int                    `json:"internal_server_error_retries,omitempty"`
+	NetworkErrorRetries           int                    `json:"network_error_retries,omitempty"`
+	DowngradeConsistentReadsAfter int                    `json:"downgrade_consistent_reads_after,omitempty"`
+	RequireIdempotentWrites       bool                   `json:"require_idempotent_writes,omitempty"`
+	ValidateItemSize              bool                   `json:"validate_item_size,omitempty"`
+	Shadow                        bool                   `json:"shadow,omitempty"`
+	RetryBudget                   bool                   `json:"retry_budget,omitempty"`
+	CapacityBudget                bool                   `json:"capacity_budget,omitempty"`
+	RateLimiter                   bool                   `json:"rate_limiter,omitempty"`
+	ConcurrencyLimiter            bool                   `json:"concurrency_limiter,omitempty"`
+	Bulkheads                     bool                   `json:"bulkheads,omitempty"`
+	CircuitBreaker                bool                   `json:"circuit_breaker,omitempty"`
+	Coalescer                     bool                   `json:"coalescer,omitempty"`
+}
+
+// MarshalJSON describes the retry settings in effect for c, for diagnostics:
+// its own policy and any read, write, operation and table policies, each
+// with the classifier it uses, along with which limits are enabled.
+func (c *RetryDynamoDBClient) MarshalJSON() ([]byte, error) {
+	effective := func(p RetryPolicy) RetryPolicy {
+		if p.Classifier == nil {
+			p.Classifier = c.classifier()
+		}
+		return p
+	}
+	effectiveMap := func(m map[string]RetryPolicy) map[string]RetryPolicy {
+		if len(m) == 0 {
+			return nil
+		}
+		out := make(map[string]RetryPolicy, len(m))
+		for k, p := range m {
+			out[k] = effective(p)
+		}
+		return out
+	}
+
+	j := clientJSON{
+		Policy:                        effective(c.policy()),
+		OperationPolicies:             effectiveMap(c.OperationPolicies),
+		TablePolicies:                 effectiveMap(c.TablePolicies),
+		HonorRetryAfter:               c.HonorRetryAfter,
+		HedgeDelay:                    configDuration(c.HedgeDelay),
+		InternalServerErrorRetries:    c.InternalServerErrorRetries,
+		NetworkErrorRetries:           c.NetworkErrorRetries,
+		DowngradeConsistentReadsAfter: c.DowngradeConsistentReadsAfter,
+		RequireIdempotentWrites:       c.RequireIdempotentWrites,
+		ValidateItemSize:              c.ValidateItemSize,
+		Shadow:                        c.Shadow,
+		RetryBudget:                   c.RetryBudget != nil,
+		CapacityBudget:                c.CapacityBudget != nil,
+		RateLimiter:                   c.RateLimiter != nil,
+		ConcurrencyLimiter:            c.ConcurrencyLimiter != nil,
+		Bulkheads:                     c.Bulkheads != nil,
+		CircuitBreaker:                c.CircuitBreaker != nil,
+		Coalescer:                     c.Coalescer != nil,
+	}
+	if c.ReadPolicy != nil {
+		p := effective(*c.ReadPolicy)
+		j.ReadPolicy = &p
+	}
+	if c.WritePolicy != nil {
+		p := effective(*c.WritePolicy)
+		j.WritePolicy = &p
+	}
+
+	return json.Marshal(j)
+}
+
+// String returns c's settings as encoded by MarshalJSON.
+func (c *RetryDynamoDBClient) String() string {
+	return jsonString(c)
+}
+
+// classifierName names the type of c, along with the errors it opts in to
+// retrying if it is a DefaultErrorClassifier.
+func classifierName(c ErrorClassifier) string {
+	if d, ok := c.(DefaultErrorClassifier); ok {
+		return fmt.Sprintf("%T%+v", d, d)
+	}
+
+	return fmt.Sprintf("%T", c)
+}
+
+func jsonString(v json.Marshaler) string {
+	data, err := v.MarshalJSON()
+	if err != nil {
+		return fmt.Sprintf("!(%v)", err)
+	}
+
+	return string(data)
+}
@@ -0,0 +1,69 @@
+package ddbretry
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry/ddbretrytest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicy_String(t *testing.T) {
+	p := RetryPolicy{
+		Retries:         5,
+		BackOffTime:     100 * time.Millisecond,
+		BackOffStrategy: FullJitterBackOff,
+		MaxBackOff:      2 * time.Second,
+	}
+	assert.JSONEq(t, `{
+		"retries": 5,
+		"backoff": "100ms",
+		"strategy": "full-jitter",
+		"max_backoff": "2s",
+		"classifier": "ddbretry.DefaultErrorClassifier{RetryInternalServerErrors:false RetryNetworkErrors:false RetryTransactionConflicts:false}"
+	}`, p.String())
+
+	p = RetryPolicy{
+		BackOff: &linearBackOff{step: time.Millisecond},
+		Classifier: ErrorClassifierFunc(func(err error) RetryDecision {
+			return DoNotRetry
+		}),
+	}
+	assert.JSONEq(t, `{
+		"retries": 0,
+		"backoff": "0s",
+		"strategy": "constant",
+		"custom_backoff": "*ddbretry.linearBackOff",
+		"classifier": "ddbretry.ErrorClassifierFunc"
+	}`, p.String())
+}
+
+func TestConfig_String(t *testing.T) {
+	cfg := Config{Retries: 2, BackOffTime: time.Second}
+
+	assert.JSONEq(t, `{"retries": 2, "backoff": "1s", "strategy": "constant"}`, cfg.String())
+}
+
+func TestRetryDynamoDBClient_MarshalJSON(t *testing.T) {
+	c, err := NewRetryDynamoDBClient(ddbretrytest.NewFakeClient(), 3, time.Second)
+	assert.NoError(t, err)
+	c.WritePolicy = &RetryPolicy{Retries: 1}
+	c.TablePolicies = map[string]RetryPolicy{
+		"users": {Retries: 10, BackOffStrategy: ExponentialBackOff},
+	}
+	c.RetryTransactionConflicts = true
+	c.CircuitBreaker = NewCircuitBreaker(5, time.Second, 1)
+
+	data, err := json.Marshal(c)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"policy": {"retries": 3, "backoff": "1s", "strategy": "constant", "classifier": "ddbretry.DefaultErrorClassifier{RetryInternalServerErrors:false RetryNetworkErrors:false RetryTransactionConflicts:true}"},
+		"write_policy": {"retries": 1, "backoff": "0s", "strategy": "constant", "classifier": "ddbretry.DefaultErrorClassifier{RetryInternalServerErrors:false RetryNetworkErrors:false RetryTransactionConflicts:true}"},
+		"table_policies": {
+			"users": {"retries": 10, "backoff": "0s", "strategy": "exponential", "classifier": "ddbretry.DefaultErrorClassifier{RetryInternalServerErrors:false RetryNetworkErrors:false RetryTransactionConflicts:true}"}
+		},
+		"circuit_breaker": true
+	}`, string(data))
+	assert.Equal(t, string(data), c.String())
+}
@@ -0,0 +1,40 @@
+package ddbretry
+
+import (
+	"context"
+)
+
+// Do calls fn with input, retrying it under c's settings as though it were
+// the DynamoDB operation named op, so that operations RetryDynamoDBClient has
+// no method for can share its policies, hooks, metrics and limits:
+//
+//	output, err := ddbretry.Do(ctx, client, "Query", func(ctx context.Context, input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+//		return sdkClient.Query(ctx, input)
+//	}, input)
+//
+// Call options such as WithCallRetries cannot be passed to Do, as fn takes no
+// options. DoWithPolicy retries a call under a RetryPolicy without a client.
+func Do[TIn any, TOut any](ctx context.Context, c *RetryDynamoDBClient, op string, fn func(context.Context, TIn) (TOut, error), input TIn) (TOut, error) {
+	return executeWithRetry(ctx, c, op, input, nil, func(ctx context.Context, _ *retryState) (TOut, error) {
+		return fn(ctx, input)
+	})
+}
+
+// DoWithPolicy calls fn with input, retrying it under p alone, for calls made
+// without a RetryDynamoDBClient whose hooks, metrics and limits they should
+// share, such as calls to other AWS services:
+//
+//	output, err := ddbretry.DoWithPolicy(ctx, policy, func(ctx context.Context, input *sqs.SendMessageInput) (*sqs.SendMessageOutput, error) {
+//		return sqsClient.SendMessage(ctx, input)
+//	}, input)
+//
+// fn is not called if p.Retries is negative other than InfiniteRetries, and
+// DoWithPolicy returns an InvalidRetryError instead.
+func DoWithPolicy[TIn any, TOut any](ctx context.Context, p RetryPolicy, fn func(context.Context, TIn) (TOut, error), input TIn) (TOut, error) {
+	c := policyClient(p)
+	c.freeze()
+
+	return executeWithRetry(ctx, c, "", input, nil, func(ctx context.Context, _ *retryState) (TOut, error) {
+		return fn(ctx, input)
+	})
+}
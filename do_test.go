@@ -0,0 +1,103 @@
+package ddbretry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDo(t *testing.T) {
+	tests := []struct {
+		name      string
+		throttles int
+		err       error
+		wantCalls int
+		wantErr   bool
+	}{
+		{
+			name:      "should return output after retrying throttled calls",
+			throttles: 2,
+			wantCalls: 3,
+		},
+		{
+			name:      "should give up once retries are exhausted",
+			throttles: 5,
+			wantCalls: 4,
+			wantErr:   true,
+		},
+		{
+			name:      "should not retry errors that are not retryable",
+			err:       errors.New("validation failed"),
+			wantCalls: 1,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tables []string
+			c := &RetryDynamoDBClient{
+				Retries: 3,
+				OnRetry: func(op string, table string, attempt int, err error, delay time.Duration) {
+					assert.Equal(t, "Query", op)
+					tables = append(tables, table)
+				},
+			}
+
+			calls, throttles := 0, tt.throttles
+			query := func(ctx context.Context, input *ddb.QueryInput) (*ddb.QueryOutput, error) {
+				calls++
+				if throttles > 0 {
+					throttles--
+					return nil, &types.ProvisionedThroughputExceededException{}
+				}
+				if tt.err != nil {
+					return nil, tt.err
+				}
+				return &ddb.QueryOutput{Count: 1}, nil
+			}
+
+			output, err := Do(context.Background(), c, "Query", query, &ddb.QueryInput{TableName: aws.String("users")})
+			assert.Equal(t, tt.wantCalls, calls)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, output)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, int32(1), output.Count)
+			assert.Equal(t, []string{"users", "users"}, tables)
+		})
+	}
+}
+
+func TestDoWithPolicy(t *testing.T) {
+	calls := 0
+	send := func(ctx context.Context, body string) (int, error) {
+		calls++
+		if calls < 3 {
+			return 0, &types.ProvisionedThroughputExceededException{}
+		}
+		return len(body), nil
+	}
+
+	n, err := DoWithPolicy(context.Background(), RetryPolicy{Retries: 2, BackOffTime: time.Millisecond}, send, "hello")
+	assert.NoError(t, err)
+	assert.Equal(t, 5, n)
+	assert.Equal(t, 3, calls)
+
+	calls = 0
+	_, err = DoWithPolicy(context.Background(), RetryPolicy{Retries: 1, BackOffTime: time.Millisecond}, send, "hello")
+	assert.True(t, IsThrottlingError(err))
+	assert.Equal(t, 2, calls)
+
+	calls = 0
+	_, err = DoWithPolicy(context.Background(), RetryPolicy{Retries: -5}, send, "hello")
+	assert.True(t, IsInvalidRetryError(err))
+	assert.Equal(t, 0, calls)
+}
@@ -0,0 +1,62 @@
+package ddbretry
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/smithy-go/middleware"
+)
+
+type consistentReadDowngradedKey struct{}
+
+// ConsistentReadDowngraded reports whether the output holding metadata, the
+// ResultMetadata of a GetItemOutput or QueryOutput, was read with
+// ConsistentRead turned off by DowngradeConsistentReadsAfter, in which case
+// it may not reflect the latest writes.
+func ConsistentReadDowngraded(metadata middleware.Metadata) bool {
+	downgraded, _ := metadata.Get(consistentReadDowngradedKey{}).(bool)
+
+	return downgraded
+}
+
+// downgrade reports whether the next attempt of a strongly consistent read
+// should be sent as an eventually consistent one, having been throttled
+// DowngradeConsistentReadsAfter times.
+func (s *retryState) downgrade(consistentRead *bool) bool {
+	n := s.client.DowngradeConsistentReadsAfter
+	if n <= 0 || !aws.ToBool(consistentRead) || s.throttled < n {
+		return false
+	}
+	s.downgraded = true
+
+	return true
+}
+
+func (s *retryState) getItemInput(input *ddb.GetItemInput) *ddb.GetItemInput {
+	if !s.downgrade(input.ConsistentRead) {
+		return input
+	}
+
+	downgraded := *input
+	downgraded.ConsistentRead = aws.Bool(false)
+
+	return &downgraded
+}
+
+func (s *retryState) queryInput(input *ddb.QueryInput) *ddb.QueryInput {
+	if !s.downgrade(input.ConsistentRead) {
+		return input
+	}
+
+	downgraded := *input
+	downgraded.ConsistentRead = aws.Bool(false)
+
+	return &downgraded
+}
+
+// markDowngraded flags the metadata of a read's output if the read was
+// downgraded.
+func (s *retryState) markDowngraded(metadata *middleware.Metadata) {
+	if s.downgraded {
+		metadata.Set(consistentReadDowngradedKey{}, true)
+	}
+}
@@ -0,0 +1,85 @@
+package ddbretry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry/ddbretrytest"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryDynamoDBClient_DowngradeConsistentReadsAfter(t *testing.T) {
+	tests := []struct {
+		name           string
+		after          int
+		throttles      int
+		consistentRead bool
+		wantConsistent []bool
+		wantDowngraded bool
+	}{
+		{
+			name:           "should downgrade after the given number of throttles",
+			after:          2,
+			throttles:      3,
+			consistentRead: true,
+			wantConsistent: []bool{true, true, false, false},
+			wantDowngraded: true,
+		},
+		{
+			name:           "should not downgrade reads throttled fewer times",
+			after:          2,
+			throttles:      1,
+			consistentRead: true,
+			wantConsistent: []bool{true, true},
+			wantDowngraded: false,
+		},
+		{
+			name:           "should not downgrade when unset",
+			throttles:      3,
+			consistentRead: true,
+			wantConsistent: []bool{true, true, true, true},
+			wantDowngraded: false,
+		},
+		{
+			name:           "should not flag eventually consistent reads",
+			after:          1,
+			throttles:      2,
+			wantConsistent: []bool{false, false, false},
+			wantDowngraded: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := ddbretrytest.NewFakeClient().
+				Script("GetItem", ddbretrytest.Throttles(tt.throttles)...).
+				Script("Query", ddbretrytest.Throttles(tt.throttles)...)
+			c := &RetryDynamoDBClient{
+				DynamoDBClient:                fake,
+				Retries:                       3,
+				BackOffTime:                   time.Millisecond,
+				DowngradeConsistentReadsAfter: tt.after,
+			}
+			ctx := context.Background()
+
+			getItem, err := c.GetItem(ctx, &ddb.GetItemInput{ConsistentRead: aws.Bool(tt.consistentRead)})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantDowngraded, ConsistentReadDowngraded(getItem.ResultMetadata))
+			query, err := c.Query(ctx, &ddb.QueryInput{ConsistentRead: aws.Bool(tt.consistentRead)})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantDowngraded, ConsistentReadDowngraded(query.ResultMetadata))
+
+			var getItemConsistent, queryConsistent []bool
+			for _, input := range fake.Inputs("GetItem") {
+				getItemConsistent = append(getItemConsistent, aws.ToBool(input.(*ddb.GetItemInput).ConsistentRead))
+			}
+			for _, input := range fake.Inputs("Query") {
+				queryConsistent = append(queryConsistent, aws.ToBool(input.(*ddb.QueryInput).ConsistentRead))
+			}
+			assert.Equal(t, tt.wantConsistent, getItemConsistent)
+			assert.Equal(t, tt.wantConsistent, queryConsistent)
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package ddbretry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"strings"
+
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// logThrottledRequest logs the shape of a throttled request at debug level
+// when DebugDump is set, with every attribute value replaced by a hash.
+func (s *retryState) logThrottledRequest() {
+	l := s.client.Logger
+	if l == nil || !s.client.DebugDump || !s.sampleLog() {
+		return
+	}
+
+	args := []any{
+		"operation", s.op,
+		"table", s.table,
+		"attempt", s.sent,
+	}
+	l.Debug("throttled DynamoDB request", append(args, dumpInput(s.input)...)...)
+}
+
+// dumpInput returns the key and expressions of an operation input as log
+// arguments, with attribute values hashed. Queries and scans are logged with
+// the index they read and the key they start from, if any.
+func dumpInput(input interface{}) []any {
+	var (
+		keyName      = "key"
+		key          map[string]types.AttributeValue
+		index        *string
+		keyCondition *string
+		update       *string
+		condition    *string
+		filter       *string
+		names        map[string]string
+		values       map[string]types.AttributeValue
+		projection   *string
+		startKey     map[string]types.AttributeValue
+	)
+	switch input := input.(type) {
+	case *ddb.GetItemInput:
+		key, projection, names = input.Key, input.ProjectionExpression, input.ExpressionAttributeNames
+	case *ddb.DeleteItemInput:
+		key, condition, names, values = input.Key, input.ConditionExpression, input.ExpressionAttributeNames, input.ExpressionAttributeValues
+	case *ddb.PutItemInput:
+		keyName = "item"
+		key, condition, names, values = input.Item, input.ConditionExpression, input.ExpressionAttributeNames, input.ExpressionAttributeValues
+	case *ddb.UpdateItemInput:
+		key, update, condition = input.Key, input.UpdateExpression, input.ConditionExpression
+		names, values = input.ExpressionAttributeNames, input.ExpressionAttributeValues
+	case *ddb.QueryInput:
+		index, keyCondition, filter, projection = input.IndexName, input.KeyConditionExpression, input.FilterExpression, input.ProjectionExpression
+		names, values, startKey = input.ExpressionAttributeNames, input.ExpressionAttributeValues, input.ExclusiveStartKey
+	case *ddb.ScanInput:
+		index, filter, projection = input.IndexName, input.FilterExpression, input.ProjectionExpression
+		names, values, startKey = input.ExpressionAttributeNames, input.ExpressionAttributeValues, input.ExclusiveStartKey
+	default:
+		return nil
+	}
+
+	var args []any
+	if len(key) > 0 {
+		args = append(args, keyName, redactAttributeValues(key))
+	}
+	if index != nil {
+		args = append(args, "index_name", *index)
+	}
+	if keyCondition != nil {
+		args = append(args, "key_condition_expression", *keyCondition)
+	}
+	if update != nil {
+		args = append(args, "update_expression", *update)
+	}
+	if condition != nil {
+		args = append(args, "condition_expression", *condition)
+	}
+	if filter != nil {
+		args = append(args, "filter_expression", *filter)
+	}
+	if projection != nil {
+		args = append(args, "projection_expression", *projection)
+	}
+	if len(names) > 0 {
+		args = append(args, "expression_attribute_names", names)
+	}
+	if len(values) > 0 {
+		args = append(args, "expression_attribute_values", redactAttributeValues(values))
+	}
+	if len(startKey) > 0 {
+		args = append(args, "exclusive_start_key", redactAttributeValues(startKey))
+	}
+
+	return args
+}
+
+// redactAttributeValues replaces each attribute value with its hash, so that
+// requests for the same item can be recognised without logging its data.
+func redactAttributeValues(item map[string]types.AttributeValue) map[string]string {
+	redacted := make(map[string]string, len(item))
+	for name, v := range item {
+		redacted[name] = hashAttributeValue(v)
+	}
+
+	return redacted
+}
+
+// hashAttributeValue returns a short, stable hash of an attribute value.
+func hashAttributeValue(v types.AttributeValue) string {
+	var b strings.Builder
+	writeAttributeValue(&b, v)
+	sum := sha256.Sum256([]byte(b.String()))
+
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
+
+// writeAttributeValue writes a canonical encoding of v to b.
+func writeAttributeValue(b *strings.Builder, v types.AttributeValue) {
+	switch v := v.(type) {
+	case *types.AttributeValueMemberS:
+		b.WriteString("S")
+		b.WriteString(strconv.Quote(v.Value))
+	case *types.AttributeValueMemberN:
+		b.WriteString("N")
+		b.WriteString(v.Value)
+	case *types.AttributeValueMemberB:
+		b.WriteString("B")
+		b.WriteString(hex.EncodeToString(v.Value))
+	case *types.AttributeValueMemberBOOL:
+		b.WriteString("BOOL")
+		b.WriteString(strconv.FormatBool(v.Value))
+	case *types.AttributeValueMemberNULL:
+		b.WriteString("NULL")
+	case *types.AttributeValueMemberSS:
+		b.WriteString("SS")
+		writeSorted(b, v.Value, strconv.Quote)
+	case *types.AttributeValueMemberNS:
+		b.WriteString("NS")
+		writeSorted(b, v.Value, func(s string) string { return s })
+	case *types.AttributeValueMemberBS:
+		b.WriteString("BS")
+		bs := make([]string, 0, len(v.Value))
+		for _, value := range v.Value {
+			bs = append(bs, hex.EncodeToString(value))
+		}
+		writeSorted(b, bs, func(s string) string { return s })
+	case *types.AttributeValueMemberL:
+		b.WriteString("L[")
+		for _, value := range v.Value {
+			writeAttributeValue(b, value)
+			b.WriteString(",")
+		}
+		b.WriteString("]")
+	case *types.AttributeValueMemberM:
+		names := make([]string, 0, len(v.Value))
+		for name := range v.Value {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		b.WriteString("M{")
+		for _, name := range names {
+			b.WriteString(strconv.Quote(name))
+			b.WriteString(":")
+			writeAttributeValue(b, v.Value[name])
+			b.WriteString(",")
+		}
+		b.WriteString("}")
+	}
+}
+
+func writeSorted(b *strings.Builder, values []string, format func(string) string) {
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+
+	b.WriteString("[")
+	for _, value := range sorted {
+		b.WriteString(format(value))
+		b.WriteString(",")
+	}
+	b.WriteString("]")
+}
@@ -0,0 +1,159 @@
+package ddbretry
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry/ddbretrytest"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashAttributeValue(t *testing.T) {
+	m := func(v map[string]types.AttributeValue) types.AttributeValue {
+		return &types.AttributeValueMemberM{Value: v}
+	}
+
+	a := hashAttributeValue(m(map[string]types.AttributeValue{
+		"id":   &types.AttributeValueMemberS{Value: "1"},
+		"tags": &types.AttributeValueMemberSS{Value: []string{"b", "a"}},
+	}))
+	b := hashAttributeValue(m(map[string]types.AttributeValue{
+		"tags": &types.AttributeValueMemberSS{Value: []string{"a", "b"}},
+		"id":   &types.AttributeValueMemberS{Value: "1"},
+	}))
+	assert.Equal(t, a, b)
+	assert.NotEqual(t, hashAttributeValue(&types.AttributeValueMemberS{Value: "1"}), hashAttributeValue(&types.AttributeValueMemberN{Value: "1"}))
+	assert.Regexp(t, "^sha256:[0-9a-f]{16}$", a)
+}
+
+func TestRetryDynamoDBClient_DebugDump(t *testing.T) {
+	var buf bytes.Buffer
+	c := &RetryDynamoDBClient{
+		DynamoDBClient: ddbretrytest.NewFakeClient().Script("DeleteItem", ddbretrytest.Throttled()),
+		Retries:        1,
+		BackOffTime:    time.Millisecond,
+		Logger:         slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})),
+		DebugDump:      true,
+	}
+
+	_, err := c.DeleteItem(context.Background(), &ddb.DeleteItemInput{
+		TableName: aws.String("users"),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: "secret-id"},
+		},
+		ConditionExpression: aws.String("#s = :s"),
+		ExpressionAttributeNames: map[string]string{
+			"#s": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":s": &types.AttributeValueMemberS{Value: "secret-status"},
+		},
+	})
+	assert.NoError(t, err)
+
+	out := buf.String()
+	assert.Contains(t, out, `msg="throttled DynamoDB request"`)
+	assert.Contains(t, out, `condition_expression="#s = :s"`)
+	assert.Contains(t, out, "status")
+	assert.Contains(t, out, hashAttributeValue(&types.AttributeValueMemberS{Value: "secret-id"}))
+	assert.NotContains(t, out, "secret-id")
+	assert.NotContains(t, out, "secret-status")
+}
+
+func TestDumpInput(t *testing.T) {
+	hash := func(s string) string {
+		return hashAttributeValue(&types.AttributeValueMemberS{Value: s})
+	}
+	tests := []struct {
+		name  string
+		input interface{}
+		want  []any
+	}{
+		{
+			name: "should dump the expressions, index and start key of a query",
+			input: &ddb.QueryInput{
+				TableName:              aws.String("orders"),
+				IndexName:              aws.String("by-customer"),
+				KeyConditionExpression: aws.String("customer = :c"),
+				FilterExpression:       aws.String("#s = :s"),
+				ExpressionAttributeNames: map[string]string{
+					"#s": "status",
+				},
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":c": &types.AttributeValueMemberS{Value: "alice"},
+					":s": &types.AttributeValueMemberS{Value: "open"},
+				},
+				ExclusiveStartKey: map[string]types.AttributeValue{
+					"id": &types.AttributeValueMemberS{Value: "order-1"},
+				},
+			},
+			want: []any{
+				"index_name", "by-customer",
+				"key_condition_expression", "customer = :c",
+				"filter_expression", "#s = :s",
+				"expression_attribute_names", map[string]string{"#s": "status"},
+				"expression_attribute_values", map[string]string{":c": hash("alice"), ":s": hash("open")},
+				"exclusive_start_key", map[string]string{"id": hash("order-1")},
+			},
+		},
+		{
+			name: "should dump the filter, index and start key of a scan",
+			input: &ddb.ScanInput{
+				TableName:        aws.String("orders"),
+				IndexName:        aws.String("by-status"),
+				FilterExpression: aws.String("total > :t"),
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":t": &types.AttributeValueMemberN{Value: "100"},
+				},
+				ExclusiveStartKey: map[string]types.AttributeValue{
+					"id": &types.AttributeValueMemberS{Value: "order-2"},
+				},
+			},
+			want: []any{
+				"index_name", "by-status",
+				"filter_expression", "total > :t",
+				"expression_attribute_values", map[string]string{":t": hashAttributeValue(&types.AttributeValueMemberN{Value: "100"})},
+				"exclusive_start_key", map[string]string{"id": hash("order-2")},
+			},
+		},
+		{
+			name: "should dump the key and expressions of an update",
+			input: &ddb.UpdateItemInput{
+				TableName: aws.String("orders"),
+				Key: map[string]types.AttributeValue{
+					"id": &types.AttributeValueMemberS{Value: "order-3"},
+				},
+				UpdateExpression:    aws.String("SET #s = :s"),
+				ConditionExpression: aws.String("attribute_exists(id)"),
+				ExpressionAttributeNames: map[string]string{
+					"#s": "status",
+				},
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":s": &types.AttributeValueMemberS{Value: "shipped"},
+				},
+			},
+			want: []any{
+				"key", map[string]string{"id": hash("order-3")},
+				"update_expression", "SET #s = :s",
+				"condition_expression", "attribute_exists(id)",
+				"expression_attribute_names", map[string]string{"#s": "status"},
+				"expression_attribute_values", map[string]string{":s": hash("shipped")},
+			},
+		},
+		{
+			name:  "should dump nothing for a first page scan without expressions",
+			input: &ddb.ScanInput{TableName: aws.String("orders")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, dumpInput(tt.input))
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package emfmetrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+)
+
+// maxMetricDataPerRequest is the number of values PutMetricData accepts in a
+// single request.
+const maxMetricDataPerRequest = 1000
+
+type PutMetricDataAPI interface {
+	PutMetricData(context.Context, *cloudwatch.PutMetricDataInput, ...func(*cloudwatch.Options)) (*cloudwatch.PutMetricDataOutput, error)
+}
+
+// CloudWatchEmitter buffers measurements and sends them to CloudWatch with
+// PutMetricData each time Flush is called.
+type CloudWatchEmitter struct {
+	recorder
+
+	mu        sync.Mutex
+	client    PutMetricDataAPI
+	namespace string
+	data      []types.MetricDatum
+	now       func() time.Time
+}
+
+// NewCloudWatch creates a CloudWatchEmitter sending metrics through client
+// under namespace, or DefaultNamespace if namespace is empty.
+func NewCloudWatch(client PutMetricDataAPI, namespace string) *CloudWatchEmitter {
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+
+	e := &CloudWatchEmitter{
+		client:    client,
+		namespace: namespace,
+		now:       time.Now,
+	}
+	e.emit = e.buffer
+
+	return e
+}
+
+func (e *CloudWatchEmitter) buffer(op string, table string, metrics ...metric) {
+	dimensions := []types.Dimension{
+		{Name: aws.String("Operation"), Value: aws.String(op)},
+	}
+	if table != "" {
+		dimensions = append(dimensions, types.Dimension{Name: aws.String("Table"), Value: aws.String(table)})
+	}
+
+	timestamp := e.now()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, m := range metrics {
+		e.data = append(e.data, types.MetricDatum{
+			MetricName: aws.String(m.name),
+			Unit:       types.StandardUnit(m.unit),
+			Value:      aws.Float64(m.value),
+			Dimensions: dimensions,
+			Timestamp:  aws.Time(timestamp),
+		})
+	}
+}
+
+// Flush sends every buffered measurement to CloudWatch. If a request fails,
+// Flush returns its error and the measurements not yet sent are dropped.
+func (e *CloudWatchEmitter) Flush(ctx context.Context) error {
+	e.mu.Lock()
+	data := e.data
+	e.data = nil
+	e.mu.Unlock()
+
+	for len(data) > 0 {
+		n := len(data)
+		if n > maxMetricDataPerRequest {
+			n = maxMetricDataPerRequest
+		}
+
+		_, err := e.client.PutMetricData(ctx, &cloudwatch.PutMetricDataInput{
+			Namespace:  aws.String(e.namespace),
+			MetricData: data[:n],
+		})
+		if err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package emfmetrics
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Emitter writes one Embedded Metric Format record per measurement,
+// dimensioned by Operation and, when known, Table.
+type Emitter struct {
+	recorder
+
+	mu        sync.Mutex
+	w         io.Writer
+	namespace string
+	now       func() time.Time
+}
+
+// New creates an Emitter writing to w, or to os.Stdout if w is nil, under
+// namespace, or DefaultNamespace if namespace is empty.
+func New(w io.Writer, namespace string) *Emitter {
+	if w == nil {
+		w = os.Stdout
+	}
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+
+	e := &Emitter{
+		w:         w,
+		namespace: namespace,
+		now:       time.Now,
+	}
+	e.emit = e.write
+
+	return e
+}
+
+type emfMetadata struct {
+	Timestamp         int64                `json:"Timestamp"`
+	CloudWatchMetrics []emfMetricDirective `json:"CloudWatchMetrics"`
+}
+
+type emfMetricDirective struct {
+	Namespace  string            `json:"Namespace"`
+	Dimensions [][]string        `json:"Dimensions"`
+	Metrics    []emfMetricFormat `json:"Metrics"`
+}
+
+type emfMetricFormat struct {
+	Name string `json:"Name"`
+	Unit string `json:"Unit"`
+}
+
+func (e *Emitter) write(op string, table string, metrics ...metric) {
+	dimensions := []string{"Operation"}
+	record := map[string]interface{}{
+		"Operation": op,
+	}
+	if table != "" {
+		dimensions = append(dimensions, "Table")
+		record["Table"] = table
+	}
+
+	formats := make([]emfMetricFormat, 0, len(metrics))
+	for _, m := range metrics {
+		formats = append(formats, emfMetricFormat{Name: m.name, Unit: m.unit})
+		record[m.name] = m.value
+	}
+	record["_aws"] = emfMetadata{
+		Timestamp: e.now().UnixMilli(),
+		CloudWatchMetrics: []emfMetricDirective{
+			{
+				Namespace:  e.namespace,
+				Dimensions: [][]string{dimensions},
+				Metrics:    formats,
+			},
+		},
+	}
+
+	b, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	_, _ = e.w.Write(append(b, '\n'))
+}
@@ -0,0 +1,55 @@
+// Package emfmetrics reports ddbretry's retry metrics to CloudWatch, either
+// as CloudWatch Embedded Metric Format records written to stdout, which
+// Lambda and the CloudWatch agent turn into metrics, or through the
+// CloudWatch PutMetricData API.
+package emfmetrics
+
+import (
+	"time"
+)
+
+const DefaultNamespace = "DDBRetry"
+
+const (
+	countUnit        = "Count"
+	millisecondsUnit = "Milliseconds"
+)
+
+// metric is a single value reported for an operation and table.
+type metric struct {
+	name  string
+	unit  string
+	value float64
+}
+
+// recorder implements ddbretry.MetricsRecorder on top of an emit function.
+type recorder struct {
+	emit func(op string, table string, metrics ...metric)
+}
+
+func (r *recorder) RecordAttempt(op string, table string, latency time.Duration, err error) {
+	r.emit(op, table, metric{name: "AttemptLatency", unit: millisecondsUnit, value: milliseconds(latency)})
+}
+
+func (r *recorder) RecordRetry(op string, table string, attempt int, delay time.Duration) {
+	r.emit(op, table,
+		metric{name: "Retries", unit: countUnit, value: 1},
+		metric{name: "RetryDelay", unit: millisecondsUnit, value: milliseconds(delay)},
+	)
+}
+
+func (r *recorder) RecordThrottle(op string, table string) {
+	r.emit(op, table, metric{name: "Throttles", unit: countUnit, value: 1})
+}
+
+func (r *recorder) RecordSuccess(op string, table string, attempts int) {
+	r.emit(op, table, metric{name: "Successes", unit: countUnit, value: 1})
+}
+
+func (r *recorder) RecordFailure(op string, table string, attempts int, err error) {
+	r.emit(op, table, metric{name: "GiveUps", unit: countUnit, value: 1})
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
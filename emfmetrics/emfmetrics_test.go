@@ -0,0 +1,96 @@
+package emfmetrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	_ ddbretry.MetricsRecorder = (*Emitter)(nil)
+	_ ddbretry.MetricsRecorder = (*CloudWatchEmitter)(nil)
+)
+
+func TestEmitter(t *testing.T) {
+	var buf bytes.Buffer
+	e := New(&buf, "")
+	e.now = func() time.Time {
+		return time.UnixMilli(1700000000000)
+	}
+
+	e.RecordRetry("GetItem", "users", 1, 20*time.Millisecond)
+	e.RecordThrottle("PutItem", "")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if !assert.Len(t, lines, 2) {
+		return
+	}
+
+	assert.JSONEq(t, `{
+		"_aws": {
+			"Timestamp": 1700000000000,
+			"CloudWatchMetrics": [{
+				"Namespace": "DDBRetry",
+				"Dimensions": [["Operation", "Table"]],
+				"Metrics": [
+					{"Name": "Retries", "Unit": "Count"},
+					{"Name": "RetryDelay", "Unit": "Milliseconds"}
+				]
+			}]
+		},
+		"Operation": "GetItem",
+		"Table": "users",
+		"Retries": 1,
+		"RetryDelay": 20
+	}`, lines[0])
+
+	var record map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &record))
+	assert.NotContains(t, record, "Table")
+	assert.Equal(t, float64(1), record["Throttles"])
+}
+
+type recordingPutMetricDataAPI struct {
+	inputs []*cloudwatch.PutMetricDataInput
+	err    error
+}
+
+func (c *recordingPutMetricDataAPI) PutMetricData(ctx context.Context, input *cloudwatch.PutMetricDataInput, o ...func(*cloudwatch.Options)) (*cloudwatch.PutMetricDataOutput, error) {
+	c.inputs = append(c.inputs, input)
+
+	return &cloudwatch.PutMetricDataOutput{}, c.err
+}
+
+func TestCloudWatchEmitter(t *testing.T) {
+	client := &recordingPutMetricDataAPI{}
+	e := NewCloudWatch(client, "Custom")
+
+	for i := 0; i < 1500; i++ {
+		e.RecordThrottle("GetItem", "users")
+	}
+	assert.NoError(t, e.Flush(context.Background()))
+
+	if assert.Len(t, client.inputs, 2) {
+		assert.Equal(t, "Custom", aws.ToString(client.inputs[0].Namespace))
+		assert.Len(t, client.inputs[0].MetricData, 1000)
+		assert.Len(t, client.inputs[1].MetricData, 500)
+		assert.Equal(t, "Throttles", aws.ToString(client.inputs[0].MetricData[0].MetricName))
+		assert.Len(t, client.inputs[0].MetricData[0].Dimensions, 2)
+	}
+
+	assert.NoError(t, e.Flush(context.Background()))
+	assert.Len(t, client.inputs, 2)
+
+	client.err = errors.New("unavailable")
+	e.RecordFailure("GetItem", "users", 3, nil)
+	assert.Error(t, e.Flush(context.Background()))
+}
@@ -0,0 +1,104 @@
+package ddbretry
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// EnvPrefix prefixes the names of the environment variables read by FromEnv.
+const EnvPrefix = "DDBRETRY_"
+
+// FromEnv loads a Config from the environment, taking each setting from the
+// variable named after it, such as DDBRETRY_RETRIES, DDBRETRY_BACKOFF or
+// DDBRETRY_STRATEGY. Unset variables leave the setting at its zero value.
+// Durations use time.ParseDuration syntax, booleans strconv.ParseBool syntax
+// and strategies the names returned by BackOffStrategy.String. Unparseable
+// variables are reported as InvalidConfigErrors, alongside the errors
+// reported by Config.Validate.
+func FromEnv() (Config, error) {
+	var (
+		cfg Config
+		env envLoader
+	)
+	env.bool("DISABLE_RETRIES", &cfg.DisableRetries)
+	env.bool("INFINITE", &cfg.Infinite)
+	env.int("RETRIES", &cfg.Retries)
+	env.int("MAX_ATTEMPTS", &cfg.MaxAttempts)
+	env.duration("BACKOFF", &cfg.BackOffTime)
+	env.strategy("STRATEGY", &cfg.BackOffStrategy)
+	env.duration("MAX_BACKOFF", &cfg.MaxBackOff)
+	env.duration("INITIAL_BACKOFF", &cfg.InitialBackOff)
+	env.bool("IMMEDIATE_RETRY", &cfg.ImmediateRetry)
+	env.duration("MAX_ELAPSED_TIME", &cfg.MaxElapsedTime)
+	env.bool("HONOR_RETRY_AFTER", &cfg.HonorRetryAfter)
+	env.duration("ATTEMPT_TIMEOUT", &cfg.AttemptTimeout)
+	env.duration("HEDGE_DELAY", &cfg.HedgeDelay)
+	env.int("INTERNAL_SERVER_ERROR_RETRIES", &cfg.InternalServerErrorRetries)
+	env.int("NETWORK_ERROR_RETRIES", &cfg.NetworkErrorRetries)
+	env.bool("RETRY_TRANSACTION_CONFLICTS", &cfg.RetryTransactionConflicts)
+	env.int("DOWNGRADE_CONSISTENT_READS_AFTER", &cfg.DowngradeConsistentReadsAfter)
+	env.bool("REQUIRE_IDEMPOTENT_WRITES", &cfg.RequireIdempotentWrites)
+	env.bool("VALIDATE_ITEM_SIZE", &cfg.ValidateItemSize)
+	env.bool("AGGREGATE_ERRORS", &cfg.AggregateErrors)
+	env.bool("SHADOW", &cfg.Shadow)
+	if len(env.errs) > 0 {
+		return Config{}, errors.Join(env.errs...)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+
+	return cfg, nil
+}
+
+// envLoader parses environment variables, collecting the errors.
+type envLoader struct {
+	errs []error
+}
+
+// lookup parses the variable EnvPrefix+name with parse, if it is set.
+func (l *envLoader) lookup(name string, parse func(value string) error) {
+	value, ok := os.LookupEnv(EnvPrefix + name)
+	if !ok {
+		return
+	}
+	if err := parse(value); err != nil {
+		l.errs = append(l.errs, NewInvalidConfigError(EnvPrefix+name, err.Error()))
+	}
+}
+
+func (l *envLoader) int(name string, dst *int) {
+	l.lookup(name, func(value string) (err error) {
+		*dst, err = strconv.Atoi(value)
+		return err
+	})
+}
+
+func (l *envLoader) bool(name string, dst *bool) {
+	l.lookup(name, func(value string) (err error) {
+		*dst, err = strconv.ParseBool(value)
+		return err
+	})
+}
+
+func (l *envLoader) duration(name string, dst *time.Duration) {
+	l.lookup(name, func(value string) (err error) {
+		*dst, err = time.ParseDuration(value)
+		return err
+	})
+}
+
+func (l *envLoader) strategy(name string, dst *BackOffStrategy) {
+	l.lookup(name, func(value string) error {
+		s, ok := parseBackOffStrategy(value)
+		if !ok {
+			return fmt.Errorf("unknown strategy %q", value)
+		}
+		*dst = s
+		return nil
+	})
+}
@@ -0,0 +1,68 @@
+package ddbretry
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    Config
+		wantErr error
+	}{
+		{
+			name: "should return the zero Config when nothing is set",
+		},
+		{
+			name: "should load settings",
+			env: map[string]string{
+				"DDBRETRY_RETRIES":           "5",
+				"DDBRETRY_BACKOFF":           "100ms",
+				"DDBRETRY_STRATEGY":          "Full-Jitter",
+				"DDBRETRY_MAX_BACKOFF":       "2s",
+				"DDBRETRY_HONOR_RETRY_AFTER": "true",
+			},
+			want: Config{
+				Retries:         5,
+				BackOffTime:     100 * time.Millisecond,
+				BackOffStrategy: FullJitterBackOff,
+				MaxBackOff:      2 * time.Second,
+				HonorRetryAfter: true,
+			},
+		},
+		{
+			name: "should report unparseable variables",
+			env: map[string]string{
+				"DDBRETRY_RETRIES":  "many",
+				"DDBRETRY_STRATEGY": "linear",
+			},
+			wantErr: errors.Join(
+				NewInvalidConfigError("DDBRETRY_RETRIES", `strconv.Atoi: parsing "many": invalid syntax`),
+				NewInvalidConfigError("DDBRETRY_STRATEGY", `unknown strategy "linear"`),
+			),
+		},
+		{
+			name: "should validate the loaded Config",
+			env: map[string]string{
+				"DDBRETRY_RETRIES": "-3",
+			},
+			wantErr: errors.Join(NewInvalidRetryError(-3)),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			got, err := FromEnv()
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -1,8 +1,12 @@
 package ddbretry
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
 type InvalidRetryError struct {
@@ -25,3 +29,320 @@ func IsInvalidRetryError(err error) bool {
 
 	return ok
 }
+
+type InvalidBackOffError struct {
+	BackOff time.Duration
+}
+
+func (e *InvalidBackOffError) Error() string {
+	return fmt.Sprintf("invalid value for backoff: %s", e.BackOff)
+}
+
+func NewInvalidBackOffError(backOff time.Duration) *InvalidBackOffError {
+	return &InvalidBackOffError{
+		BackOff: backOff,
+	}
+}
+
+func IsInvalidBackOffError(err error) bool {
+	var invalidBackOffError *InvalidBackOffError
+	ok := errors.As(err, &invalidBackOffError)
+
+	return ok
+}
+
+type BackOffDeadlineError struct {
+	Delay    time.Duration
+	Deadline time.Time
+	Err      error
+}
+
+func (e *BackOffDeadlineError) Error() string {
+	return fmt.Sprintf("backoff of %s would exceed context deadline %s: %v", e.Delay, e.Deadline.Format(time.RFC3339Nano), e.Err)
+}
+
+func (e *BackOffDeadlineError) Unwrap() error {
+	return e.Err
+}
+
+func (e *BackOffDeadlineError) Is(target error) bool {
+	return target == context.DeadlineExceeded
+}
+
+func NewBackOffDeadlineError(delay time.Duration, deadline time.Time, err error) *BackOffDeadlineError {
+	return &BackOffDeadlineError{
+		Delay:    delay,
+		Deadline: deadline,
+		Err:      err,
+	}
+}
+
+func IsBackOffDeadlineError(err error) bool {
+	var backOffDeadlineError *BackOffDeadlineError
+	ok := errors.As(err, &backOffDeadlineError)
+
+	return ok
+}
+
+type RetryBudgetExhaustedError struct {
+	Err error
+}
+
+func (e *RetryBudgetExhaustedError) Error() string {
+	return fmt.Sprintf("retry budget exhausted: %v", e.Err)
+}
+
+func (e *RetryBudgetExhaustedError) Unwrap() error {
+	return e.Err
+}
+
+func NewRetryBudgetExhaustedError(err error) *RetryBudgetExhaustedError {
+	return &RetryBudgetExhaustedError{
+		Err: err,
+	}
+}
+
+func IsRetryBudgetExhaustedError(err error) bool {
+	var retryBudgetExhaustedError *RetryBudgetExhaustedError
+	ok := errors.As(err, &retryBudgetExhaustedError)
+
+	return ok
+}
+
+type CircuitOpenError struct {
+	Until time.Time
+}
+
+func (e *CircuitOpenError) Error() string {
+	if e.Until.IsZero() {
+		return "circuit breaker is open"
+	}
+
+	return fmt.Sprintf("circuit breaker is open until %s", e.Until.Format(time.RFC3339Nano))
+}
+
+func NewCircuitOpenError(until time.Time) *CircuitOpenError {
+	return &CircuitOpenError{
+		Until: until,
+	}
+}
+
+func IsCircuitOpenError(err error) bool {
+	var circuitOpenError *CircuitOpenError
+	ok := errors.As(err, &circuitOpenError)
+
+	return ok
+}
+
+type InvalidConfigError struct {
+	Field  string
+	Reason string
+}
+
+func (e *InvalidConfigError) Error() string {
+	return fmt.Sprintf("invalid value for %s: %s", e.Field, e.Reason)
+}
+
+func NewInvalidConfigError(field string, reason string) *InvalidConfigError {
+	return &InvalidConfigError{
+		Field:  field,
+		Reason: reason,
+	}
+}
+
+func IsInvalidConfigError(err error) bool {
+	var invalidConfigError *InvalidConfigError
+	ok := errors.As(err, &invalidConfigError)
+
+	return ok
+}
+
+type NestedClientError struct{}
+
+func (e *NestedClientError) Error() string {
+	return "client is already wrapped by a RetryDynamoDBClient"
+}
+
+func NewNestedClientError() *NestedClientError {
+	return &NestedClientError{}
+}
+
+func IsNestedClientError(err error) bool {
+	var nestedClientError *NestedClientError
+	ok := errors.As(err, &nestedClientError)
+
+	return ok
+}
+
+type NoDefaultClientError struct{}
+
+func (e *NoDefaultClientError) Error() string {
+	return "no default client set, see SetDefault"
+}
+
+func NewNoDefaultClientError() *NoDefaultClientError {
+	return &NoDefaultClientError{}
+}
+
+func IsNoDefaultClientError(err error) bool {
+	var noDefaultClientError *NoDefaultClientError
+	ok := errors.As(err, &noDefaultClientError)
+
+	return ok
+}
+
+type UnprocessedItemsError struct {
+	Items map[string][]types.WriteRequest
+	Err   error
+}
+
+func (e *UnprocessedItemsError) Error() string {
+	n := 0
+	for _, requests := range e.Items {
+		n += len(requests)
+	}
+	if e.Err != nil {
+		return fmt.Sprintf("%d write requests left unprocessed: %v", n, e.Err)
+	}
+
+	return fmt.Sprintf("%d write requests left unprocessed", n)
+}
+
+func (e *UnprocessedItemsError) Unwrap() error {
+	return e.Err
+}
+
+func NewUnprocessedItemsError(items map[string][]types.WriteRequest, err error) *UnprocessedItemsError {
+	return &UnprocessedItemsError{
+		Items: items,
+		Err:   err,
+	}
+}
+
+func IsUnprocessedItemsError(err error) bool {
+	var unprocessedItemsError *UnprocessedItemsError
+	ok := errors.As(err, &unprocessedItemsError)
+
+	return ok
+}
+
+type BatchWriterClosedError struct{}
+
+func (e *BatchWriterClosedError) Error() string {
+	return "batch writer is closed"
+}
+
+func NewBatchWriterClosedError() *BatchWriterClosedError {
+	return &BatchWriterClosedError{}
+}
+
+func IsBatchWriterClosedError(err error) bool {
+	var batchWriterClosedError *BatchWriterClosedError
+	ok := errors.As(err, &batchWriterClosedError)
+
+	return ok
+}
+
+type WriteQueueClosedError struct{}
+
+func (e *WriteQueueClosedError) Error() string {
+	return "write queue is closed"
+}
+
+func NewWriteQueueClosedError() *WriteQueueClosedError {
+	return &WriteQueueClosedError{}
+}
+
+func IsWriteQueueClosedError(err error) bool {
+	var writeQueueClosedError *WriteQueueClosedError
+	ok := errors.As(err, &writeQueueClosedError)
+
+	return ok
+}
+
+type CapacityBudgetExhaustedError struct {
+	Err error
+}
+
+func (e *CapacityBudgetExhaustedError) Error() string {
+	if e.Err == nil {
+		return "capacity budget exhausted"
+	}
+
+	return fmt.Sprintf("capacity budget exhausted: %v", e.Err)
+}
+
+func (e *CapacityBudgetExhaustedError) Unwrap() error {
+	return e.Err
+}
+
+func NewCapacityBudgetExhaustedError(err error) *CapacityBudgetExhaustedError {
+	return &CapacityBudgetExhaustedError{
+		Err: err,
+	}
+}
+
+func IsCapacityBudgetExhaustedError(err error) bool {
+	var capacityBudgetExhaustedError *CapacityBudgetExhaustedError
+	ok := errors.As(err, &capacityBudgetExhaustedError)
+
+	return ok
+}
+
+type ItemTooLargeError struct {
+	Table string
+	Size  int
+}
+
+func (e *ItemTooLargeError) Error() string {
+	return fmt.Sprintf("item of %d bytes for table %q exceeds the DynamoDB item size limit of %d bytes", e.Size, e.Table, MaxItemSize)
+}
+
+func NewItemTooLargeError(table string, size int) *ItemTooLargeError {
+	return &ItemTooLargeError{
+		Table: table,
+		Size:  size,
+	}
+}
+
+func IsItemTooLargeError(err error) bool {
+	var itemTooLargeError *ItemTooLargeError
+	ok := errors.As(err, &itemTooLargeError)
+
+	return ok
+}
+
+type UnprocessedKeysError struct {
+	Keys map[string][]map[string]types.AttributeValue
+	Err  error
+}
+
+func (e *UnprocessedKeysError) Error() string {
+	n := 0
+	for _, keys := range e.Keys {
+		n += len(keys)
+	}
+	if e.Err != nil {
+		return fmt.Sprintf("%d keys left unprocessed: %v", n, e.Err)
+	}
+
+	return fmt.Sprintf("%d keys left unprocessed", n)
+}
+
+func (e *UnprocessedKeysError) Unwrap() error {
+	return e.Err
+}
+
+func NewUnprocessedKeysError(keys map[string][]map[string]types.AttributeValue, err error) *UnprocessedKeysError {
+	return &UnprocessedKeysError{
+		Keys: keys,
+		Err:  err,
+	}
+}
+
+func IsUnprocessedKeysError(err error) bool {
+	var unprocessedKeysError *UnprocessedKeysError
+	ok := errors.As(err, &unprocessedKeysError)
+
+	return ok
+}
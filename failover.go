@@ -0,0 +1,139 @@
+package ddbretry
+
+import (
+	"context"
+
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// FailoverDynamoDBClient sends calls to a primary client and, when a call
+// fails with a throttling, internal server or network error, or because the
+// primary's CircuitBreaker is open, sends it again to each replica in turn,
+// such as the clients of other regions of a global table. The primary and
+// replicas are usually RetryDynamoDBClients, so a call only fails over once
+// the primary has used up its retries. Reads fail over always and writes only
+// if FailoverWrites is set, as global tables resolve conflicting writes made
+// in different regions by keeping the last one. Strongly consistent reads
+// served by a replica are only consistent with the writes made in its
+// region.
+type FailoverDynamoDBClient struct {
+	DynamoDBClient
+	Replicas       []DynamoDBClient
+	FailoverWrites bool
+	// OnFailover is called, when set, each time a call is sent to the
+	// replica at the given index of Replicas after failing with err.
+	OnFailover func(op string, table string, replica int, err error)
+}
+
+var _ DynamoDBClient = (*FailoverDynamoDBClient)(nil)
+
+func NewFailoverDynamoDBClient(primary DynamoDBClient, replicas ...DynamoDBClient) *FailoverDynamoDBClient {
+	return &FailoverDynamoDBClient{
+		DynamoDBClient: primary,
+		Replicas:       replicas,
+	}
+}
+
+// Unwrap returns the primary client.
+func (c *FailoverDynamoDBClient) Unwrap() DynamoDBClient {
+	return c.DynamoDBClient
+}
+
+// failoverError reports whether a call failing with err should be sent to a
+// replica.
+func failoverError(err error) bool {
+	if IsCircuitOpenError(err) {
+		return true
+	}
+
+	switch ErrorKindOf(err) {
+	case ThrottlingErrorKind, InternalServerErrorKind, NetworkErrorKind:
+		return true
+	default:
+		return false
+	}
+}
+
+// failover sends a call to the primary client, then to each replica in turn
+// while it fails with an error that fails over, returning the last result.
+func failover[TIn, TOut any](c *FailoverDynamoDBClient, ctx context.Context, op string, input TIn, call func(DynamoDBClient, TIn) (TOut, error)) (TOut, error) {
+	output, err := call(c.DynamoDBClient, input)
+	if err == nil || (writeOperations[op] && !c.FailoverWrites) {
+		return output, err
+	}
+
+	for i, replica := range c.Replicas {
+		if ctx.Err() != nil || !failoverError(err) {
+			break
+		}
+		if f := c.OnFailover; f != nil {
+			f(op, tableName(input), i, err)
+		}
+		output, err = call(replica, input)
+		if err == nil {
+			break
+		}
+	}
+
+	return output, err
+}
+
+func (c *FailoverDynamoDBClient) GetItem(ctx context.Context, input *ddb.GetItemInput, o ...func(*ddb.Options)) (*ddb.GetItemOutput, error) {
+	return failover(c, ctx, "GetItem", input, func(client DynamoDBClient, input *ddb.GetItemInput) (*ddb.GetItemOutput, error) {
+		return client.GetItem(ctx, input, o...)
+	})
+}
+
+func (c *FailoverDynamoDBClient) Query(ctx context.Context, input *ddb.QueryInput, o ...func(*ddb.Options)) (*ddb.QueryOutput, error) {
+	return failover(c, ctx, "Query", input, func(client DynamoDBClient, input *ddb.QueryInput) (*ddb.QueryOutput, error) {
+		return client.Query(ctx, input, o...)
+	})
+}
+
+func (c *FailoverDynamoDBClient) Scan(ctx context.Context, input *ddb.ScanInput, o ...func(*ddb.Options)) (*ddb.ScanOutput, error) {
+	return failover(c, ctx, "Scan", input, func(client DynamoDBClient, input *ddb.ScanInput) (*ddb.ScanOutput, error) {
+		return client.Scan(ctx, input, o...)
+	})
+}
+
+func (c *FailoverDynamoDBClient) BatchGetItem(ctx context.Context, input *ddb.BatchGetItemInput, o ...func(*ddb.Options)) (*ddb.BatchGetItemOutput, error) {
+	return failover(c, ctx, "BatchGetItem", input, func(client DynamoDBClient, input *ddb.BatchGetItemInput) (*ddb.BatchGetItemOutput, error) {
+		return client.BatchGetItem(ctx, input, o...)
+	})
+}
+
+func (c *FailoverDynamoDBClient) TransactGetItems(ctx context.Context, input *ddb.TransactGetItemsInput, o ...func(*ddb.Options)) (*ddb.TransactGetItemsOutput, error) {
+	return failover(c, ctx, "TransactGetItems", input, func(client DynamoDBClient, input *ddb.TransactGetItemsInput) (*ddb.TransactGetItemsOutput, error) {
+		return client.TransactGetItems(ctx, input, o...)
+	})
+}
+
+func (c *FailoverDynamoDBClient) PutItem(ctx context.Context, input *ddb.PutItemInput, o ...func(*ddb.Options)) (*ddb.PutItemOutput, error) {
+	return failover(c, ctx, "PutItem", input, func(client DynamoDBClient, input *ddb.PutItemInput) (*ddb.PutItemOutput, error) {
+		return client.PutItem(ctx, input, o...)
+	})
+}
+
+func (c *FailoverDynamoDBClient) DeleteItem(ctx context.Context, input *ddb.DeleteItemInput, o ...func(*ddb.Options)) (*ddb.DeleteItemOutput, error) {
+	return failover(c, ctx, "DeleteItem", input, func(client DynamoDBClient, input *ddb.DeleteItemInput) (*ddb.DeleteItemOutput, error) {
+		return client.DeleteItem(ctx, input, o...)
+	})
+}
+
+func (c *FailoverDynamoDBClient) UpdateItem(ctx context.Context, input *ddb.UpdateItemInput, o ...func(*ddb.Options)) (*ddb.UpdateItemOutput, error) {
+	return failover(c, ctx, "UpdateItem", input, func(client DynamoDBClient, input *ddb.UpdateItemInput) (*ddb.UpdateItemOutput, error) {
+		return client.UpdateItem(ctx, input, o...)
+	})
+}
+
+func (c *FailoverDynamoDBClient) BatchWriteItem(ctx context.Context, input *ddb.BatchWriteItemInput, o ...func(*ddb.Options)) (*ddb.BatchWriteItemOutput, error) {
+	return failover(c, ctx, "BatchWriteItem", input, func(client DynamoDBClient, input *ddb.BatchWriteItemInput) (*ddb.BatchWriteItemOutput, error) {
+		return client.BatchWriteItem(ctx, input, o...)
+	})
+}
+
+func (c *FailoverDynamoDBClient) TransactWriteItems(ctx context.Context, input *ddb.TransactWriteItemsInput, o ...func(*ddb.Options)) (*ddb.TransactWriteItemsOutput, error) {
+	return failover(c, ctx, "TransactWriteItems", input, func(client DynamoDBClient, input *ddb.TransactWriteItemsInput) (*ddb.TransactWriteItemsOutput, error) {
+		return client.TransactWriteItems(ctx, input, o...)
+	})
+}
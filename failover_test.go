@@ -0,0 +1,89 @@
+package ddbretry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry/ddbretrytest"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFailoverDynamoDBClient(t *testing.T) {
+	tests := []struct {
+		name           string
+		primaryErr     error
+		replicas       []DynamoDBClient
+		failoverWrites bool
+		wantReadErr    bool
+		wantWriteErr   bool
+		wantFailovers  int
+	}{
+		{
+			name:          "should not fail over successful calls",
+			replicas:      []DynamoDBClient{ddbretrytest.NewFakeClient()},
+			wantFailovers: 0,
+		},
+		{
+			name:          "should fail over throttled reads but not writes",
+			primaryErr:    &types.ProvisionedThroughputExceededException{},
+			replicas:      []DynamoDBClient{ddbretrytest.NewFakeClient()},
+			wantReadErr:   false,
+			wantWriteErr:  true,
+			wantFailovers: 1,
+		},
+		{
+			name:           "should fail over writes when FailoverWrites is set",
+			primaryErr:     &types.InternalServerError{},
+			replicas:       []DynamoDBClient{ddbretrytest.NewFakeClient()},
+			failoverWrites: true,
+			wantFailovers:  2,
+		},
+		{
+			name:       "should try each replica in turn",
+			primaryErr: NewCircuitOpenError(time.Unix(1700000000, 0)),
+			replicas: []DynamoDBClient{
+				ddbretrytest.NewFakeClient().
+					SetError("GetItem", &types.RequestLimitExceeded{}).
+					SetError("PutItem", &types.RequestLimitExceeded{}),
+				ddbretrytest.NewFakeClient(),
+			},
+			wantReadErr:   false,
+			wantWriteErr:  true,
+			wantFailovers: 2,
+		},
+		{
+			name:          "should not fail over other errors",
+			primaryErr:    errors.New("validation failed"),
+			replicas:      []DynamoDBClient{ddbretrytest.NewFakeClient()},
+			wantReadErr:   true,
+			wantWriteErr:  true,
+			wantFailovers: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary := ddbretrytest.NewFakeClient().
+				SetError("GetItem", tt.primaryErr).
+				SetError("PutItem", tt.primaryErr)
+			failovers := 0
+			c := NewFailoverDynamoDBClient(primary, tt.replicas...)
+			c.FailoverWrites = tt.failoverWrites
+			c.OnFailover = func(op string, table string, replica int, err error) {
+				assert.Equal(t, "users", table)
+				failovers++
+			}
+			ctx := context.Background()
+
+			_, err := c.GetItem(ctx, &ddb.GetItemInput{TableName: aws.String("users")})
+			assert.Equal(t, tt.wantReadErr, err != nil)
+			_, err = c.PutItem(ctx, &ddb.PutItemInput{TableName: aws.String("users")})
+			assert.Equal(t, tt.wantWriteErr, err != nil)
+			assert.Equal(t, tt.wantFailovers, failovers)
+		})
+	}
+}
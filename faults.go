@@ -0,0 +1,246 @@
+package ddbretry
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Fault is a failure a FaultInjectingClient injects into the calls it
+// forwards. Each matching call is hit with the given Probability, a fault
+// with a Probability of 1 hitting every call. A call that is hit is delayed
+// by Latency and then, if Err is set, fails with Err without being
+// forwarded.
+//
+// Operations and Tables limit the fault to calls of the named operations and
+// to the named tables, and After and For to a window of time measured from
+// the client's Start: the fault starts After into it and lasts For, or
+// indefinitely if For is not positive.
+type Fault struct {
+	Probability float64
+	Err         error
+	Latency     time.Duration
+	Operations  []string
+	Tables      []string
+	After       time.Duration
+	For         time.Duration
+}
+
+// ThrottleFault fails calls with a ProvisionedThroughputExceededException
+// with the given probability.
+func ThrottleFault(probability float64) Fault {
+	return Fault{
+		Probability: probability,
+		Err: &types.ProvisionedThroughputExceededException{
+			Message: aws.String("injected throttle"),
+		},
+	}
+}
+
+// InternalServerErrorFault fails calls with an InternalServerError with the
+// given probability.
+func InternalServerErrorFault(probability float64) Fault {
+	return Fault{
+		Probability: probability,
+		Err: &types.InternalServerError{
+			Message: aws.String("injected internal server error"),
+		},
+	}
+}
+
+// NetworkErrorFault fails calls with a refused connection with the given
+// probability.
+func NetworkErrorFault(probability float64) Fault {
+	return Fault{
+		Probability: probability,
+		Err: &net.OpError{
+			Op:  "dial",
+			Net: "tcp",
+			Err: errors.New("injected connection refused"),
+		},
+	}
+}
+
+// LatencyFault delays calls by latency with the given probability.
+func LatencyFault(probability float64, latency time.Duration) Fault {
+	return Fault{
+		Probability: probability,
+		Latency:     latency,
+	}
+}
+
+// FaultInjectingClient wraps a DynamoDBClient, injecting Faults into the
+// calls it forwards, to test how a service behaves while DynamoDB is
+// degraded. It is usually wrapped in turn by a RetryDynamoDBClient, so that
+// the injected failures are retried as real ones would be. Faults are
+// evaluated in order: the latencies of every fault hitting a call add up,
+// and the first fault with an error fails it.
+//
+// Rand and Clock can be set to make the faults injected reproducible, with
+// Start set to the Clock's time.
+type FaultInjectingClient struct {
+	DynamoDBClient
+	Faults []Fault
+	Start  time.Time
+	Rand   *Rand
+	Clock  Clock
+
+	injected int64
+}
+
+var _ DynamoDBClient = (*FaultInjectingClient)(nil)
+
+func NewFaultInjectingClient(client DynamoDBClient, faults ...Fault) *FaultInjectingClient {
+	return &FaultInjectingClient{
+		DynamoDBClient: client,
+		Faults:         faults,
+		Start:          time.Now(),
+	}
+}
+
+// Unwrap returns the client c wraps.
+func (c *FaultInjectingClient) Unwrap() DynamoDBClient {
+	return c.DynamoDBClient
+}
+
+// Injected returns the number of calls failed by a fault.
+func (c *FaultInjectingClient) Injected() int64 {
+	return atomic.LoadInt64(&c.injected)
+}
+
+func (c *FaultInjectingClient) clock() Clock {
+	if c.Clock != nil {
+		return c.Clock
+	}
+
+	return realClock{}
+}
+
+// active reports whether f applies to a call of op to table made elapsed
+// after the client's Start.
+func (f Fault) active(op string, table string, elapsed time.Duration) bool {
+	if elapsed < f.After || (f.For > 0 && elapsed >= f.After+f.For) {
+		return false
+	}
+
+	return (len(f.Operations) == 0 || contains(f.Operations, op)) &&
+		(len(f.Tables) == 0 || contains(f.Tables, table))
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// inject applies the faults hitting a call of op with input, returning the
+// error the call fails with, if any.
+func (c *FaultInjectingClient) inject(ctx context.Context, op string, input interface{}) error {
+	clock := c.clock()
+	elapsed := clock.Now().Sub(c.Start)
+	table := tableName(input)
+
+	var latency time.Duration
+	var err error
+	for _, f := range c.Faults {
+		if !f.active(op, table, elapsed) || c.Rand.float64() >= f.Probability {
+			continue
+		}
+		latency += f.Latency
+		if f.Err != nil {
+			err = f.Err
+			break
+		}
+	}
+
+	if latency > 0 {
+		if sleepErr := clock.Sleep(ctx, latency); sleepErr != nil {
+			return sleepErr
+		}
+	}
+	if err != nil {
+		atomic.AddInt64(&c.injected, 1)
+	}
+
+	return err
+}
+
+// injectFaults forwards a call unless a fault fails it.
+func injectFaults[TIn, TOut any](c *FaultInjectingClient, ctx context.Context, op string, input TIn, call func(TIn) (TOut, error)) (TOut, error) {
+	if err := c.inject(ctx, op, input); err != nil {
+		var zero TOut
+		return zero, err
+	}
+
+	return call(input)
+}
+
+func (c *FaultInjectingClient) GetItem(ctx context.Context, input *ddb.GetItemInput, o ...func(*ddb.Options)) (*ddb.GetItemOutput, error) {
+	return injectFaults(c, ctx, "GetItem", input, func(input *ddb.GetItemInput) (*ddb.GetItemOutput, error) {
+		return c.DynamoDBClient.GetItem(ctx, input, o...)
+	})
+}
+
+func (c *FaultInjectingClient) Query(ctx context.Context, input *ddb.QueryInput, o ...func(*ddb.Options)) (*ddb.QueryOutput, error) {
+	return injectFaults(c, ctx, "Query", input, func(input *ddb.QueryInput) (*ddb.QueryOutput, error) {
+		return c.DynamoDBClient.Query(ctx, input, o...)
+	})
+}
+
+func (c *FaultInjectingClient) Scan(ctx context.Context, input *ddb.ScanInput, o ...func(*ddb.Options)) (*ddb.ScanOutput, error) {
+	return injectFaults(c, ctx, "Scan", input, func(input *ddb.ScanInput) (*ddb.ScanOutput, error) {
+		return c.DynamoDBClient.Scan(ctx, input, o...)
+	})
+}
+
+func (c *FaultInjectingClient) BatchGetItem(ctx context.Context, input *ddb.BatchGetItemInput, o ...func(*ddb.Options)) (*ddb.BatchGetItemOutput, error) {
+	return injectFaults(c, ctx, "BatchGetItem", input, func(input *ddb.BatchGetItemInput) (*ddb.BatchGetItemOutput, error) {
+		return c.DynamoDBClient.BatchGetItem(ctx, input, o...)
+	})
+}
+
+func (c *FaultInjectingClient) TransactGetItems(ctx context.Context, input *ddb.TransactGetItemsInput, o ...func(*ddb.Options)) (*ddb.TransactGetItemsOutput, error) {
+	return injectFaults(c, ctx, "TransactGetItems", input, func(input *ddb.TransactGetItemsInput) (*ddb.TransactGetItemsOutput, error) {
+		return c.DynamoDBClient.TransactGetItems(ctx, input, o...)
+	})
+}
+
+func (c *FaultInjectingClient) PutItem(ctx context.Context, input *ddb.PutItemInput, o ...func(*ddb.Options)) (*ddb.PutItemOutput, error) {
+	return injectFaults(c, ctx, "PutItem", input, func(input *ddb.PutItemInput) (*ddb.PutItemOutput, error) {
+		return c.DynamoDBClient.PutItem(ctx, input, o...)
+	})
+}
+
+func (c *FaultInjectingClient) DeleteItem(ctx context.Context, input *ddb.DeleteItemInput, o ...func(*ddb.Options)) (*ddb.DeleteItemOutput, error) {
+	return injectFaults(c, ctx, "DeleteItem", input, func(input *ddb.DeleteItemInput) (*ddb.DeleteItemOutput, error) {
+		return c.DynamoDBClient.DeleteItem(ctx, input, o...)
+	})
+}
+
+func (c *FaultInjectingClient) UpdateItem(ctx context.Context, input *ddb.UpdateItemInput, o ...func(*ddb.Options)) (*ddb.UpdateItemOutput, error) {
+	return injectFaults(c, ctx, "UpdateItem", input, func(input *ddb.UpdateItemInput) (*ddb.UpdateItemOutput, error) {
+		return c.DynamoDBClient.UpdateItem(ctx, input, o...)
+	})
+}
+
+func (c *FaultInjectingClient) BatchWriteItem(ctx context.Context, input *ddb.BatchWriteItemInput, o ...func(*ddb.Options)) (*ddb.BatchWriteItemOutput, error) {
+	return injectFaults(c, ctx, "BatchWriteItem", input, func(input *ddb.BatchWriteItemInput) (*ddb.BatchWriteItemOutput, error) {
+		return c.DynamoDBClient.BatchWriteItem(ctx, input, o...)
+	})
+}
+
+func (c *FaultInjectingClient) TransactWriteItems(ctx context.Context, input *ddb.TransactWriteItemsInput, o ...func(*ddb.Options)) (*ddb.TransactWriteItemsOutput, error) {
+	return injectFaults(c, ctx, "TransactWriteItems", input, func(input *ddb.TransactWriteItemsInput) (*ddb.TransactWriteItemsOutput, error) {
+		return c.DynamoDBClient.TransactWriteItems(ctx, input, o...)
+	})
+}
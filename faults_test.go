@@ -0,0 +1,183 @@
+package ddbretry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry/ddbretrytest"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFaultInjectingClient(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	tests := []struct {
+		name     string
+		faults   []Fault
+		elapsed  time.Duration
+		op       string
+		table    string
+		wantErr  func(error) bool
+		wantCall bool
+		wantWait time.Duration
+	}{
+		{
+			name:     "should forward calls without faults",
+			op:       "GetItem",
+			wantCall: true,
+		},
+		{
+			name:    "should inject throttles",
+			faults:  []Fault{ThrottleFault(1)},
+			op:      "GetItem",
+			wantErr: IsProvisionedThroughputExceededException,
+		},
+		{
+			name:    "should inject internal server errors",
+			faults:  []Fault{InternalServerErrorFault(1)},
+			op:      "PutItem",
+			wantErr: IsInternalServerError,
+		},
+		{
+			name:    "should inject network errors",
+			faults:  []Fault{NetworkErrorFault(1)},
+			op:      "PutItem",
+			wantErr: IsNetworkError,
+		},
+		{
+			name:     "should add up latencies before the first error",
+			faults:   []Fault{LatencyFault(1, time.Second), LatencyFault(1, time.Second), ThrottleFault(1), LatencyFault(1, time.Hour)},
+			op:       "GetItem",
+			wantErr:  IsProvisionedThroughputExceededException,
+			wantWait: 2 * time.Second,
+		},
+		{
+			name:     "should delay forwarded calls",
+			faults:   []Fault{LatencyFault(1, time.Second)},
+			op:       "GetItem",
+			wantCall: true,
+			wantWait: time.Second,
+		},
+		{
+			name:     "should never inject faults with zero probability",
+			faults:   []Fault{ThrottleFault(0)},
+			op:       "GetItem",
+			wantCall: true,
+		},
+		{
+			name: "should only inject into matching operations",
+			faults: []Fault{func() Fault {
+				f := ThrottleFault(1)
+				f.Operations = []string{"PutItem"}
+				return f
+			}()},
+			op:       "GetItem",
+			wantCall: true,
+		},
+		{
+			name: "should only inject into matching tables",
+			faults: []Fault{func() Fault {
+				f := ThrottleFault(1)
+				f.Tables = []string{"users"}
+				return f
+			}()},
+			op:      "PutItem",
+			table:   "users",
+			wantErr: IsProvisionedThroughputExceededException,
+		},
+		{
+			name: "should not inject before the fault starts",
+			faults: []Fault{func() Fault {
+				f := ThrottleFault(1)
+				f.After = time.Minute
+				return f
+			}()},
+			elapsed:  30 * time.Second,
+			op:       "GetItem",
+			wantCall: true,
+		},
+		{
+			name: "should not inject once the fault ends",
+			faults: []Fault{func() Fault {
+				f := ThrottleFault(1)
+				f.After = time.Minute
+				f.For = time.Minute
+				return f
+			}()},
+			elapsed:  2 * time.Minute,
+			op:       "GetItem",
+			wantCall: true,
+		},
+		{
+			name: "should inject while the fault lasts",
+			faults: []Fault{func() Fault {
+				f := ThrottleFault(1)
+				f.After = time.Minute
+				f.For = time.Minute
+				return f
+			}()},
+			elapsed: 90 * time.Second,
+			op:      "GetItem",
+			wantErr: IsProvisionedThroughputExceededException,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := ddbretrytest.NewFakeClient()
+			clock := ddbretrytest.NewFakeClock(start.Add(tt.elapsed))
+			c := NewFaultInjectingClient(fake, tt.faults...)
+			c.Start = start
+			c.Clock = clock
+
+			var err error
+			switch tt.op {
+			case "GetItem":
+				_, err = c.GetItem(context.Background(), &ddb.GetItemInput{TableName: aws.String(tt.table)})
+			case "PutItem":
+				_, err = c.PutItem(context.Background(), &ddb.PutItemInput{TableName: aws.String(tt.table)})
+			}
+			if tt.wantErr != nil {
+				assert.True(t, tt.wantErr(err))
+				assert.Equal(t, int64(1), c.Injected())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantCall, fake.CallCount(tt.op) == 1)
+			assert.Equal(t, start.Add(tt.elapsed+tt.wantWait), clock.Now())
+		})
+	}
+}
+
+func TestFaultInjectingClient_probability(t *testing.T) {
+	c := NewFaultInjectingClient(ddbretrytest.NewFakeClient(), ThrottleFault(0.25))
+	c.Rand = NewSeededRand(1)
+
+	for i := 0; i < 1000; i++ {
+		_, _ = c.GetItem(context.Background(), &ddb.GetItemInput{})
+	}
+	assert.InDelta(t, 250, c.Injected(), 50)
+}
+
+func TestFaultInjectingClient_retried(t *testing.T) {
+	fault := ThrottleFault(1)
+	fault.For = 3 * time.Second
+
+	start := time.Unix(1700000000, 0)
+	clock := ddbretrytest.NewFakeClock(start)
+	faults := NewFaultInjectingClient(ddbretrytest.NewFakeClient(), fault)
+	faults.Start = start
+	faults.Clock = clock
+
+	c := &RetryDynamoDBClient{
+		DynamoDBClient:  faults,
+		Retries:         5,
+		BackOffTime:     time.Second,
+		BackOffStrategy: ConstantBackOff,
+		Clock:           clock,
+	}
+	_, err := c.GetItem(context.Background(), &ddb.GetItemInput{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), faults.Injected())
+}
@@ -0,0 +1,105 @@
+package ddbretry
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// getItem sends a single GetItem attempt. If HedgeDelay is set and the request
+// has not completed within it, an identical hedge request is sent and the
+// first successful response wins, cancelling the other request. When both
+// fail the primary request's error is returned, so a throttled hedge never
+// counts against the retry budget on its own. The hedge takes its own slot
+// from the ConcurrencyLimiter and the table's bulkhead, and is not sent if
+// either has none free.
+func (c *RetryDynamoDBClient) getItem(ctx context.Context, input *ddb.GetItemInput, o ...func(*ddb.Options)) (*ddb.GetItemOutput, error) {
+	if c.HedgeDelay <= 0 {
+		return c.DynamoDBClient.GetItem(ctx, input, o...)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		output *ddb.GetItemOutput
+		err    error
+	}
+	send := func(release func()) <-chan result {
+		results := make(chan result, 1)
+		go func() {
+			defer release()
+			output, err := c.DynamoDBClient.GetItem(ctx, input, o...)
+			results <- result{output, err}
+		}()
+
+		return results
+	}
+
+	primary := send(func() {})
+	timer := time.NewTimer(c.HedgeDelay)
+	defer timer.Stop()
+
+	select {
+	case r := <-primary:
+		return r.output, r.err
+	case <-timer.C:
+	}
+
+	release, ok := c.acquireHedge(aws.ToString(input.TableName))
+	if !ok {
+		r := <-primary
+		return r.output, r.err
+	}
+	hedge := send(release)
+	var primaryErr error
+	for primary != nil || hedge != nil {
+		select {
+		case r := <-primary:
+			if r.err == nil {
+				return r.output, nil
+			}
+			primaryErr = r.err
+			primary = nil
+		case r := <-hedge:
+			if r.err == nil {
+				return r.output, nil
+			}
+			hedge = nil
+		}
+	}
+
+	return nil, primaryErr
+}
+
+// acquireHedge takes a slot for a hedge request to table from the client's
+// ConcurrencyLimiter and the table's bulkhead, without waiting for one. It
+// returns a function giving the slots back, or false if either is full.
+func (c *RetryDynamoDBClient) acquireHedge(table string) (release func(), ok bool) {
+	var limiters []*ConcurrencyLimiter
+	if l := c.ConcurrencyLimiter; l != nil {
+		limiters = append(limiters, l)
+	}
+	if b := c.Bulkheads; b != nil {
+		if l := b.Limiter(table); l != nil {
+			limiters = append(limiters, l)
+		}
+	}
+
+	for i, l := range limiters {
+		if !l.tryAcquire() {
+			for _, l := range limiters[:i] {
+				l.release()
+			}
+			return nil, false
+		}
+	}
+
+	return func() {
+		for _, l := range limiters {
+			l.release()
+		}
+	}, true
+}
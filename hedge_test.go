@@ -0,0 +1,168 @@
+package ddbretry
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry/ddbretrytest"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type hedgeResponse struct {
+	delay time.Duration
+	err   error
+}
+
+type HedgingDynamoDBClient struct {
+	ddbretrytest.FakeClient
+	mu        sync.Mutex
+	responses []hedgeResponse
+	cancelled int
+}
+
+func (c *HedgingDynamoDBClient) GetItem(ctx context.Context, input *ddb.GetItemInput, o ...func(*ddb.Options)) (*ddb.GetItemOutput, error) {
+	c.mu.Lock()
+	r := c.responses[0]
+	c.responses = c.responses[1:]
+	c.mu.Unlock()
+
+	select {
+	case <-time.After(r.delay):
+	case <-ctx.Done():
+		c.mu.Lock()
+		c.cancelled++
+		c.mu.Unlock()
+		return nil, ctx.Err()
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	return &ddb.GetItemOutput{}, nil
+}
+
+func TestRetryDynamoDBClient_HedgeDelay(t *testing.T) {
+	tests := []struct {
+		name          string
+		responses     []hedgeResponse
+		wantErr       error
+		wantCancelled int
+	}{
+		{
+			name: "should return hedge response and cancel slow primary",
+			responses: []hedgeResponse{
+				{delay: time.Hour},
+				{delay: 0},
+			},
+			wantErr:       nil,
+			wantCancelled: 1,
+		},
+		{
+			name: "should return primary response when it completes before the hedge delay",
+			responses: []hedgeResponse{
+				{delay: 0},
+			},
+			wantErr:       nil,
+			wantCancelled: 0,
+		},
+		{
+			name: "should ignore throttled hedge and wait for primary",
+			responses: []hedgeResponse{
+				{delay: 50 * time.Millisecond},
+				{delay: 0, err: &types.ProvisionedThroughputExceededException{}},
+			},
+			wantErr:       nil,
+			wantCancelled: 0,
+		},
+		{
+			name: "should return primary error when both requests fail",
+			responses: []hedgeResponse{
+				{delay: 50 * time.Millisecond, err: errors.New("foo")},
+				{delay: 0, err: &types.ProvisionedThroughputExceededException{}},
+			},
+			wantErr:       errors.New("foo"),
+			wantCancelled: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &HedgingDynamoDBClient{
+				responses: tt.responses,
+			}
+			c := &RetryDynamoDBClient{
+				DynamoDBClient: client,
+				HedgeDelay:     10 * time.Millisecond,
+			}
+
+			_, err := c.GetItem(context.Background(), &ddb.GetItemInput{})
+			assert.Equal(t, tt.wantErr, err)
+
+			// Give a cancelled loser a moment to observe its context.
+			time.Sleep(10 * time.Millisecond)
+			client.mu.Lock()
+			assert.Equal(t, tt.wantCancelled, client.cancelled)
+			client.mu.Unlock()
+		})
+	}
+}
+
+func TestRetryDynamoDBClient_HedgeDelaySlots(t *testing.T) {
+	tests := []struct {
+		name          string
+		limiter       *ConcurrencyLimiter
+		bulkheads     *Bulkheads
+		wantHedged    bool
+		wantCancelled int
+	}{
+		{
+			name:          "should hedge when the limiter has a free slot",
+			limiter:       NewConcurrencyLimiter(2),
+			wantHedged:    true,
+			wantCancelled: 1,
+		},
+		{
+			name:    "should not hedge when the limiter is full",
+			limiter: NewConcurrencyLimiter(1),
+		},
+		{
+			name:      "should not hedge when the table's bulkhead is full",
+			limiter:   NewConcurrencyLimiter(2),
+			bulkheads: NewBulkheads(1, 0, 0),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &HedgingDynamoDBClient{
+				responses: []hedgeResponse{
+					{delay: 50 * time.Millisecond},
+					{delay: time.Hour},
+				},
+			}
+			c := &RetryDynamoDBClient{
+				DynamoDBClient:     client,
+				HedgeDelay:         10 * time.Millisecond,
+				ConcurrencyLimiter: tt.limiter,
+				Bulkheads:          tt.bulkheads,
+			}
+
+			_, err := c.GetItem(context.Background(), &ddb.GetItemInput{TableName: aws.String("users")})
+			assert.NoError(t, err)
+
+			time.Sleep(10 * time.Millisecond)
+			client.mu.Lock()
+			assert.Equal(t, tt.wantHedged, len(client.responses) == 0)
+			assert.Equal(t, tt.wantCancelled, client.cancelled)
+			client.mu.Unlock()
+			assert.Equal(t, 0, tt.limiter.InFlight())
+			if tt.bulkheads != nil {
+				assert.Equal(t, 0, tt.bulkheads.Limiter("users").InFlight())
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package ddbretry
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Incident describes a run of throttling errors with no gap longer than an
+// IncidentRecorder's quiet period.
+type Incident struct {
+	Start     time.Time
+	End       time.Time
+	Duration  time.Duration
+	Tables    []string
+	Throttles int
+	Retries   int
+}
+
+// IncidentRecorder groups throttling errors into incidents, calling onClose
+// with each incident once no throttling has been seen for the quiet period.
+type IncidentRecorder struct {
+	mu sync.Mutex
+	clockSource
+	quiet   time.Duration
+	onClose func(Incident)
+
+	open   *Incident
+	tables map[string]bool
+	timer  *time.Timer
+}
+
+func NewIncidentRecorder(quiet time.Duration, onClose func(Incident)) *IncidentRecorder {
+	return &IncidentRecorder{
+		quiet:   quiet,
+		onClose: onClose,
+	}
+}
+
+// Flush closes the open incident, if any, without waiting for the quiet
+// period to pass.
+func (r *IncidentRecorder) Flush() {
+	r.mu.Lock()
+	incident, ok := r.close()
+	r.mu.Unlock()
+
+	if ok {
+		r.onClose(incident)
+	}
+}
+
+// throttle records a throttling error for table, opening an incident if none
+// is open.
+func (r *IncidentRecorder) throttle(table string, clock Clock) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.use(clock)
+	now := r.now()
+	if r.open == nil {
+		r.open = &Incident{Start: now}
+		r.tables = map[string]bool{}
+	}
+	r.open.End = now
+	r.open.Throttles++
+	if table != "" {
+		r.tables[table] = true
+	}
+
+	if r.timer == nil {
+		open := r.open
+		r.timer = time.AfterFunc(r.quiet, func() {
+			r.expire(open)
+		})
+	} else {
+		r.timer.Reset(r.quiet)
+	}
+}
+
+// expire closes incident once its quiet period has passed, unless it has
+// already been closed.
+func (r *IncidentRecorder) expire(incident *Incident) {
+	r.mu.Lock()
+	if r.open != incident {
+		r.mu.Unlock()
+		return
+	}
+	closed, _ := r.close()
+	r.mu.Unlock()
+
+	r.onClose(closed)
+}
+
+// retry counts a retry against the open incident, if any.
+func (r *IncidentRecorder) retry() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.open != nil {
+		r.open.Retries++
+	}
+}
+
+// close ends the open incident, reporting false if there was none.
+func (r *IncidentRecorder) close() (Incident, bool) {
+	if r.open == nil {
+		return Incident{}, false
+	}
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+
+	incident := *r.open
+	incident.Duration = incident.End.Sub(incident.Start)
+	for table := range r.tables {
+		incident.Tables = append(incident.Tables, table)
+	}
+	sort.Strings(incident.Tables)
+	r.open = nil
+	r.tables = nil
+
+	return incident, true
+}
@@ -0,0 +1,74 @@
+package ddbretry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry/ddbretrytest"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIncidentRecorder(t *testing.T) {
+	clock := ddbretrytest.NewFakeClock(time.Unix(1700000000, 0))
+	var incidents []Incident
+	r := NewIncidentRecorder(time.Hour, func(incident Incident) {
+		incidents = append(incidents, incident)
+	})
+
+	r.Flush()
+	assert.Empty(t, incidents)
+
+	r.throttle("users", clock)
+	r.retry()
+	clock.Advance(time.Second)
+	r.throttle("orders", clock)
+	r.throttle("users", clock)
+	r.retry()
+	r.Flush()
+
+	r.retry()
+	r.throttle("", clock)
+	r.Flush()
+
+	assert.Equal(t, []Incident{
+		{
+			Start:     time.Unix(1700000000, 0),
+			End:       time.Unix(1700000001, 0),
+			Duration:  time.Second,
+			Tables:    []string{"orders", "users"},
+			Throttles: 3,
+			Retries:   2,
+		},
+		{
+			Start:     time.Unix(1700000001, 0),
+			End:       time.Unix(1700000001, 0),
+			Throttles: 1,
+		},
+	}, incidents)
+}
+
+func TestRetryDynamoDBClient_Incidents(t *testing.T) {
+	closed := make(chan Incident, 1)
+	c := &RetryDynamoDBClient{
+		DynamoDBClient: ddbretrytest.NewFakeClient().Script("GetItem", ddbretrytest.Throttles(2)...),
+		Retries:        2,
+		Incidents: NewIncidentRecorder(10*time.Millisecond, func(incident Incident) {
+			closed <- incident
+		}),
+	}
+
+	_, err := c.GetItem(context.Background(), &ddb.GetItemInput{TableName: aws.String("users")})
+	assert.NoError(t, err)
+
+	select {
+	case incident := <-closed:
+		assert.Equal(t, []string{"users"}, incident.Tables)
+		assert.Equal(t, 2, incident.Throttles)
+		assert.Equal(t, 2, incident.Retries)
+	case <-time.After(time.Second):
+		t.Fatal("incident was not closed after the quiet period")
+	}
+}
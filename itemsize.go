@@ -0,0 +1,183 @@
+package ddbretry
+
+import (
+	"encoding/base64"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// MaxItemSize is the largest item, in bytes, DynamoDB stores.
+const MaxItemSize = 400 * 1024
+
+// ItemSize returns the size of item as DynamoDB counts it against
+// MaxItemSize: the UTF-8 length of each attribute name plus the size of its
+// value, with numbers taking about one byte per two significant digits and
+// lists and maps three bytes plus one per element.
+func ItemSize(item map[string]types.AttributeValue) int {
+	size := 0
+	for name, value := range item {
+		size += len(name) + attributeSize(value)
+	}
+
+	return size
+}
+
+func attributeSize(value types.AttributeValue) int {
+	switch v := value.(type) {
+	case *types.AttributeValueMemberS:
+		return len(v.Value)
+	case *types.AttributeValueMemberN:
+		return numberSize(v.Value)
+	case *types.AttributeValueMemberB:
+		return len(v.Value)
+	case *types.AttributeValueMemberBOOL, *types.AttributeValueMemberNULL:
+		return 1
+	case *types.AttributeValueMemberSS:
+		size := 0
+		for _, s := range v.Value {
+			size += len(s)
+		}
+		return size
+	case *types.AttributeValueMemberNS:
+		size := 0
+		for _, n := range v.Value {
+			size += numberSize(n)
+		}
+		return size
+	case *types.AttributeValueMemberBS:
+		size := 0
+		for _, b := range v.Value {
+			size += len(b)
+		}
+		return size
+	case *types.AttributeValueMemberL:
+		size := 3
+		for _, element := range v.Value {
+			size += 1 + attributeSize(element)
+		}
+		return size
+	case *types.AttributeValueMemberM:
+		size := 3
+		for name, element := range v.Value {
+			size += 1 + len(name) + attributeSize(element)
+		}
+		return size
+	default:
+		return 0
+	}
+}
+
+// numberSize returns the size of a number: one byte per two significant
+// digits, rounded up, plus one.
+func numberSize(n string) int {
+	if i := strings.IndexAny(n, "eE"); i >= 0 {
+		n = n[:i]
+	}
+	n = strings.TrimLeft(n, "+-")
+	if strings.Contains(n, ".") {
+		n = strings.TrimRight(n, "0")
+	}
+	n = strings.Replace(n, ".", "", 1)
+	n = strings.Trim(n, "0")
+	if n == "" {
+		return 1
+	}
+
+	return (len(n)+1)/2 + 1
+}
+
+// validateItemSize returns an ItemTooLargeError if item exceeds MaxItemSize.
+func validateItemSize(table string, item map[string]types.AttributeValue) error {
+	if size := ItemSize(item); size > MaxItemSize {
+		return NewItemTooLargeError(table, size)
+	}
+
+	return nil
+}
+
+// validateWriteRequests returns an ItemTooLargeError for the first put
+// request in requests whose item exceeds MaxItemSize.
+func validateWriteRequests(requests map[string][]types.WriteRequest) error {
+	for table, writes := range requests {
+		for _, w := range writes {
+			if w.PutRequest == nil {
+				continue
+			}
+			if err := validateItemSize(table, w.PutRequest.Item); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// MaxBatchWritePayload is the largest request payload, in bytes, DynamoDB
+// accepts for a BatchWriteItem call.
+const MaxBatchWritePayload = 16 * 1024 * 1024
+
+// writeRequestPayload estimates the bytes request adds to the JSON payload
+// of a BatchWriteItem call, ignoring escaping.
+func writeRequestPayload(request types.WriteRequest) int {
+	switch {
+	case request.PutRequest != nil:
+		return len(`{"PutRequest":{"Item":}},`) + itemPayload(request.PutRequest.Item)
+	case request.DeleteRequest != nil:
+		return len(`{"DeleteRequest":{"Key":}},`) + itemPayload(request.DeleteRequest.Key)
+	default:
+		return 0
+	}
+}
+
+func itemPayload(item map[string]types.AttributeValue) int {
+	size := len(`{}`)
+	for name, value := range item {
+		size += len(`"":,`) + len(name) + attributePayload(value)
+	}
+
+	return size
+}
+
+func attributePayload(value types.AttributeValue) int {
+	switch v := value.(type) {
+	case *types.AttributeValueMemberS:
+		return len(`{"S":""}`) + len(v.Value)
+	case *types.AttributeValueMemberN:
+		return len(`{"N":""}`) + len(v.Value)
+	case *types.AttributeValueMemberB:
+		return len(`{"B":""}`) + base64.StdEncoding.EncodedLen(len(v.Value))
+	case *types.AttributeValueMemberBOOL:
+		return len(`{"BOOL":false}`)
+	case *types.AttributeValueMemberNULL:
+		return len(`{"NULL":true}`)
+	case *types.AttributeValueMemberSS:
+		size := len(`{"SS":[]}`)
+		for _, s := range v.Value {
+			size += len(`"",`) + len(s)
+		}
+		return size
+	case *types.AttributeValueMemberNS:
+		size := len(`{"NS":[]}`)
+		for _, n := range v.Value {
+			size += len(`"",`) + len(n)
+		}
+		return size
+	case *types.AttributeValueMemberBS:
+		size := len(`{"BS":[]}`)
+		for _, b := range v.Value {
+			size += len(`"",`) + base64.StdEncoding.EncodedLen(len(b))
+		}
+		return size
+	case *types.AttributeValueMemberL:
+		size := len(`{"L":[]}`)
+		for _, element := range v.Value {
+			size += len(`,`) + attributePayload(element)
+		}
+		return size
+	case *types.AttributeValueMemberM:
+		return len(`{"M":}`) + itemPayload(v.Value)
+	default:
+		return 0
+	}
+}
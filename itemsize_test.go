@@ -0,0 +1,92 @@
+package ddbretry
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Thumbscrew/ddbretry/ddbretrytest"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestItemSize(t *testing.T) {
+	tests := []struct {
+		name string
+		item map[string]types.AttributeValue
+		want int
+	}{
+		{
+			name: "should count names and strings",
+			item: map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: "abc"}},
+			want: 5,
+		},
+		{
+			name: "should count numbers by significant digits",
+			item: map[string]types.AttributeValue{
+				"a": &types.AttributeValueMemberN{Value: "12345"},
+				"b": &types.AttributeValueMemberN{Value: "-0.00100"},
+				"c": &types.AttributeValueMemberN{Value: "0"},
+			},
+			want: 1 + 4 + 1 + 2 + 1 + 1,
+		},
+		{
+			name: "should count booleans, nulls and binaries",
+			item: map[string]types.AttributeValue{
+				"ok":   &types.AttributeValueMemberBOOL{Value: true},
+				"none": &types.AttributeValueMemberNULL{Value: true},
+				"data": &types.AttributeValueMemberB{Value: []byte{1, 2, 3}},
+			},
+			want: 2 + 1 + 4 + 1 + 4 + 3,
+		},
+		{
+			name: "should count lists and maps with their overhead",
+			item: map[string]types.AttributeValue{
+				"l": &types.AttributeValueMemberL{Value: []types.AttributeValue{
+					&types.AttributeValueMemberS{Value: "ab"},
+				}},
+				"m": &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+					"k": &types.AttributeValueMemberS{Value: "v"},
+				}},
+				"ss": &types.AttributeValueMemberSS{Value: []string{"a", "bc"}},
+			},
+			want: 1 + 3 + 1 + 2 + 1 + 3 + 1 + 1 + 1 + 2 + 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ItemSize(tt.item))
+		})
+	}
+}
+
+func TestRetryDynamoDBClient_ValidateItemSize(t *testing.T) {
+	large := map[string]types.AttributeValue{
+		"data": &types.AttributeValueMemberS{Value: strings.Repeat("x", MaxItemSize)},
+	}
+	fake := ddbretrytest.NewFakeClient()
+	c := &RetryDynamoDBClient{
+		DynamoDBClient:   fake,
+		ValidateItemSize: true,
+	}
+	ctx := context.Background()
+
+	_, err := c.PutItem(ctx, &ddb.PutItemInput{TableName: aws.String("users"), Item: large})
+	assert.True(t, IsItemTooLargeError(err))
+	assert.EqualError(t, err, `item of 409604 bytes for table "users" exceeds the DynamoDB item size limit of 409600 bytes`)
+
+	_, err = c.BatchWriteItem(ctx, &ddb.BatchWriteItemInput{RequestItems: map[string][]types.WriteRequest{
+		"users": {{PutRequest: &types.PutRequest{Item: large}}},
+	}})
+	assert.True(t, IsItemTooLargeError(err))
+	assert.Empty(t, fake.Calls())
+
+	_, err = c.PutItem(ctx, &ddb.PutItemInput{TableName: aws.String("users"), Item: testItem("1")})
+	assert.NoError(t, err)
+
+	w := NewBatchWriter(fake, RetryPolicy{}, 0)
+	assert.True(t, IsItemTooLargeError(w.Put(ctx, "users", large)))
+	assert.NoError(t, w.Close(ctx))
+}
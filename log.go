@@ -0,0 +1,81 @@
+package ddbretry
+
+import (
+	"errors"
+	"time"
+
+	"github.com/aws/smithy-go"
+)
+
+// Logger receives structured log records from RetryDynamoDBClient, with args
+// given as alternating keys and values. *slog.Logger satisfies it, and the
+// zaplogger and logruslogger packages adapt zap and logrus loggers to it.
+type Logger interface {
+	Debug(msg string, args ...any)
+	Warn(msg string, args ...any)
+}
+
+// logRetry logs a retry at debug level.
+func (s *retryState) logRetry(attempt int, err error, delay time.Duration) {
+	l := s.client.Logger
+	if l == nil || !s.sampleLog() {
+		return
+	}
+
+	l.Debug("retrying DynamoDB request",
+		"operation", s.op,
+		"table", s.table,
+		"attempt", attempt,
+		"delay", delay,
+		"error_code", errorCode(err),
+		"request_id", RequestID(err),
+		"error", err,
+	)
+}
+
+// logGiveUp logs a call failing for good at warn level.
+func (s *retryState) logGiveUp(err error) {
+	l := s.client.Logger
+	if l == nil {
+		return
+	}
+
+	l.Warn("giving up on DynamoDB request",
+		"operation", s.op,
+		"table", s.table,
+		"attempts", s.sent,
+		"error_code", errorCode(err),
+		"request_ids", RequestIDs(err),
+		"error", err,
+	)
+}
+
+// sampleLog reports whether a per-attempt record may be logged under the
+// client's LogSampler, first logging how many records were suppressed since
+// the last one allowed for the table.
+func (s *retryState) sampleLog() bool {
+	sampler := s.client.LogSampler
+	if sampler == nil {
+		return true
+	}
+
+	ok, suppressed := sampler.allow(s.table, s.clock)
+	if suppressed > 0 {
+		s.client.Logger.Debug("suppressed DynamoDB retry log records",
+			"table", s.table,
+			"suppressed", suppressed,
+		)
+	}
+
+	return ok
+}
+
+// errorCode returns the AWS error code carried by err, if any.
+func errorCode(err error) string {
+	var apiError smithy.APIError
+	if errors.As(err, &apiError) {
+		return apiError.ErrorCode()
+	}
+
+	return ""
+}
@@ -0,0 +1,97 @@
+package ddbretry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry/ddbretrytest"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ Logger = (*slog.Logger)(nil)
+
+func TestRetryDynamoDBClient_Logger(t *testing.T) {
+	var buf bytes.Buffer
+	c := &RetryDynamoDBClient{
+		DynamoDBClient: ddbretrytest.NewFakeClient().Script("GetItem", ddbretrytest.Throttles(5)...),
+		Retries:        1,
+		BackOffTime:    time.Millisecond,
+		Logger:         slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})),
+	}
+
+	_, err := c.GetItem(context.Background(), &ddb.GetItemInput{TableName: aws.String("users")})
+	assert.True(t, IsProvisionedThroughputExceededException(err))
+
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(line), &record))
+		records = append(records, record)
+	}
+	if assert.Len(t, records, 2) {
+		assert.Equal(t, "DEBUG", records[0]["level"])
+		assert.Equal(t, "GetItem", records[0]["operation"])
+		assert.Equal(t, "users", records[0]["table"])
+		assert.Equal(t, float64(1), records[0]["attempt"])
+		assert.Equal(t, "ProvisionedThroughputExceededException", records[0]["error_code"])
+
+		assert.Equal(t, "WARN", records[1]["level"])
+		assert.Equal(t, float64(2), records[1]["attempts"])
+	}
+}
+
+func TestLogSampler(t *testing.T) {
+	clock := ddbretrytest.NewFakeClock(time.Unix(1700000000, 0))
+	s := NewLogSampler(2, time.Minute)
+
+	type result struct {
+		ok         bool
+		suppressed int
+	}
+	var results []result
+	allow := func(table string) {
+		ok, suppressed := s.allow(table, clock)
+		results = append(results, result{ok, suppressed})
+	}
+
+	allow("users")
+	allow("users")
+	allow("users")
+	allow("orders")
+	allow("users")
+	clock.Advance(time.Minute)
+	allow("users")
+	allow("users")
+
+	assert.Equal(t, []result{
+		{true, 0},
+		{true, 0},
+		{false, 0},
+		{true, 0},
+		{false, 0},
+		{true, 2},
+		{true, 0},
+	}, results)
+}
+
+func TestRetryDynamoDBClient_LogSampler(t *testing.T) {
+	var buf bytes.Buffer
+	c := &RetryDynamoDBClient{
+		DynamoDBClient: ddbretrytest.NewFakeClient().Script("GetItem", ddbretrytest.Throttles(3)...),
+		Retries:        3,
+		Logger:         slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})),
+		LogSampler:     NewLogSampler(1, time.Hour),
+	}
+
+	_, err := c.GetItem(context.Background(), &ddb.GetItemInput{TableName: aws.String("users")})
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, strings.Count(buf.String(), "retrying DynamoDB request"))
+}
@@ -0,0 +1,50 @@
+// Package logruslogger adapts a logrus logger to the ddbretry.Logger
+// interface.
+package logruslogger
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// badKey is the field name given to a trailing value without a key, matching
+// log/slog.
+const badKey = "!BADKEY"
+
+type Logger struct {
+	logger logrus.FieldLogger
+}
+
+func New(logger logrus.FieldLogger) *Logger {
+	return &Logger{
+		logger: logger,
+	}
+}
+
+func (l *Logger) Debug(msg string, args ...any) {
+	l.logger.WithFields(fields(args)).Debug(msg)
+}
+
+func (l *Logger) Warn(msg string, args ...any) {
+	l.logger.WithFields(fields(args)).Warn(msg)
+}
+
+// fields converts alternating keys and values into logrus fields.
+func fields(args []any) logrus.Fields {
+	f := make(logrus.Fields, len(args)/2)
+	for i := 0; i < len(args); i += 2 {
+		if i+1 == len(args) {
+			f[badKey] = args[i]
+			break
+		}
+
+		key, ok := args[i].(string)
+		if !ok {
+			key = fmt.Sprint(args[i])
+		}
+		f[key] = args[i+1]
+	}
+
+	return f
+}
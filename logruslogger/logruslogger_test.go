@@ -0,0 +1,31 @@
+package logruslogger
+
+import (
+	"testing"
+
+	"github.com/Thumbscrew/ddbretry"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ ddbretry.Logger = (*Logger)(nil)
+
+func TestLogger(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	logger.SetLevel(logrus.DebugLevel)
+	l := New(logger)
+
+	l.Debug("retrying", "operation", "GetItem", "attempt", 1)
+	l.Warn("giving up", "operation", "PutItem", "dangling")
+
+	entries := hook.AllEntries()
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, logrus.DebugLevel, entries[0].Level)
+		assert.Equal(t, "retrying", entries[0].Message)
+		assert.Equal(t, logrus.Fields{"operation": "GetItem", "attempt": 1}, entries[0].Data)
+
+		assert.Equal(t, logrus.WarnLevel, entries[1].Level)
+		assert.Equal(t, logrus.Fields{"operation": "PutItem", badKey: "dangling"}, entries[1].Data)
+	}
+}
@@ -0,0 +1,29 @@
+package ddbretry
+
+import (
+	"time"
+)
+
+// MetricsRecorder receives measurements from the retry loop, so that retry
+// behaviour can be reported to any metrics system. Implementations must be
+// safe for concurrent use.
+type MetricsRecorder interface {
+	// RecordAttempt is called after every attempt with the time it took and
+	// the error it returned, if any.
+	RecordAttempt(op string, table string, latency time.Duration, err error)
+	// RecordRetry is called before each retry with the delay about to be
+	// applied.
+	RecordRetry(op string, table string, attempt int, delay time.Duration)
+	// RecordThrottle is called for every attempt rejected by throttling.
+	RecordThrottle(op string, table string)
+	// RecordSuccess is called once a call succeeds.
+	RecordSuccess(op string, table string, attempts int)
+	// RecordFailure is called once a call fails for good.
+	RecordFailure(op string, table string, attempts int, err error)
+}
+
+// ShadowMetricsRecorder is implemented by MetricsRecorders that also record
+// the retries a client in Shadow mode would have made.
+type ShadowMetricsRecorder interface {
+	RecordShadowRetry(op string, table string, attempt int, delay time.Duration)
+}
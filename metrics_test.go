@@ -0,0 +1,97 @@
+package ddbretry
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry/ddbretrytest"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingMetricsRecorder records every call as a string.
+type recordingMetricsRecorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *recordingMetricsRecorder) record(format string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls = append(r.calls, fmt.Sprintf(format, args...))
+}
+
+func (r *recordingMetricsRecorder) RecordAttempt(op string, table string, latency time.Duration, err error) {
+	r.record("attempt %s %s %t", op, table, err == nil)
+}
+
+func (r *recordingMetricsRecorder) RecordRetry(op string, table string, attempt int, delay time.Duration) {
+	r.record("retry %s %s %d", op, table, attempt)
+}
+
+func (r *recordingMetricsRecorder) RecordThrottle(op string, table string) {
+	r.record("throttle %s %s", op, table)
+}
+
+func (r *recordingMetricsRecorder) RecordSuccess(op string, table string, attempts int) {
+	r.record("success %s %s %d", op, table, attempts)
+}
+
+func (r *recordingMetricsRecorder) RecordFailure(op string, table string, attempts int, err error) {
+	r.record("failure %s %s %d", op, table, attempts)
+}
+
+func (r *recordingMetricsRecorder) RecordShadowRetry(op string, table string, attempt int, delay time.Duration) {
+	r.record("shadow retry %s %s %d %s", op, table, attempt, delay)
+}
+
+func TestRetryDynamoDBClient_Metrics(t *testing.T) {
+	tests := []struct {
+		name      string
+		throttles int
+		want      []string
+	}{
+		{
+			name:      "should record attempts, throttles, retries and success",
+			throttles: 1,
+			want: []string{
+				"attempt DeleteItem users false",
+				"throttle DeleteItem users",
+				"retry DeleteItem users 1",
+				"attempt DeleteItem users true",
+				"success DeleteItem users 2",
+			},
+		},
+		{
+			name:      "should record failure once retries are exhausted",
+			throttles: 5,
+			want: []string{
+				"attempt DeleteItem users false",
+				"throttle DeleteItem users",
+				"retry DeleteItem users 1",
+				"attempt DeleteItem users false",
+				"throttle DeleteItem users",
+				"failure DeleteItem users 2",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := &recordingMetricsRecorder{}
+			c := &RetryDynamoDBClient{
+				DynamoDBClient: ddbretrytest.NewFakeClient().Script("DeleteItem", ddbretrytest.Throttles(tt.throttles)...),
+				Retries:        1,
+				BackOffTime:    time.Millisecond,
+				Metrics:        metrics,
+			}
+
+			_, _ = c.DeleteItem(context.Background(), &ddb.DeleteItemInput{TableName: aws.String("users")})
+			assert.Equal(t, tt.want, metrics.calls)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package ddbretry
+
+import (
+	"context"
+
+	"github.com/aws/smithy-go/middleware"
+)
+
+// MiddlewareID identifies the retry middleware in a smithy-go middleware
+// stack.
+const MiddlewareID = "ddbretry.Retry"
+
+// Middleware returns an initialize-step middleware that applies the client's
+// retry settings to every operation sent through the stack it is added to.
+// Each retry re-runs the rest of the stack, so requests are rebuilt and
+// re-signed on every attempt. The embedded DynamoDBClient is not used.
+//
+// Per-call options such as WithCallRetries are only seen by the wrapping
+// methods, and GetItem is not hedged when the middleware is used.
+func (c *RetryDynamoDBClient) Middleware() middleware.InitializeMiddleware {
+	return middleware.InitializeMiddlewareFunc(MiddlewareID, c.handleInitialize)
+}
+
+// AddMiddleware appends the retry middleware to stack. It can be added to a
+// DynamoDB client's APIOptions, usually alongside disabling the SDK's own
+// retryer:
+//
+//	client := ddb.NewFromConfig(cfg, func(o *ddb.Options) {
+//		o.Retryer = aws.NopRetryer{}
+//		o.APIOptions = append(o.APIOptions, retrier.AddMiddleware)
+//	})
+func (c *RetryDynamoDBClient) AddMiddleware(stack *middleware.Stack) error {
+	return stack.Initialize.Add(c.Middleware(), middleware.After)
+}
+
+func (c *RetryDynamoDBClient) handleInitialize(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (output middleware.InitializeOutput, metadata middleware.Metadata, err error) {
+	s := c.newRetryState(ctx, middleware.GetOperationName(ctx), in.Parameters, nil)
+	if s.invalid != nil {
+		return output, metadata, s.invalid
+	}
+	for s.valid() {
+		if err = s.beforeAttempt(ctx); err != nil {
+			return
+		}
+		attemptCtx, cancel := s.attemptContext(ctx)
+		output, metadata, err = next.HandleInitialize(attemptCtx, in)
+		cancel()
+		s.afterAttempt(output.Result, err)
+		if err == nil {
+			return
+		}
+		if err = s.wait(ctx, err); err != nil {
+			return
+		}
+	}
+
+	return output, metadata, NewInvalidRetryError(s.policy.Retries)
+}
@@ -0,0 +1,100 @@
+package ddbretry
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+// throttlingHTTPClient answers the first Throttles requests with a
+// ProvisionedThroughputExceededException and every later one with an empty
+// item.
+type throttlingHTTPClient struct {
+	mu        sync.Mutex
+	Throttles int
+	Requests  int
+}
+
+func (h *throttlingHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.Requests++
+	if h.Requests <= h.Throttles {
+		return newHTTPResponse(req, http.StatusBadRequest, `{"__type":"com.amazonaws.dynamodb.v20120810#ProvisionedThroughputExceededException","message":"throttled"}`), nil
+	}
+
+	return newHTTPResponse(req, http.StatusOK, `{}`), nil
+}
+
+func newHTTPResponse(req *http.Request, status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"application/x-amz-json-1.0"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}
+}
+
+func newMiddlewareTestClient(httpClient *throttlingHTTPClient, c *RetryDynamoDBClient) *ddb.Client {
+	return ddb.New(ddb.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String("http://localhost:8000"),
+		Credentials:  aws.AnonymousCredentials{},
+		HTTPClient:   httpClient,
+		Retryer:      aws.NopRetryer{},
+		APIOptions:   []func(*middleware.Stack) error{c.AddMiddleware},
+	})
+}
+
+func TestRetryDynamoDBClient_Middleware(t *testing.T) {
+	tests := []struct {
+		name         string
+		throttles    int
+		retries      int
+		wantErr      bool
+		wantRequests int
+	}{
+		{
+			name:         "should retry throttled requests until they succeed",
+			throttles:    2,
+			retries:      3,
+			wantRequests: 3,
+		},
+		{
+			name:         "should return error once retries are exhausted",
+			throttles:    5,
+			retries:      2,
+			wantErr:      true,
+			wantRequests: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpClient := &throttlingHTTPClient{Throttles: tt.throttles}
+			client := newMiddlewareTestClient(httpClient, &RetryDynamoDBClient{Retries: tt.retries})
+
+			_, err := client.GetItem(context.Background(), &ddb.GetItemInput{
+				TableName: aws.String("table"),
+				Key: map[string]types.AttributeValue{
+					"id": &types.AttributeValueMemberS{Value: "1"},
+				},
+			})
+			if tt.wantErr {
+				assert.True(t, IsProvisionedThroughputExceededException(err))
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantRequests, httpClient.Requests)
+		})
+	}
+}
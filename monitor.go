@@ -0,0 +1,110 @@
+package ddbretry
+
+import (
+	"sync"
+	"time"
+)
+
+// throttleMonitorBuckets is the number of buckets a ThrottleMonitor divides
+// its window into.
+const throttleMonitorBuckets = 10
+
+// ThrottleMonitor tracks the share of attempts rejected by throttling over a
+// sliding window, as a cheap signal that DynamoDB is under pressure and
+// optional work should be shed.
+type ThrottleMonitor struct {
+	mu sync.Mutex
+	clockSource
+	width     time.Duration
+	threshold float64
+
+	buckets [throttleMonitorBuckets]throttleMonitorBucket
+}
+
+type throttleMonitorBucket struct {
+	start     time.Time
+	attempts  int
+	throttles int
+}
+
+// NewThrottleMonitor creates a ThrottleMonitor over the given window that
+// reports the client as throttled once the throttle rate reaches threshold,
+// between 0 and 1.
+func NewThrottleMonitor(window time.Duration, threshold float64) *ThrottleMonitor {
+	width := window / throttleMonitorBuckets
+	if width <= 0 {
+		width = 1
+	}
+
+	return &ThrottleMonitor{
+		width:     width,
+		threshold: threshold,
+	}
+}
+
+// ThrottleRate returns the share of attempts within the window that were
+// throttled, or 0 if there were none.
+func (m *ThrottleMonitor) ThrottleRate() float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	oldest := m.now().Add(-m.width * throttleMonitorBuckets)
+	attempts, throttles := 0, 0
+	for _, b := range m.buckets {
+		if b.start.After(oldest) {
+			attempts += b.attempts
+			throttles += b.throttles
+		}
+	}
+	if attempts == 0 {
+		return 0
+	}
+
+	return float64(throttles) / float64(attempts)
+}
+
+// IsThrottled reports whether the throttle rate has reached the threshold.
+func (m *ThrottleMonitor) IsThrottled() bool {
+	rate := m.ThrottleRate()
+
+	return rate > 0 && rate >= m.threshold
+}
+
+// record adds the outcome of an attempt to the current bucket.
+func (m *ThrottleMonitor) record(throttled bool, clock Clock) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.use(clock)
+	now := m.now()
+	start := now.Truncate(m.width)
+	b := &m.buckets[(start.UnixNano()/int64(m.width))%throttleMonitorBuckets]
+	if !b.start.Equal(start) {
+		*b = throttleMonitorBucket{start: start}
+	}
+
+	b.attempts++
+	if throttled {
+		b.throttles++
+	}
+}
+
+// IsThrottled reports whether the client's ThrottleMonitor considers
+// DynamoDB to be throttling it, and is always false without one.
+func (c *RetryDynamoDBClient) IsThrottled() bool {
+	if m := c.ThrottleMonitor; m != nil {
+		return m.IsThrottled()
+	}
+
+	return false
+}
+
+// ThrottleRate returns the throttle rate measured by the client's
+// ThrottleMonitor, or 0 without one.
+func (c *RetryDynamoDBClient) ThrottleRate() float64 {
+	if m := c.ThrottleMonitor; m != nil {
+		return m.ThrottleRate()
+	}
+
+	return 0
+}
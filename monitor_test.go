@@ -0,0 +1,53 @@
+package ddbretry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry/ddbretrytest"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThrottleMonitor(t *testing.T) {
+	clock := ddbretrytest.NewFakeClock(time.Unix(1700000000, 0))
+	m := NewThrottleMonitor(10*time.Second, 0.5)
+
+	assert.Equal(t, 0.0, m.ThrottleRate())
+	assert.False(t, m.IsThrottled())
+
+	m.record(true, clock)
+	m.record(false, clock)
+	clock.Advance(3 * time.Second)
+	m.record(true, clock)
+	m.record(true, clock)
+	assert.Equal(t, 0.75, m.ThrottleRate())
+	assert.True(t, m.IsThrottled())
+
+	clock.Advance(8 * time.Second)
+	assert.Equal(t, 1.0, m.ThrottleRate())
+
+	m.record(false, clock)
+	m.record(false, clock)
+	assert.Equal(t, 0.5, m.ThrottleRate())
+
+	clock.Advance(time.Minute)
+	assert.Equal(t, 0.0, m.ThrottleRate())
+	assert.False(t, m.IsThrottled())
+}
+
+func TestRetryDynamoDBClient_IsThrottled(t *testing.T) {
+	c := &RetryDynamoDBClient{
+		DynamoDBClient: ddbretrytest.NewFakeClient().Script("GetItem", ddbretrytest.Throttles(3)...),
+		Retries:        3,
+	}
+	assert.False(t, c.IsThrottled())
+	assert.Equal(t, 0.0, c.ThrottleRate())
+
+	c.ThrottleMonitor = NewThrottleMonitor(time.Minute, 0.5)
+	_, err := c.GetItem(context.Background(), &ddb.GetItemInput{})
+	assert.NoError(t, err)
+	assert.Equal(t, 0.75, c.ThrottleRate())
+	assert.True(t, c.IsThrottled())
+}
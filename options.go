@@ -0,0 +1,267 @@
+package ddbretry
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/smithy-go/logging"
+)
+
+// Option configures a RetryDynamoDBClient built by NewFromAWSConfig or
+// derived by With, returning an error if the setting it applies is invalid.
+type Option func(*RetryDynamoDBClient) error
+
+// WithRetries sets Retries, returning an InvalidRetryError if retries is
+// negative other than InfiniteRetries. Passing zero retries disables
+// retrying.
+func WithRetries(retries int) Option {
+	return func(c *RetryDynamoDBClient) error {
+		if retries < 0 && retries != InfiniteRetries {
+			return NewInvalidRetryError(retries)
+		}
+		c.Retries = retries
+		c.MaxAttempts = 0
+		c.DisableRetries = retries == 0
+		c.Infinite = retries == InfiniteRetries
+
+		return nil
+	}
+}
+
+// WithMaxAttempts sets MaxAttempts in place of Retries, returning an
+// InvalidConfigError if maxAttempts is less than one.
+func WithMaxAttempts(maxAttempts int) Option {
+	return func(c *RetryDynamoDBClient) error {
+		if maxAttempts < 1 {
+			return NewInvalidConfigError("MaxAttempts", "must be at least 1")
+		}
+		c.MaxAttempts = maxAttempts
+		c.Retries = 0
+		c.DisableRetries = false
+		c.Infinite = false
+
+		return nil
+	}
+}
+
+// WithInfiniteRetries makes the client retry indefinitely.
+func WithInfiniteRetries() Option {
+	return func(c *RetryDynamoDBClient) error {
+		c.Infinite = true
+		c.MaxAttempts = 0
+		c.DisableRetries = false
+
+		return nil
+	}
+}
+
+// WithBackOff sets BackOffTime, returning an InvalidBackOffError if backOff
+// is negative.
+func WithBackOff(backOff time.Duration) Option {
+	return func(c *RetryDynamoDBClient) error {
+		if backOff < 0 {
+			return NewInvalidBackOffError(backOff)
+		}
+		c.BackOffTime = backOff
+
+		return nil
+	}
+}
+
+// WithMaxBackOff sets MaxBackOff, returning an InvalidConfigError if
+// maxBackOff is negative.
+func WithMaxBackOff(maxBackOff time.Duration) Option {
+	return func(c *RetryDynamoDBClient) error {
+		if maxBackOff < 0 {
+			return NewInvalidConfigError("MaxBackOff", "must not be negative")
+		}
+		c.MaxBackOff = maxBackOff
+
+		return nil
+	}
+}
+
+func WithBackOffStrategy(strategy BackOffStrategy) Option {
+	return func(c *RetryDynamoDBClient) error {
+		c.BackOffStrategy = strategy
+
+		return nil
+	}
+}
+
+// WithConfig applies every setting in cfg, returning the errors reported by
+// its Validate method if it is invalid.
+func WithConfig(cfg Config) Option {
+	return func(c *RetryDynamoDBClient) error {
+		if err := cfg.Validate(); err != nil {
+			return err
+		}
+		cfg.apply(c)
+
+		return nil
+	}
+}
+
+// callSettings holds the overrides requested for a single call.
+type callSettings struct {
+	retries    *int
+	backOff    *time.Duration
+	priority   *Priority
+	idempotent bool
+}
+
+// callProbe stands in for the SDK logger while extracting callSettings from a
+// call's option functions. Call options only act when they find a probe, so
+// they are no-ops once they reach the SDK.
+type callProbe struct {
+	settings callSettings
+}
+
+func (p *callProbe) Logf(logging.Classification, string, ...interface{}) {}
+
+func callOption(apply func(*callSettings)) func(*ddb.Options) {
+	return func(o *ddb.Options) {
+		if p, ok := o.Logger.(*callProbe); ok {
+			apply(&p.settings)
+		}
+	}
+}
+
+var callOptionPointer = reflect.ValueOf(callOption(nil)).Pointer()
+
+// WithCallRetries overrides the number of retries for a single call. It is
+// passed alongside the SDK's own option functions:
+//
+//	client.GetItem(ctx, input, ddbretry.WithCallRetries(10))
+//
+// A call given a negative number of retries other than InfiniteRetries fails
+// with an InvalidRetryError before any attempt is made.
+func WithCallRetries(retries int) func(*ddb.Options) {
+	return callOption(func(s *callSettings) {
+		s.retries = &retries
+	})
+}
+
+// WithCallBackOff overrides BackOffTime for a single call. A call given a
+// negative backOff fails with an InvalidBackOffError before any attempt is
+// made.
+func WithCallBackOff(backOff time.Duration) func(*ddb.Options) {
+	return callOption(func(s *callSettings) {
+		s.backOff = &backOff
+	})
+}
+
+// WithIdempotent marks a single write as safe to repeat, allowing it to be
+// retried after ambiguous failures when RequireIdempotentWrites is set.
+func WithIdempotent() func(*ddb.Options) {
+	return callOption(func(s *callSettings) {
+		s.idempotent = true
+	})
+}
+
+// WithCallPriority sets the priority of a single call.
+func WithCallPriority(p Priority) func(*ddb.Options) {
+	return callOption(func(s *callSettings) {
+		s.priority = &p
+	})
+}
+
+// callSettingsFrom collects the overrides set by call options among o. Other
+// option functions are left untouched.
+func callSettingsFrom(o []func(*ddb.Options)) callSettings {
+	probe := &callProbe{}
+	options := ddb.Options{
+		Logger: probe,
+	}
+	for _, fn := range o {
+		if fn != nil && reflect.ValueOf(fn).Pointer() == callOptionPointer {
+			fn(&options)
+		}
+	}
+
+	return probe.settings
+}
+
+// merge returns s with the overrides set in t taking precedence.
+func (s callSettings) merge(t callSettings) callSettings {
+	if t.retries != nil {
+		s.retries = t.retries
+	}
+	if t.backOff != nil {
+		s.backOff = t.backOff
+	}
+	if t.priority != nil {
+		s.priority = t.priority
+	}
+	s.idempotent = s.idempotent || t.idempotent
+
+	return s
+}
+
+type contextKey struct{}
+
+// ContextWithRetries returns a copy of ctx that overrides the number of
+// retries for every call made with it, so that code higher up the stack can
+// tune calls it does not make itself. Call options take precedence over
+// overrides set on the context. Calls made with a negative number of retries
+// other than InfiniteRetries fail with an InvalidRetryError before any
+// attempt is made.
+func ContextWithRetries(ctx context.Context, retries int) context.Context {
+	s := contextSettings(ctx)
+	s.retries = &retries
+
+	return context.WithValue(ctx, contextKey{}, s)
+}
+
+// ContextWithBackOff returns a copy of ctx that overrides BackOffTime for
+// every call made with it. Calls made with a negative backOff fail with an
+// InvalidBackOffError before any attempt is made.
+func ContextWithBackOff(ctx context.Context, backOff time.Duration) context.Context {
+	s := contextSettings(ctx)
+	s.backOff = &backOff
+
+	return context.WithValue(ctx, contextKey{}, s)
+}
+
+// ContextWithPriority returns a copy of ctx that sets the priority of every
+// call made with it.
+func ContextWithPriority(ctx context.Context, p Priority) context.Context {
+	s := contextSettings(ctx)
+	s.priority = &p
+
+	return context.WithValue(ctx, contextKey{}, s)
+}
+
+// contextSettings returns the overrides set on ctx.
+func contextSettings(ctx context.Context) callSettings {
+	s, _ := ctx.Value(contextKey{}).(callSettings)
+
+	return s
+}
+
+// validate returns an error if the overrides in s are invalid, as Validate
+// does for the matching fields of a Config.
+func (s callSettings) validate() error {
+	if s.retries != nil && *s.retries < 0 && *s.retries != InfiniteRetries {
+		return NewInvalidRetryError(*s.retries)
+	}
+	if s.backOff != nil && *s.backOff < 0 {
+		return NewInvalidBackOffError(*s.backOff)
+	}
+
+	return nil
+}
+
+// apply overrides the matching fields of p.
+func (s callSettings) apply(p RetryPolicy) RetryPolicy {
+	if s.retries != nil {
+		p.Retries = *s.retries
+	}
+	if s.backOff != nil {
+		p.BackOffTime = *s.backOff
+	}
+
+	return p
+}
@@ -0,0 +1,183 @@
+package ddbretry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry/ddbretrytest"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCallSettingsFrom(t *testing.T) {
+	region := ""
+	settings := callSettingsFrom([]func(*ddb.Options){
+		func(o *ddb.Options) {
+			region = "should not be called"
+		},
+		WithCallRetries(10),
+		WithCallBackOff(50 * time.Millisecond),
+		WithIdempotent(),
+		nil,
+	})
+
+	assert.Equal(t, "", region)
+	if assert.NotNil(t, settings.retries) {
+		assert.Equal(t, 10, *settings.retries)
+	}
+	if assert.NotNil(t, settings.backOff) {
+		assert.Equal(t, 50*time.Millisecond, *settings.backOff)
+	}
+	assert.True(t, settings.idempotent)
+}
+
+func TestCallOptions_noopForSDK(t *testing.T) {
+	options := ddb.Options{
+		Region: "eu-west-1",
+	}
+	WithCallRetries(10)(&options)
+	WithCallBackOff(time.Second)(&options)
+
+	assert.Equal(t, ddb.Options{Region: "eu-west-1"}, options)
+}
+
+func TestRetryDynamoDBClient_CallOptions(t *testing.T) {
+	c := &RetryDynamoDBClient{
+		DynamoDBClient: ddbretrytest.NewFakeClient().Script("GetItem", ddbretrytest.Throttles(3)...),
+		Retries:        1,
+		BackOffTime:    time.Hour,
+	}
+
+	_, err := c.GetItem(context.Background(), &ddb.GetItemInput{}, WithCallRetries(3), WithCallBackOff(0))
+	assert.NoError(t, err)
+}
+
+func TestRetryDynamoDBClient_ContextOverrides(t *testing.T) {
+	c := &RetryDynamoDBClient{
+		DynamoDBClient: ddbretrytest.NewFakeClient().Script("GetItem", ddbretrytest.Throttles(3)...),
+		Retries:        1,
+		BackOffTime:    time.Hour,
+	}
+	ctx := ContextWithBackOff(ContextWithRetries(context.Background(), 3), 0)
+
+	_, err := c.GetItem(ctx, &ddb.GetItemInput{})
+	assert.NoError(t, err)
+
+	c.DynamoDBClient = ddbretrytest.NewFakeClient().Script("GetItem", ddbretrytest.Throttles(3)...)
+	_, err = c.GetItem(ctx, &ddb.GetItemInput{}, WithCallRetries(1))
+	assert.True(t, IsProvisionedThroughputExceededException(err))
+}
+
+func TestRetryDynamoDBClient_InvalidOverrides(t *testing.T) {
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		o       []func(*ddb.Options)
+		wantErr func(error) bool
+	}{
+		{
+			name:    "should reject negative call retries",
+			ctx:     context.Background(),
+			o:       []func(*ddb.Options){WithCallRetries(-3)},
+			wantErr: IsInvalidRetryError,
+		},
+		{
+			name:    "should reject negative context retries",
+			ctx:     ContextWithRetries(context.Background(), -3),
+			wantErr: IsInvalidRetryError,
+		},
+		{
+			name:    "should reject a negative call backoff",
+			ctx:     context.Background(),
+			o:       []func(*ddb.Options){WithCallBackOff(-time.Second)},
+			wantErr: IsInvalidBackOffError,
+		},
+		{
+			name:    "should reject a negative context backoff",
+			ctx:     ContextWithBackOff(context.Background(), -time.Second),
+			wantErr: IsInvalidBackOffError,
+		},
+		{
+			name: "should accept infinite call retries",
+			ctx:  context.Background(),
+			o:    []func(*ddb.Options){WithCallRetries(InfiniteRetries)},
+		},
+		{
+			name: "should let a valid call option replace an invalid context override",
+			ctx:  ContextWithRetries(context.Background(), -3),
+			o:    []func(*ddb.Options){WithCallRetries(1)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := ddbretrytest.NewFakeClient()
+			c := &RetryDynamoDBClient{
+				DynamoDBClient: fake,
+				Retries:        1,
+			}
+
+			_, err := c.GetItem(tt.ctx, &ddb.GetItemInput{}, tt.o...)
+			if tt.wantErr != nil {
+				assert.True(t, tt.wantErr(err))
+				assert.Zero(t, fake.CallCount("GetItem"))
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, 1, fake.CallCount("GetItem"))
+			}
+		})
+	}
+}
+
+func TestRetryDynamoDBClient_RequireIdempotentWrites(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		input   *ddb.PutItemInput
+		o       []func(*ddb.Options)
+		wantErr bool
+	}{
+		{
+			name:    "should not retry unconditional write after ambiguous failure",
+			err:     &types.InternalServerError{},
+			input:   &ddb.PutItemInput{},
+			wantErr: true,
+		},
+		{
+			name: "should retry conditional write after ambiguous failure",
+			err:  &types.InternalServerError{},
+			input: &ddb.PutItemInput{
+				ConditionExpression: aws.String("attribute_not_exists(pk)"),
+			},
+			wantErr: false,
+		},
+		{
+			name:    "should retry write marked idempotent after ambiguous failure",
+			err:     &types.InternalServerError{},
+			input:   &ddb.PutItemInput{},
+			o:       []func(*ddb.Options){WithIdempotent()},
+			wantErr: false,
+		},
+		{
+			name:    "should retry unconditional write after throttling",
+			err:     &types.ProvisionedThroughputExceededException{},
+			input:   &ddb.PutItemInput{},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &RetryDynamoDBClient{
+				DynamoDBClient:             ddbretrytest.NewFakeClient().Script("PutItem", tt.err),
+				Retries:                    1,
+				InternalServerErrorRetries: 1,
+				RequireIdempotentWrites:    true,
+			}
+
+			_, err := c.PutItem(context.Background(), tt.input, tt.o...)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
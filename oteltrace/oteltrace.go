@@ -0,0 +1,120 @@
+// Package oteltrace traces ddbretry calls with OpenTelemetry, creating a span
+// per call with a child span per attempt and an event per retry.
+package oteltrace
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry"
+	"github.com/aws/smithy-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/Thumbscrew/ddbretry/oteltrace"
+
+const (
+	attemptKey   = attribute.Key("ddbretry.attempt")
+	attemptsKey  = attribute.Key("ddbretry.attempts")
+	delayKey     = attribute.Key("ddbretry.delay_ms")
+	errorCodeKey = attribute.Key("aws.error_code")
+	operationKey = attribute.Key("rpc.method")
+	requestIDKey = attribute.Key("aws.request_id")
+	tableKey     = attribute.Key("aws.dynamodb.table_names")
+)
+
+// Tracer is a ddbretry.Tracer reporting to an OpenTelemetry TracerProvider.
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+// New creates a Tracer using provider, or the global TracerProvider if
+// provider is nil.
+func New(provider trace.TracerProvider) *Tracer {
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+
+	return &Tracer{
+		tracer: provider.Tracer(instrumentationName),
+	}
+}
+
+func (t *Tracer) StartSpan(ctx context.Context, op string, table string) (context.Context, ddbretry.Span) {
+	attrs := []attribute.KeyValue{
+		attribute.String("db.system", "dynamodb"),
+		operationKey.String(op),
+	}
+	if table != "" {
+		attrs = append(attrs, tableKey.StringSlice([]string{table}))
+	}
+
+	ctx, span := t.tracer.Start(ctx, "DynamoDB."+op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+
+	return ctx, &Span{
+		tracer: t.tracer,
+		name:   "DynamoDB." + op + " attempt",
+		span:   span,
+	}
+}
+
+type Span struct {
+	tracer trace.Tracer
+	name   string
+	span   trace.Span
+}
+
+func (s *Span) StartAttempt(ctx context.Context, attempt int) (context.Context, func(err error)) {
+	ctx, span := s.tracer.Start(ctx, s.name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attemptKey.Int(attempt)),
+	)
+
+	return ctx, func(err error) {
+		end(span, err)
+	}
+}
+
+func (s *Span) RecordRetry(attempt int, err error, delay time.Duration) {
+	s.span.AddEvent("retry", trace.WithAttributes(
+		attemptKey.Int(attempt),
+		delayKey.Int64(delay.Milliseconds()),
+		errorCodeKey.String(errorCode(err)),
+	))
+}
+
+func (s *Span) End(attempts int, err error) {
+	s.span.SetAttributes(attemptsKey.Int(attempts))
+	end(s.span, err)
+}
+
+// end records err on span, if it is not nil, and ends it.
+func end(span trace.Span, err error) {
+	if err != nil {
+		if code := errorCode(err); code != "" {
+			span.SetAttributes(errorCodeKey.String(code))
+		}
+		if id := ddbretry.RequestID(err); id != "" {
+			span.SetAttributes(requestIDKey.String(id))
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func errorCode(err error) string {
+	var apiError smithy.APIError
+	if errors.As(err, &apiError) {
+		return apiError.ErrorCode()
+	}
+
+	return ""
+}
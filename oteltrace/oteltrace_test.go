@@ -0,0 +1,52 @@
+package oteltrace
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var _ ddbretry.Tracer = (*Tracer)(nil)
+
+func TestTracer(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := New(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	ctx, span := tracer.StartSpan(context.Background(), "GetItem", "users")
+	_, endAttempt := span.StartAttempt(ctx, 1)
+	endAttempt(&types.ProvisionedThroughputExceededException{})
+	span.RecordRetry(1, &types.ProvisionedThroughputExceededException{}, 20*time.Millisecond)
+	_, endAttempt = span.StartAttempt(ctx, 2)
+	endAttempt(nil)
+	span.End(2, nil)
+
+	spans := recorder.Ended()
+	if !assert.Len(t, spans, 3) {
+		return
+	}
+
+	first, second, call := spans[0], spans[1], spans[2]
+	assert.Equal(t, "DynamoDB.GetItem", call.Name())
+	assert.Equal(t, "DynamoDB.GetItem attempt", first.Name())
+	assert.Equal(t, call.SpanContext().SpanID(), first.Parent().SpanID())
+	assert.Equal(t, call.SpanContext().SpanID(), second.Parent().SpanID())
+
+	assert.Equal(t, codes.Error, first.Status().Code)
+	assert.Contains(t, first.Attributes(), errorCodeKey.String("ProvisionedThroughputExceededException"))
+	assert.Equal(t, codes.Unset, second.Status().Code)
+
+	if assert.Len(t, call.Events(), 1) {
+		event := call.Events()[0]
+		assert.Equal(t, "retry", event.Name)
+		assert.Contains(t, event.Attributes, delayKey.Int64(20))
+	}
+	assert.Contains(t, call.Attributes(), attemptsKey.Int(2))
+	assert.Contains(t, call.Attributes(), tableKey.StringSlice([]string{"users"}))
+}
@@ -0,0 +1,219 @@
+package ddbretry
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// RetryPolicy holds the settings that control how a single call is retried.
+// The fields have the same meaning as their counterparts on
+// RetryDynamoDBClient, and a policy's Classifier and Rand, when set, take
+// precedence over the client's. A RetryPolicy holds no state, so one value
+// can be shared by any number of clients, and its decisions can be inspected
+// through ShouldRetry and Delay. Do runs a function under the policy alone,
+// without a client.
+type RetryPolicy struct {
+	Retries         int
+	BackOffTime     time.Duration
+	BackOffStrategy BackOffStrategy
+	MaxBackOff      time.Duration
+	BackOff         BackOff
+	BackOffFunc     func(attempt int) time.Duration
+	InitialBackOff  time.Duration
+	ImmediateRetry  bool
+	MaxElapsedTime  time.Duration
+	AttemptTimeout  time.Duration
+	Classifier      ErrorClassifier
+	Rand            *Rand
+}
+
+// Classify decides how err is retried under the policy, using
+// DefaultErrorClassifier if the policy has no Classifier.
+func (p RetryPolicy) Classify(err error) RetryDecision {
+	if p.Classifier != nil {
+		return p.Classifier.Classify(err)
+	}
+
+	return DefaultErrorClassifier{}.Classify(err)
+}
+
+// ShouldRetry reports whether the policy allows the given retry attempt,
+// where attempt is 1 for the first retry, after a failure with err.
+func (p RetryPolicy) ShouldRetry(attempt int, err error) bool {
+	if p.Classify(err) == DoNotRetry {
+		return false
+	}
+
+	return p.Retries == InfiniteRetries || attempt <= p.Retries
+}
+
+// Delay returns the delay the policy applies before the given retry attempt,
+// where attempt is 1 for the first retry, prev is the delay applied before
+// the previous retry and err is the error that triggered the retry.
+func (p RetryPolicy) Delay(attempt int, prev time.Duration, err error) time.Duration {
+	delay := p.backOff(attempt, prev, err)
+	if p.Classify(err) == RetryWithLongBackOff {
+		delay = saturatingMul(delay, longBackOffMultiplier)
+	}
+
+	return delay
+}
+
+// Do calls fn, retrying it under the policy until it succeeds or the policy
+// gives up, in the same way as DoWithPolicy, for functions that are not
+// DynamoDB calls and have no output.
+func (p RetryPolicy) Do(ctx context.Context, fn func(context.Context) error) error {
+	_, err := DoWithPolicy(ctx, p, func(ctx context.Context, _ struct{}) (struct{}, error) {
+		return struct{}{}, fn(ctx)
+	}, struct{}{})
+
+	return err
+}
+
+var writeOperations = map[string]bool{
+	"BatchWriteItem":     true,
+	"DeleteItem":         true,
+	"ExecuteStatement":   true,
+	"PutItem":            true,
+	"TransactWriteItems": true,
+	"UpdateItem":         true,
+}
+
+const (
+	DefaultRetries     = 3
+	DefaultBackOffTime = 100 * time.Millisecond
+	// InfiniteRetries, as a number of retries, retries until the call
+	// succeeds, fails with an error that is not retried or its context ends.
+	InfiniteRetries = -1
+)
+
+// policy returns the client's own retry settings as a RetryPolicy: those
+// frozen by the constructor, if it was built by one, or else those in its
+// fields.
+func (c *RetryDynamoDBClient) policy() RetryPolicy {
+	if p, ok := c.frozen.Load().(*RetryPolicy); ok {
+		return *p
+	}
+
+	return c.fieldPolicy()
+}
+
+// freeze snapshots the retry settings in c's fields, so that calls no longer
+// read them.
+func (c *RetryDynamoDBClient) freeze() {
+	p := c.fieldPolicy()
+	c.frozen.Store(&p)
+}
+
+// fieldPolicy returns the retry settings in c's fields as a RetryPolicy. A
+// client with neither Retries nor BackOffTime set retries DefaultRetries
+// times, backing off by DefaultBackOffTime, unless DisableRetries, Infinite
+// or MaxAttempts is set.
+func (c *RetryDynamoDBClient) fieldPolicy() RetryPolicy {
+	retries, backOffTime := c.Retries, c.BackOffTime
+	switch {
+	case c.DisableRetries:
+		retries = 0
+	case c.Infinite:
+		retries = InfiniteRetries
+	case c.MaxAttempts > 0:
+		retries = c.MaxAttempts - 1
+	case retries == 0 && backOffTime == 0:
+		retries, backOffTime = DefaultRetries, DefaultBackOffTime
+	}
+
+	return RetryPolicy{
+		Retries:         retries,
+		BackOffTime:     backOffTime,
+		BackOffStrategy: c.BackOffStrategy,
+		MaxBackOff:      c.MaxBackOff,
+		BackOff:         c.BackOff,
+		BackOffFunc:     c.BackOffFunc,
+		InitialBackOff:  c.InitialBackOff,
+		ImmediateRetry:  c.ImmediateRetry,
+		MaxElapsedTime:  c.MaxElapsedTime,
+		AttemptTimeout:  c.AttemptTimeout,
+		Classifier:      c.Classifier,
+		Rand:            c.Rand,
+	}
+}
+
+// policyFor returns the retry policy that applies to the named operation on
+// the given table. Entries in TablePolicies take precedence, followed by
+// OperationPolicies; otherwise ReadPolicy and WritePolicy, when set, replace
+// the client's own settings for read and write operations respectively.
+func (c *RetryDynamoDBClient) policyFor(op string, table string) RetryPolicy {
+	if p, ok := c.TablePolicies[table]; ok && table != "" {
+		return p
+	}
+	if p, ok := c.OperationPolicies[op]; ok {
+		return p
+	}
+
+	if writeOperations[op] {
+		if c.WritePolicy != nil {
+			return *c.WritePolicy
+		}
+	} else if c.ReadPolicy != nil {
+		return *c.ReadPolicy
+	}
+
+	return c.policy()
+}
+
+// tableName returns the name of the table targeted by an operation input.
+// Batch inputs only have a table name when they target a single table.
+func tableName(input interface{}) string {
+	switch input := input.(type) {
+	case *ddb.GetItemInput:
+		return aws.ToString(input.TableName)
+	case *ddb.DeleteItemInput:
+		return aws.ToString(input.TableName)
+	case *ddb.PutItemInput:
+		return aws.ToString(input.TableName)
+	case *ddb.UpdateItemInput:
+		return aws.ToString(input.TableName)
+	case *ddb.QueryInput:
+		return aws.ToString(input.TableName)
+	case *ddb.ScanInput:
+		return aws.ToString(input.TableName)
+	case *ddb.BatchGetItemInput:
+		return onlyKey(input.RequestItems)
+	case *ddb.BatchWriteItemInput:
+		return onlyKey(input.RequestItems)
+	default:
+		return ""
+	}
+}
+
+// onlyKey returns the key of a map holding a single entry.
+func onlyKey[V any](m map[string]V) string {
+	if len(m) != 1 {
+		return ""
+	}
+	for k := range m {
+		return k
+	}
+
+	return ""
+}
+
+// conditional reports whether a write input only applies under a condition,
+// making it safe to repeat.
+func conditional(input interface{}) bool {
+	switch input := input.(type) {
+	case *ddb.DeleteItemInput:
+		return input.ConditionExpression != nil || len(input.Expected) > 0
+	case *ddb.PutItemInput:
+		return input.ConditionExpression != nil || len(input.Expected) > 0
+	case *ddb.UpdateItemInput:
+		return input.ConditionExpression != nil || len(input.Expected) > 0
+	case *ddb.TransactWriteItemsInput:
+		return input.ClientRequestToken != nil
+	default:
+		return false
+	}
+}
@@ -0,0 +1,344 @@
+package ddbretry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry/ddbretrytest"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryDynamoDBClient_ReadWritePolicies(t *testing.T) {
+	type fields struct {
+		DisableRetries bool
+		Retries        int
+		ReadPolicy     *RetryPolicy
+		WritePolicy    *RetryPolicy
+	}
+	tests := []struct {
+		name              string
+		fields            fields
+		throttles         int
+		wantGetItemErr    bool
+		wantDeleteItemErr bool
+		wantPutItemErr    bool
+	}{
+		{
+			name: "should use client settings when no policies are set",
+			fields: fields{
+				Retries: 2,
+			},
+			throttles:         2,
+			wantGetItemErr:    false,
+			wantDeleteItemErr: false,
+			wantPutItemErr:    false,
+		},
+		{
+			name: "should use ReadPolicy for reads and client settings for writes",
+			fields: fields{
+				DisableRetries: true,
+				ReadPolicy: &RetryPolicy{
+					Retries: 2,
+				},
+			},
+			throttles:         2,
+			wantGetItemErr:    false,
+			wantDeleteItemErr: true,
+			wantPutItemErr:    true,
+		},
+		{
+			name: "should use WritePolicy for writes and client settings for reads",
+			fields: fields{
+				DisableRetries: true,
+				WritePolicy: &RetryPolicy{
+					Retries: 2,
+				},
+			},
+			throttles:         2,
+			wantGetItemErr:    true,
+			wantDeleteItemErr: false,
+			wantPutItemErr:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			newClient := func(op string) *RetryDynamoDBClient {
+				return &RetryDynamoDBClient{
+					DynamoDBClient: ddbretrytest.NewFakeClient().Script(op, ddbretrytest.Throttles(tt.throttles)...),
+					DisableRetries: tt.fields.DisableRetries,
+					Retries:        tt.fields.Retries,
+					ReadPolicy:     tt.fields.ReadPolicy,
+					WritePolicy:    tt.fields.WritePolicy,
+				}
+			}
+
+			_, err := newClient("GetItem").GetItem(ctx, &ddb.GetItemInput{})
+			assert.Equal(t, tt.wantGetItemErr, err != nil)
+
+			_, err = newClient("DeleteItem").DeleteItem(ctx, &ddb.DeleteItemInput{})
+			assert.Equal(t, tt.wantDeleteItemErr, err != nil)
+
+			_, err = newClient("PutItem").PutItem(ctx, &ddb.PutItemInput{})
+			assert.Equal(t, tt.wantPutItemErr, err != nil)
+		})
+	}
+}
+
+func TestRetryDynamoDBClient_policyFor(t *testing.T) {
+	c := &RetryDynamoDBClient{
+		Retries: 1,
+		ReadPolicy: &RetryPolicy{
+			Retries: 2,
+		},
+		WritePolicy: &RetryPolicy{
+			Retries: 3,
+		},
+		OperationPolicies: map[string]RetryPolicy{
+			"GetItem": {
+				Retries: 10,
+			},
+			"DeleteItem": {
+				Retries: 0,
+			},
+		},
+		TablePolicies: map[string]RetryPolicy{
+			"hot": {
+				Retries: 20,
+			},
+		},
+	}
+
+	tests := []struct {
+		op    string
+		table string
+		want  int
+	}{
+		{op: "GetItem", table: "users", want: 10},
+		{op: "DeleteItem", table: "users", want: 0},
+		{op: "PutItem", table: "users", want: 3},
+		{op: "Scan", table: "users", want: 2},
+		{op: "GetItem", table: "hot", want: 20},
+		{op: "PutItem", table: "hot", want: 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.op+"/"+tt.table, func(t *testing.T) {
+			assert.Equal(t, tt.want, c.policyFor(tt.op, tt.table).Retries)
+		})
+	}
+}
+
+func TestRetryDynamoDBClient_TablePolicies(t *testing.T) {
+	c := &RetryDynamoDBClient{
+		DynamoDBClient: ddbretrytest.NewFakeClient().Script("GetItem", ddbretrytest.Throttles(2)...),
+		DisableRetries: true,
+		TablePolicies: map[string]RetryPolicy{
+			"hot": {
+				Retries: 2,
+			},
+		},
+	}
+
+	_, err := c.GetItem(context.Background(), &ddb.GetItemInput{TableName: aws.String("cold")})
+	assert.True(t, IsProvisionedThroughputExceededException(err))
+
+	_, err = c.GetItem(context.Background(), &ddb.GetItemInput{TableName: aws.String("hot")})
+	assert.NoError(t, err)
+}
+
+func TestRetryDynamoDBClient_policy(t *testing.T) {
+	tests := []struct {
+		name            string
+		client          *RetryDynamoDBClient
+		wantRetries     int
+		wantBackOffTime time.Duration
+	}{
+		{
+			name:            "should apply defaults to a zero-value client",
+			client:          &RetryDynamoDBClient{},
+			wantRetries:     DefaultRetries,
+			wantBackOffTime: DefaultBackOffTime,
+		},
+		{
+			name: "should not retry when retries are disabled",
+			client: &RetryDynamoDBClient{
+				DisableRetries: true,
+			},
+			wantRetries: 0,
+		},
+		{
+			name: "should retry indefinitely when Infinite is set",
+			client: &RetryDynamoDBClient{
+				Infinite:    true,
+				BackOffTime: time.Second,
+			},
+			wantRetries:     InfiniteRetries,
+			wantBackOffTime: time.Second,
+		},
+		{
+			name: "should make one attempt fewer retries than MaxAttempts",
+			client: &RetryDynamoDBClient{
+				MaxAttempts: 1,
+			},
+			wantRetries: 0,
+		},
+		{
+			name: "should keep explicit settings",
+			client: &RetryDynamoDBClient{
+				Retries: 5,
+			},
+			wantRetries: 5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := tt.client.policy()
+			assert.Equal(t, tt.wantRetries, p.Retries)
+			assert.Equal(t, tt.wantBackOffTime, p.BackOffTime)
+		})
+	}
+}
+
+func TestTableName(t *testing.T) {
+	tests := []struct {
+		name  string
+		input interface{}
+		want  string
+	}{
+		{
+			name:  "should return table of item input",
+			input: &ddb.UpdateItemInput{TableName: aws.String("users")},
+			want:  "users",
+		},
+		{
+			name:  "should return table of query input",
+			input: &ddb.QueryInput{TableName: aws.String("orders")},
+			want:  "orders",
+		},
+		{
+			name: "should return table of single-table batch input",
+			input: &ddb.BatchWriteItemInput{
+				RequestItems: map[string][]types.WriteRequest{
+					"users": nil,
+				},
+			},
+			want: "users",
+		},
+		{
+			name: "should return empty string for multi-table batch input",
+			input: &ddb.BatchGetItemInput{
+				RequestItems: map[string]types.KeysAndAttributes{
+					"users":  {},
+					"orders": {},
+				},
+			},
+			want: "",
+		},
+		{
+			name:  "should return empty string for inputs without a table",
+			input: &ddb.ListTablesInput{},
+			want:  "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tableName(tt.input))
+		})
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	throttled := &types.ProvisionedThroughputExceededException{}
+	limited := &types.LimitExceededException{}
+	policy := RetryPolicy{
+		Retries:         2,
+		BackOffTime:     100 * time.Millisecond,
+		BackOffStrategy: ExponentialBackOff,
+	}
+
+	assert.True(t, policy.ShouldRetry(1, throttled))
+	assert.True(t, policy.ShouldRetry(2, throttled))
+	assert.False(t, policy.ShouldRetry(3, throttled))
+	assert.False(t, policy.ShouldRetry(1, errors.New("validation failed")))
+	assert.Equal(t, 200*time.Millisecond, policy.Delay(2, 0, throttled))
+	assert.Equal(t, 2*time.Second, policy.Delay(2, 0, limited))
+
+	infinite := RetryPolicy{Retries: -1}
+	assert.True(t, infinite.ShouldRetry(1000, throttled))
+
+	custom := RetryPolicy{
+		Retries: 1,
+		Classifier: ErrorClassifierFunc(func(err error) RetryDecision {
+			return Retry
+		}),
+	}
+	assert.True(t, custom.ShouldRetry(1, errors.New("validation failed")))
+}
+
+func TestRetryPolicy_Do(t *testing.T) {
+	errBusy := errors.New("lock held")
+	policy := RetryPolicy{
+		Retries:     3,
+		BackOffTime: time.Millisecond,
+		Classifier: ErrorClassifierFunc(func(err error) RetryDecision {
+			if errors.Is(err, errBusy) {
+				return Retry
+			}
+			return DoNotRetry
+		}),
+	}
+
+	calls := 0
+	err := policy.Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return errBusy
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+
+	calls = 0
+	err = policy.Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		return errBusy
+	})
+	assert.ErrorIs(t, err, errBusy)
+	assert.Equal(t, 4, calls)
+
+	calls = 0
+	errFatal := errors.New("not found")
+	err = policy.Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		return errFatal
+	})
+	assert.ErrorIs(t, err, errFatal)
+	assert.Equal(t, 1, calls)
+}
+
+func TestRetryDynamoDBClient_policyClassifier(t *testing.T) {
+	policy := RetryPolicy{
+		Retries: 1,
+		Classifier: ErrorClassifierFunc(func(err error) RetryDecision {
+			return DoNotRetry
+		}),
+	}
+	c := &RetryDynamoDBClient{
+		DynamoDBClient: ddbretrytest.NewFakeClient().Script("GetItem", ddbretrytest.Throttled()),
+		Retries:        1,
+		WritePolicy:    &policy,
+	}
+
+	_, err := c.GetItem(context.Background(), &ddb.GetItemInput{})
+	assert.NoError(t, err)
+
+	c.DynamoDBClient = ddbretrytest.NewFakeClient().Script("PutItem", ddbretrytest.Throttled())
+	_, err = c.PutItem(context.Background(), &ddb.PutItemInput{})
+	assert.True(t, IsProvisionedThroughputExceededException(err))
+}
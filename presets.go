@@ -0,0 +1,71 @@
+package ddbretry
+
+import (
+	"time"
+)
+
+// Presets are Options that apply a tested combination of retry settings,
+// replacing every setting a Config holds, for use with NewFromAWSConfig or
+// With:
+//
+//	client, err := ddbretry.NewFromAWSConfig(cfg, ddbretry.Conservative)
+//
+// Given to UpdateConfig, a preset only changes the settings of the client's
+// RetryPolicy; the retry budget and rate limiter some presets attach are
+// dropped.
+var (
+	// Conservative retries a few times with jittered backoff of at least
+	// 100ms, and stops retrying once a retry budget drains, for services that
+	// would rather fail than add load to a struggling table.
+	Conservative = preset(Config{
+		Retries:         3,
+		BackOffTime:     200 * time.Millisecond,
+		BackOffStrategy: EqualJitterBackOff,
+		MaxBackOff:      5 * time.Second,
+	}, func(c *RetryDynamoDBClient) {
+		c.RetryBudget = NewDefaultRetryTokenBucket()
+	})
+	// Aggressive retries many times with short full-jitter backoff, for
+	// batch workloads that must eventually get through.
+	Aggressive = preset(Config{
+		Retries:         10,
+		BackOffTime:     25 * time.Millisecond,
+		BackOffStrategy: FullJitterBackOff,
+		MaxBackOff:      time.Second,
+		HonorRetryAfter: true,
+	}, nil)
+	// Adaptive paces requests with an AdaptiveRateLimiter as throttling comes
+	// and goes, with decorrelated jitter and a retry budget between retries.
+	Adaptive = preset(Config{
+		Retries:         5,
+		BackOffTime:     50 * time.Millisecond,
+		BackOffStrategy: DecorrelatedJitterBackOff,
+		MaxBackOff:      5 * time.Second,
+	}, func(c *RetryDynamoDBClient) {
+		c.RateLimiter = NewAdaptiveRateLimiter()
+		c.RetryBudget = NewDefaultRetryTokenBucket()
+	})
+	// LambdaShortLived keeps every call within a few seconds, for functions
+	// whose invocation would otherwise time out while backing off.
+	LambdaShortLived = preset(Config{
+		Retries:         3,
+		BackOffTime:     20 * time.Millisecond,
+		BackOffStrategy: FullJitterBackOff,
+		MaxBackOff:      500 * time.Millisecond,
+		MaxElapsedTime:  3 * time.Second,
+		AttemptTimeout:  time.Second,
+	}, nil)
+)
+
+// preset returns an Option applying cfg and then, if set, components, which
+// attaches the stateful components the preset relies on.
+func preset(cfg Config, components func(*RetryDynamoDBClient)) Option {
+	return func(c *RetryDynamoDBClient) error {
+		cfg.apply(c)
+		if components != nil {
+			components(c)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,39 @@
+package ddbretry
+
+import (
+	"testing"
+
+	"github.com/Thumbscrew/ddbretry/ddbretrytest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPresets(t *testing.T) {
+	tests := []struct {
+		name   string
+		preset Option
+	}{
+		{name: "Conservative", preset: Conservative},
+		{name: "Aggressive", preset: Aggressive},
+		{name: "Adaptive", preset: Adaptive},
+		{name: "LambdaShortLived", preset: LambdaShortLived},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := &RetryDynamoDBClient{DynamoDBClient: ddbretrytest.NewFakeClient(), Retries: 100}
+			c, err := base.With(tt.preset)
+			assert.NoError(t, err)
+			assert.Less(t, c.policy().Retries, 100)
+			assert.Greater(t, c.policy().BackOffTime, DefaultBackOffTime/10)
+
+			other, err := base.With(tt.preset)
+			assert.NoError(t, err)
+			if c.RetryBudget != nil {
+				assert.NotSame(t, c.RetryBudget, other.RetryBudget)
+			}
+		})
+	}
+
+	c := &RetryDynamoDBClient{}
+	assert.NoError(t, c.UpdateConfig(LambdaShortLived))
+	assert.Equal(t, 3, c.policy().Retries)
+}
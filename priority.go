@@ -0,0 +1,26 @@
+package ddbretry
+
+// Priority ranks calls competing for a RetryTokenBucket: under sustained
+// throttling, the reserves set with RetryTokenBucket.SetReserve make calls
+// of lower priority stop retrying first. Calls are NormalPriority unless
+// tagged otherwise with WithCallPriority or ContextWithPriority.
+type Priority int
+
+const (
+	LowPriority    Priority = -1
+	NormalPriority Priority = 0
+	HighPriority   Priority = 1
+)
+
+func (p Priority) String() string {
+	switch p {
+	case LowPriority:
+		return "low"
+	case NormalPriority:
+		return "normal"
+	case HighPriority:
+		return "high"
+	default:
+		return "unknown"
+	}
+}
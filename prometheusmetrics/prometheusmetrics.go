@@ -0,0 +1,115 @@
+// Package prometheusmetrics exposes ddbretry's retry metrics as a Prometheus
+// collector.
+package prometheusmetrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const DefaultNamespace = "ddbretry"
+
+var labels = []string{"operation", "table"}
+
+// Collector is a ddbretry.MetricsRecorder that is also a
+// prometheus.Collector, exporting retries_total, throttles_total,
+// give_ups_total, attempt_duration_seconds and backoff_duration_seconds
+// labelled by operation and table, along with shadow_retries_total for
+// clients in Shadow mode. Register it and set it as the client's Metrics:
+//
+//	collector := prometheusmetrics.New("")
+//	prometheus.MustRegister(collector)
+//	client.Metrics = collector
+type Collector struct {
+	retries   *prometheus.CounterVec
+	throttles *prometheus.CounterVec
+	giveUps   *prometheus.CounterVec
+	attempts  *prometheus.HistogramVec
+	backOffs  *prometheus.HistogramVec
+	shadows   *prometheus.CounterVec
+}
+
+// New creates a Collector whose metrics are prefixed with namespace, or with
+// DefaultNamespace if namespace is empty.
+func New(namespace string) *Collector {
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+
+	return &Collector{
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "retries_total",
+			Help:      "Number of DynamoDB requests retried.",
+		}, labels),
+		throttles: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "throttles_total",
+			Help:      "Number of DynamoDB attempts rejected by throttling.",
+		}, labels),
+		giveUps: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "give_ups_total",
+			Help:      "Number of DynamoDB calls that failed after exhausting their retries.",
+		}, labels),
+		attempts: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "attempt_duration_seconds",
+			Help:      "Latency of individual DynamoDB attempts.",
+			Buckets:   prometheus.DefBuckets,
+		}, labels),
+		backOffs: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "backoff_duration_seconds",
+			Help:      "Delay applied before retrying DynamoDB requests.",
+			Buckets:   []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+		}, labels),
+		shadows: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "shadow_retries_total",
+			Help:      "Number of DynamoDB requests that would have been retried outside shadow mode.",
+		}, labels),
+	}
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.retries.Describe(ch)
+	c.throttles.Describe(ch)
+	c.giveUps.Describe(ch)
+	c.attempts.Describe(ch)
+	c.backOffs.Describe(ch)
+	c.shadows.Describe(ch)
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.retries.Collect(ch)
+	c.throttles.Collect(ch)
+	c.giveUps.Collect(ch)
+	c.attempts.Collect(ch)
+	c.backOffs.Collect(ch)
+	c.shadows.Collect(ch)
+}
+
+func (c *Collector) RecordAttempt(op string, table string, latency time.Duration, err error) {
+	c.attempts.WithLabelValues(op, table).Observe(latency.Seconds())
+}
+
+func (c *Collector) RecordRetry(op string, table string, attempt int, delay time.Duration) {
+	c.retries.WithLabelValues(op, table).Inc()
+	c.backOffs.WithLabelValues(op, table).Observe(delay.Seconds())
+}
+
+func (c *Collector) RecordThrottle(op string, table string) {
+	c.throttles.WithLabelValues(op, table).Inc()
+}
+
+func (c *Collector) RecordSuccess(op string, table string, attempts int) {}
+
+func (c *Collector) RecordFailure(op string, table string, attempts int, err error) {
+	c.giveUps.WithLabelValues(op, table).Inc()
+}
+
+func (c *Collector) RecordShadowRetry(op string, table string, attempt int, delay time.Duration) {
+	c.shadows.WithLabelValues(op, table).Inc()
+}
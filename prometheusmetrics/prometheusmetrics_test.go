@@ -0,0 +1,42 @@
+package prometheusmetrics
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	_ ddbretry.MetricsRecorder       = (*Collector)(nil)
+	_ ddbretry.ShadowMetricsRecorder = (*Collector)(nil)
+)
+
+func TestCollector(t *testing.T) {
+	c := New("")
+
+	c.RecordAttempt("GetItem", "users", 10*time.Millisecond, errors.New("throttled"))
+	c.RecordThrottle("GetItem", "users")
+	c.RecordRetry("GetItem", "users", 1, time.Millisecond)
+	c.RecordAttempt("GetItem", "users", 10*time.Millisecond, nil)
+	c.RecordSuccess("GetItem", "users", 2)
+	c.RecordFailure("PutItem", "orders", 4, errors.New("throttled"))
+	c.RecordShadowRetry("Query", "users", 1, time.Second)
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(c.retries.WithLabelValues("GetItem", "users")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(c.throttles.WithLabelValues("GetItem", "users")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(c.giveUps.WithLabelValues("PutItem", "orders")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(c.shadows.WithLabelValues("Query", "users")))
+
+	err := testutil.CollectAndCompare(c, strings.NewReader(`
+# HELP ddbretry_give_ups_total Number of DynamoDB calls that failed after exhausting their retries.
+# TYPE ddbretry_give_ups_total counter
+ddbretry_give_ups_total{operation="PutItem",table="orders"} 1
+`), "ddbretry_give_ups_total")
+	assert.NoError(t, err)
+	assert.Equal(t, 6, testutil.CollectAndCount(c))
+}
@@ -0,0 +1,69 @@
+package ddbretry
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+)
+
+// Rand is the source of randomness behind jittered backoff. Setting one on a
+// RetryDynamoDBClient or RetryPolicy, seeded with a fixed value, makes its
+// backoff schedules reproducible in tests and simulations; without one,
+// jitter is drawn from math/rand's global source. Rand is safe for
+// concurrent use.
+type Rand struct {
+	mu  sync.Mutex
+	r   *rand.Rand
+	max bool
+}
+
+// NewRand returns a Rand drawing from src, which does not need to be safe for
+// concurrent use.
+func NewRand(src rand.Source) *Rand {
+	return &Rand{r: rand.New(src)}
+}
+
+// NewSeededRand returns a Rand drawing from a source seeded with seed.
+func NewSeededRand(seed int64) *Rand {
+	return NewRand(rand.NewSource(seed))
+}
+
+// int63n returns a random number in [0, n), or in [0, 2^63) if n is not
+// positive, from r or from the global source if r is nil. A Rand made by
+// maxRand always returns the largest such number.
+func (r *Rand) int63n(n int64) int64 {
+	if r == nil {
+		if n <= 0 {
+			return rand.Int63()
+		}
+		return rand.Int63n(n)
+	}
+
+	if r.max {
+		if n <= 0 {
+			return math.MaxInt64
+		}
+		return n - 1
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if n <= 0 {
+		return r.r.Int63()
+	}
+
+	return r.r.Int63n(n)
+}
+
+// float64 returns a random number in [0, 1), from r or from the global source
+// if r is nil.
+func (r *Rand) float64() float64 {
+	return float64(r.int63n(1<<53)) / (1 << 53)
+}
+
+// maxRand returns a Rand that always draws its largest value, so that every
+// jittered delay is as long as it can be.
+func maxRand() *Rand {
+	return &Rand{max: true}
+}
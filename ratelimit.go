@@ -0,0 +1,204 @@
+package ddbretry
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	adaptiveSmooth        = 0.8
+	adaptiveBeta          = 0.7
+	adaptiveScaleConstant = 0.4
+	adaptiveMinFillRate   = 0.5
+	adaptiveMinCapacity   = 1.0
+)
+
+// AdaptiveRateLimiter throttles outgoing requests on the client side, in the
+// same way as the AWS SDK's "adaptive" retry mode. It stays dormant until the
+// first throttling error, then tracks the rate at which requests succeed and
+// makes every attempt, not only retries, wait for a send token. The sending
+// rate is cut on each throttle and grows back along a cubic curve as requests
+// succeed, converging on the throughput the table can sustain.
+//
+// A single AdaptiveRateLimiter can be shared by several clients talking to the
+// same table.
+type AdaptiveRateLimiter struct {
+	mu sync.Mutex
+	clockSource
+
+	enabled         bool
+	fillRate        float64
+	maxCapacity     float64
+	currentCapacity float64
+	lastTimestamp   float64
+
+	measuredTxRate   float64
+	lastTxRateBucket float64
+	requestCount     float64
+
+	lastMaxRate      float64
+	lastThrottleTime float64
+	timeWindow       float64
+}
+
+func NewAdaptiveRateLimiter() *AdaptiveRateLimiter {
+	return &AdaptiveRateLimiter{}
+}
+
+// SendRate returns the current client-side sending rate in requests per
+// second, or 0 if the limiter has not been enabled by a throttling error yet.
+func (l *AdaptiveRateLimiter) SendRate() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.enabled {
+		return 0
+	}
+
+	return l.fillRate
+}
+
+func (l *AdaptiveRateLimiter) seconds() float64 {
+	return float64(l.now().UnixNano()) / float64(time.Second)
+}
+
+// acquire waits on clock until a send token is available or ctx is done.
+func (l *AdaptiveRateLimiter) acquire(ctx context.Context, clock Clock) error {
+	l.mu.Lock()
+	if !l.enabled {
+		l.mu.Unlock()
+		return nil
+	}
+
+	l.use(clock)
+	l.refill()
+	var delay time.Duration
+	if l.currentCapacity < 1 {
+		delay = time.Duration((1 - l.currentCapacity) / l.fillRate * float64(time.Second))
+	}
+	// Reserve the token up front so concurrent callers queue up behind each
+	// other instead of all waking at once.
+	l.currentCapacity--
+	l.mu.Unlock()
+
+	return clock.Sleep(ctx, delay)
+}
+
+// update adjusts the sending rate after an attempt completes.
+func (l *AdaptiveRateLimiter) update(throttled bool, clock Clock) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.use(clock)
+	now := l.seconds()
+	if l.lastTxRateBucket == 0 {
+		l.lastTxRateBucket = math.Floor(now)
+		l.lastThrottleTime = now
+	}
+	l.updateMeasuredRate(now)
+
+	var rate float64
+	if throttled {
+		rate = l.measuredTxRate
+		if l.enabled {
+			rate = math.Min(rate, l.fillRate)
+		}
+		l.lastMaxRate = rate
+		l.calculateTimeWindow()
+		l.lastThrottleTime = now
+		rate *= adaptiveBeta
+		l.enabled = true
+	} else {
+		l.calculateTimeWindow()
+		rate = adaptiveScaleConstant*math.Pow(now-l.lastThrottleTime-l.timeWindow, 3) + l.lastMaxRate
+	}
+
+	l.updateRate(math.Min(rate, 2*l.measuredTxRate))
+}
+
+func (l *AdaptiveRateLimiter) refill() {
+	now := l.seconds()
+	if l.lastTimestamp == 0 {
+		l.lastTimestamp = now
+		return
+	}
+
+	l.currentCapacity = math.Min(l.maxCapacity, l.currentCapacity+(now-l.lastTimestamp)*l.fillRate)
+	l.lastTimestamp = now
+}
+
+func (l *AdaptiveRateLimiter) updateRate(rate float64) {
+	l.refill()
+	l.fillRate = math.Max(rate, adaptiveMinFillRate)
+	l.maxCapacity = math.Max(rate, adaptiveMinCapacity)
+	l.currentCapacity = math.Min(l.currentCapacity, l.maxCapacity)
+}
+
+func (l *AdaptiveRateLimiter) calculateTimeWindow() {
+	l.timeWindow = math.Cbrt(l.lastMaxRate * (1 - adaptiveBeta) / adaptiveScaleConstant)
+}
+
+func (l *AdaptiveRateLimiter) updateMeasuredRate(now float64) {
+	bucket := math.Floor(now*2) / 2
+	l.requestCount++
+	if bucket > l.lastTxRateBucket {
+		rate := l.requestCount / (bucket - l.lastTxRateBucket)
+		l.measuredTxRate = rate*adaptiveSmooth + l.measuredTxRate*(1-adaptiveSmooth)
+		l.requestCount = 0
+		l.lastTxRateBucket = bucket
+	}
+}
+
+// RetryRateLimiter caps the number of retries issued per second, however many
+// calls are retrying at once, so that the client cannot amplify the load on a
+// throttled table. First attempts are never delayed by it. A single
+// RetryRateLimiter may be shared by several clients.
+type RetryRateLimiter struct {
+	mu sync.Mutex
+	clockSource
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewRetryRateLimiter returns a limiter allowing perSecond retries per second
+// on average, with bursts of up to burst retries.
+func NewRetryRateLimiter(perSecond float64, burst int) *RetryRateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &RetryRateLimiter{
+		rate:   perSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+	}
+}
+
+// reserve takes a token and returns how long the caller must wait before
+// using it.
+func (l *RetryRateLimiter) reserve(clock Clock) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.use(clock)
+	now := l.now()
+	if elapsed := now.Sub(l.last); elapsed > 0 {
+		l.tokens = math.Min(l.burst, l.tokens+elapsed.Seconds()*l.rate)
+	}
+	l.last = now
+	l.tokens--
+	if l.tokens >= 0 || l.rate <= 0 {
+		return 0
+	}
+
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// wait blocks on clock until a retry may be sent or ctx is done.
+func (l *RetryRateLimiter) wait(ctx context.Context, clock Clock) error {
+	return clock.Sleep(ctx, l.reserve(clock))
+}
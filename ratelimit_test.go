@@ -0,0 +1,81 @@
+package ddbretry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry/ddbretrytest"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdaptiveRateLimiter(t *testing.T) {
+	clock := ddbretrytest.NewFakeClock(time.Unix(1700000000, 0))
+	l := NewAdaptiveRateLimiter()
+
+	// Dormant until the first throttle.
+	assert.NoError(t, l.acquire(context.Background(), clock))
+	assert.Equal(t, 0.0, l.SendRate())
+
+	for i := 0; i < 50; i++ {
+		clock.Advance(100 * time.Millisecond)
+		l.update(false, clock)
+	}
+	assert.Equal(t, 0.0, l.SendRate())
+
+	l.update(true, clock)
+	throttledRate := l.SendRate()
+	assert.InDelta(t, 7.0, throttledRate, 1.0)
+
+	for i := 0; i < 50; i++ {
+		clock.Advance(100 * time.Millisecond)
+		l.update(false, clock)
+	}
+	assert.Greater(t, l.SendRate(), throttledRate)
+}
+
+func TestAdaptiveRateLimiter_acquire(t *testing.T) {
+	clock := ddbretrytest.NewFakeClock(time.Unix(1700000000, 0))
+	l := NewAdaptiveRateLimiter()
+	l.update(true, clock)
+
+	// The bucket starts empty once enabled, so the next token is at least
+	// a second away at the minimum fill rate.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, l.acquire(ctx, clock), context.Canceled)
+
+	assert.NoError(t, l.acquire(context.Background(), clock))
+	assert.Len(t, clock.Sleeps(), 1)
+	assert.GreaterOrEqual(t, clock.Sleeps()[0], time.Second)
+}
+
+func TestRetryRateLimiter_reserve(t *testing.T) {
+	clock := ddbretrytest.NewFakeClock(time.Unix(1700000000, 0))
+	l := NewRetryRateLimiter(10, 2)
+
+	assert.Equal(t, time.Duration(0), l.reserve(clock))
+	assert.Equal(t, time.Duration(0), l.reserve(clock))
+	assert.Equal(t, 100*time.Millisecond, l.reserve(clock))
+	assert.Equal(t, 200*time.Millisecond, l.reserve(clock))
+
+	clock.Advance(time.Second)
+	assert.Equal(t, time.Duration(0), l.reserve(clock))
+}
+
+func TestRetryDynamoDBClient_RetryRateLimiter(t *testing.T) {
+	clock := ddbretrytest.NewFakeClock(time.Unix(1700000000, 0))
+	limiter := NewRetryRateLimiter(1, 1)
+	c := &RetryDynamoDBClient{
+		DynamoDBClient:   ddbretrytest.NewFakeClient().Script("GetItem", ddbretrytest.Throttles(3)...),
+		Retries:          3,
+		RetryRateLimiter: limiter,
+		Clock:            clock,
+	}
+
+	_, err := c.GetItem(context.Background(), &ddb.GetItemInput{})
+	assert.NoError(t, err)
+	// A backoff and then a wait for the limiter before each retry.
+	assert.Equal(t, []time.Duration{0, 0, 0, time.Second, 0, time.Second}, clock.Sleeps())
+}
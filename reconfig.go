@@ -0,0 +1,58 @@
+package ddbretry
+
+// UpdateConfig applies opts to the settings that make up c's own
+// RetryPolicy, from Retries to AttemptTimeout, while c is in use, such as
+// from an operations endpoint tuning retries live. Calls already in progress
+// finish under the settings they started with. Options that set anything
+// else, such as the other settings in a Config given to WithConfig, are
+// ignored. If an option fails, UpdateConfig returns its error and leaves the
+// settings unchanged. Concurrent updates are applied one after the other.
+func (c *RetryDynamoDBClient) UpdateConfig(opts ...Option) error {
+	for {
+		old := c.frozen.Load()
+		base := c.fieldPolicy()
+		if p, ok := old.(*RetryPolicy); ok {
+			base = *p
+		}
+
+		scratch := policyClient(base)
+		for _, opt := range opts {
+			if err := opt(scratch); err != nil {
+				return err
+			}
+		}
+		p := scratch.fieldPolicy()
+
+		if c.frozen.CompareAndSwap(old, &p) {
+			return nil
+		}
+	}
+}
+
+// policyClient returns a client whose fields hold the settings in p.
+func policyClient(p RetryPolicy) *RetryDynamoDBClient {
+	c := &RetryDynamoDBClient{}
+	c.setPolicy(p)
+
+	return c
+}
+
+// setPolicy writes the settings in p to c's fields, so that fieldPolicy
+// returns p.
+func (c *RetryDynamoDBClient) setPolicy(p RetryPolicy) {
+	c.DisableRetries = p.Retries == 0
+	c.Infinite = p.Retries == InfiniteRetries
+	c.MaxAttempts = 0
+	c.Retries = p.Retries
+	c.BackOffTime = p.BackOffTime
+	c.BackOffStrategy = p.BackOffStrategy
+	c.MaxBackOff = p.MaxBackOff
+	c.BackOff = p.BackOff
+	c.BackOffFunc = p.BackOffFunc
+	c.InitialBackOff = p.InitialBackOff
+	c.ImmediateRetry = p.ImmediateRetry
+	c.MaxElapsedTime = p.MaxElapsedTime
+	c.AttemptTimeout = p.AttemptTimeout
+	c.Classifier = p.Classifier
+	c.Rand = p.Rand
+}
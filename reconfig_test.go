@@ -0,0 +1,101 @@
+package ddbretry
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry/ddbretrytest"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryDynamoDBClient_UpdateConfig(t *testing.T) {
+	c, err := NewRetryDynamoDBClient(ddbretrytest.NewFakeClient(), 0, time.Second)
+	assert.NoError(t, err)
+
+	assert.NoError(t, c.UpdateConfig(WithRetries(5)))
+	assert.Equal(t, 5, c.policy().Retries)
+	assert.Equal(t, time.Second, c.policy().BackOffTime)
+
+	assert.NoError(t, c.UpdateConfig(WithBackOff(0), WithBackOffStrategy(FullJitterBackOff)))
+	assert.Equal(t, 5, c.policy().Retries)
+	assert.Equal(t, time.Duration(0), c.policy().BackOffTime)
+	assert.Equal(t, FullJitterBackOff, c.policy().BackOffStrategy)
+
+	assert.True(t, IsInvalidRetryError(c.UpdateConfig(WithRetries(1), WithRetries(-5))))
+	assert.Equal(t, 5, c.policy().Retries)
+
+	assert.NoError(t, c.UpdateConfig(WithRetries(0)))
+	assert.Equal(t, 0, c.policy().Retries)
+}
+
+func TestRetryDynamoDBClient_UpdateConfigWith(t *testing.T) {
+	c, err := NewRetryDynamoDBClient(ddbretrytest.NewFakeClient(), 1, time.Second)
+	assert.NoError(t, err)
+	rand := NewSeededRand(1)
+	assert.NoError(t, c.UpdateConfig(WithRetries(7), func(c *RetryDynamoDBClient) error {
+		c.Rand = rand
+		return nil
+	}))
+
+	d, err := c.With(WithBackOff(2 * time.Millisecond))
+	assert.NoError(t, err)
+	assert.Equal(t, 7, d.policy().Retries)
+	assert.Equal(t, 2*time.Millisecond, d.policy().BackOffTime)
+	assert.Same(t, rand, d.policy().Rand)
+	assert.Equal(t, 7, c.policy().Retries)
+	assert.Equal(t, time.Second, c.policy().BackOffTime)
+}
+
+func TestRetryDynamoDBClient_UpdateConfigConcurrent(t *testing.T) {
+	c := &RetryDynamoDBClient{
+		DynamoDBClient: ddbretrytest.NewFakeClient(),
+		Retries:        1,
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, c.UpdateConfig(WithRetries(i)))
+		}(i)
+		go func() {
+			defer wg.Done()
+			_, err := c.GetItem(context.Background(), &ddb.GetItemInput{})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestRetryDynamoDBClient_WithConcurrent(t *testing.T) {
+	c, err := NewRetryDynamoDBClient(ddbretrytest.NewFakeClient(), 1, time.Millisecond)
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(3)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, c.UpdateConfig(WithRetries(i+1)))
+		}(i)
+		go func() {
+			defer wg.Done()
+			_, err := c.GetItem(context.Background(), &ddb.GetItemInput{})
+			assert.NoError(t, err)
+		}()
+		go func() {
+			defer wg.Done()
+			d, err := c.With(WithBackOff(0))
+			assert.NoError(t, err)
+			assert.Equal(t, time.Duration(0), d.policy().BackOffTime)
+			assert.Positive(t, d.policy().Retries)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(8), c.Stats().Successes)
+}
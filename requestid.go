@@ -0,0 +1,56 @@
+package ddbretry
+
+import (
+	"errors"
+)
+
+// requestIDError is implemented by errors carrying the ID AWS assigned to
+// the request, such as *awshttp.ResponseError.
+type requestIDError interface {
+	ServiceRequestID() string
+}
+
+// RequestID returns the AWS request ID carried by err, or an empty string if
+// it has none.
+func RequestID(err error) string {
+	var requestIDError requestIDError
+	if errors.As(err, &requestIDError) {
+		return requestIDError.ServiceRequestID()
+	}
+
+	return ""
+}
+
+// RequestIDs returns every AWS request ID carried by err, including those of
+// errors joined with errors.Join. Combined with AggregateErrors, it lists the
+// requests made by every failed attempt of a call, for reference in support
+// cases.
+func RequestIDs(err error) []string {
+	var ids []string
+	seen := map[string]bool{}
+
+	var walk func(err error)
+	walk = func(err error) {
+		if err == nil {
+			return
+		}
+		if e, ok := err.(requestIDError); ok {
+			if id := e.ServiceRequestID(); id != "" && !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+
+		switch e := err.(type) {
+		case interface{ Unwrap() error }:
+			walk(e.Unwrap())
+		case interface{ Unwrap() []error }:
+			for _, err := range e.Unwrap() {
+				walk(err)
+			}
+		}
+	}
+	walk(err)
+
+	return ids
+}
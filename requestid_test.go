@@ -0,0 +1,72 @@
+package ddbretry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry/ddbretrytest"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRequestIDError(id string, err error) error {
+	return &awshttp.ResponseError{
+		ResponseError: &smithyhttp.ResponseError{
+			Err: err,
+		},
+		RequestID: id,
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "should return request ID of response error",
+			err:  newRequestIDError("abc", &types.ProvisionedThroughputExceededException{}),
+			want: "abc",
+		},
+		{
+			name: "should return request ID of wrapped response error",
+			err:  fmt.Errorf("get item: %w", newRequestIDError("abc", nil)),
+			want: "abc",
+		},
+		{
+			name: "should return empty string for other errors",
+			err:  errors.New("foo"),
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, RequestID(tt.err))
+		})
+	}
+}
+
+func TestRetryDynamoDBClient_RequestIDs(t *testing.T) {
+	c := &RetryDynamoDBClient{
+		DynamoDBClient: ddbretrytest.NewFakeClient().Script("GetItem",
+			newRequestIDError("first", &types.ProvisionedThroughputExceededException{}),
+			newRequestIDError("second", &types.ProvisionedThroughputExceededException{}),
+			newRequestIDError("third", &types.ProvisionedThroughputExceededException{}),
+		),
+		Retries:         2,
+		BackOffTime:     time.Millisecond,
+		AggregateErrors: true,
+	}
+
+	_, err := c.GetItem(context.Background(), &ddb.GetItemInput{})
+	assert.Equal(t, "first", RequestID(err))
+	assert.Equal(t, []string{"first", "second", "third"}, RequestIDs(err))
+	assert.Nil(t, RequestIDs(errors.New("foo")))
+}
@@ -0,0 +1,450 @@
+package ddbretry
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// retryState tracks the progress of a single call through the retry loop.
+type retryState struct {
+	client     *RetryDynamoDBClient
+	clock      Clock
+	op         string
+	table      string
+	input      interface{}
+	policy     RetryPolicy
+	idempotent bool
+	priority   Priority
+	retries    int
+	infinite   bool
+	attempt    int
+	sent       int
+	throttled  int
+	downgraded bool
+	level      int
+	delay      time.Duration
+	start      time.Time
+	sentAt     time.Time
+	span       Span
+	bulkhead   *bulkhead
+	spanCtx    context.Context
+	endAttempt func(error)
+	errs       []error
+	capacity   *types.ConsumedCapacity
+	invalid    error
+
+	kindRetries map[ErrorKind]int
+}
+
+// executeWithRetry sends a call of the operation named op with input through
+// attempt, under the retry settings and per-call options among o that apply
+// to it, until it succeeds or gives up. Each attempt is passed its own
+// context and the call's state, from which it can derive the input it sends.
+func executeWithRetry[TIn, TOut any](ctx context.Context, c *RetryDynamoDBClient, op string, input TIn, o []func(*ddb.Options), attempt func(context.Context, *retryState) (TOut, error)) (output TOut, err error) {
+	s := c.newRetryState(ctx, op, input, o)
+	if s.invalid != nil {
+		return output, s.invalid
+	}
+	for s.valid() {
+		if err = s.beforeAttempt(ctx); err != nil {
+			return
+		}
+		attemptCtx, cancel := s.attemptContext(ctx)
+		output, err = attempt(attemptCtx, s)
+		cancel()
+		s.afterAttempt(output, err)
+		if err == nil {
+			return
+		}
+		if err = s.wait(ctx, err); err != nil {
+			return
+		}
+	}
+
+	var zero TOut
+	return zero, NewInvalidRetryError(s.policy.Retries)
+}
+
+func (c *RetryDynamoDBClient) newRetryState(ctx context.Context, op string, input interface{}, o []func(*ddb.Options)) *retryState {
+	table := tableName(input)
+	settings := contextSettings(ctx).merge(callSettingsFrom(o))
+	policy := settings.apply(c.policyFor(op, table))
+	if policy.Rand == nil {
+		policy.Rand = c.Rand
+	}
+	clock := c.clock()
+	level := 0
+	if d := c.BackOffDecay; d != nil {
+		level = d.Level()
+	}
+
+	var h *bulkhead
+	if b := c.Bulkheads; b != nil {
+		h = b.get(table)
+	}
+	priority := NormalPriority
+	if settings.priority != nil {
+		priority = *settings.priority
+	}
+
+	return &retryState{
+		client:     c,
+		bulkhead:   h,
+		clock:      clock,
+		op:         op,
+		table:      table,
+		input:      input,
+		policy:     policy,
+		idempotent: !writeOperations[op] || conditional(input) || settings.idempotent,
+		priority:   priority,
+		retries:    policy.Retries,
+		infinite:   policy.Retries == InfiniteRetries,
+		level:      level,
+		start:      clock.Now(),
+		invalid:    settings.validate(),
+
+		kindRetries: map[ErrorKind]int{},
+	}
+}
+
+func (s *retryState) valid() bool {
+	return s.retries >= 0 || s.infinite
+}
+
+// attemptContext derives the context for a single attempt, bounded by
+// AttemptTimeout if one is set.
+func (s *retryState) attemptContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx = s.startAttemptSpan(ctx)
+	if s.policy.AttemptTimeout > 0 {
+		return context.WithTimeout(ctx, s.policy.AttemptTimeout)
+	}
+
+	return ctx, func() {}
+}
+
+// classify decides how a failed attempt is retried.
+func (s *retryState) classify(ctx context.Context, err error) RetryDecision {
+	if ctx.Err() != nil {
+		return DoNotRetry
+	}
+
+	// An attempt that ran out of its own AttemptTimeout while the caller's
+	// context is still live is worth another try.
+	if s.policy.AttemptTimeout > 0 && errors.Is(err, context.DeadlineExceeded) {
+		return Retry
+	}
+
+	if s.policy.Classifier != nil {
+		return s.policy.Classifier.Classify(err)
+	}
+
+	return s.client.classifier().Classify(err)
+}
+
+// rejected reports whether err means DynamoDB refused the request outright,
+// so a write that failed with it cannot have been applied. Any other failure
+// is ambiguous: the write may have succeeded with only the response lost.
+func rejected(err error) bool {
+	switch ErrorKindOf(err) {
+	case ThrottlingErrorKind, LimitExceededErrorKind, TransactionConflictErrorKind:
+		return true
+	default:
+		return false
+	}
+}
+
+// takeRetry consumes one retry from the allowance err draws on, reporting
+// false once it is used up. When InternalServerErrorRetries or
+// NetworkErrorRetries are set, errors of those kinds draw on them instead of
+// on the policy's Retries.
+func (s *retryState) takeRetry(err error) bool {
+	kind := ErrorKindOf(err)
+	limit := 0
+	switch kind {
+	case InternalServerErrorKind:
+		limit = s.client.InternalServerErrorRetries
+	case NetworkErrorKind:
+		limit = s.client.NetworkErrorRetries
+	}
+	if limit > 0 {
+		if s.kindRetries[kind] >= limit {
+			return false
+		}
+		s.kindRetries[kind]++
+		return true
+	}
+
+	if s.infinite {
+		return true
+	}
+	if s.retries == 0 {
+		return false
+	}
+	s.retries--
+
+	return true
+}
+
+// wait decides whether a failed attempt should be retried. If it should, wait
+// sleeps for the next backoff interval and returns nil; otherwise it returns
+// the error the call should fail with.
+func (s *retryState) wait(ctx context.Context, err error) error {
+	if err := s.retry(ctx, err); err != nil {
+		return s.giveUp(err)
+	}
+
+	return nil
+}
+
+func (s *retryState) retry(ctx context.Context, err error) error {
+	decision := s.classify(ctx, err)
+	if decision == DoNotRetry {
+		return err
+	}
+	if s.client.RequireIdempotentWrites && !s.idempotent && !rejected(err) {
+		return err
+	}
+
+	if !s.takeRetry(err) {
+		return err
+	}
+
+	attempt := s.attempt + 1
+	delay := s.policy.backOff(s.level+attempt, s.delay, err)
+	if decision == RetryWithLongBackOff {
+		delay = saturatingMul(delay, longBackOffMultiplier)
+	}
+	if s.client.HonorRetryAfter {
+		if hint, ok := retryAfter(err, s.clock); ok && hint > delay {
+			delay = hint
+		}
+	}
+	if max := s.policy.MaxElapsedTime; max > 0 && s.clock.Now().Sub(s.start)+delay > max {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok && deadline.Sub(s.clock.Now()) < delay {
+		return NewBackOffDeadlineError(delay, deadline, err)
+	}
+	if s.client.Shadow {
+		s.shadowRetry(attempt, err, decision, delay)
+		return err
+	}
+
+	if b := s.client.CapacityBudget; b != nil && b.exhausted(s.clock) {
+		return NewCapacityBudgetExhaustedError(err)
+	}
+	if h := s.bulkhead; h != nil && h.budget != nil && !h.budget.acquireFor(s.priority) {
+		return NewRetryBudgetExhaustedError(err)
+	}
+	if b := s.client.RetryBudget; b != nil && !b.acquireFor(s.priority) {
+		if h := s.bulkhead; h != nil && h.budget != nil {
+			h.budget.refund()
+		}
+		return NewRetryBudgetExhaustedError(err)
+	}
+
+	s.attempt = attempt
+	s.delay = delay
+	if d := s.client.BackOffDecay; d != nil {
+		d.raise(s.level + attempt)
+	}
+	s.logRetry(attempt, err, delay)
+	if f := s.client.OnRetry; f != nil {
+		f(s.op, s.table, attempt, err, delay)
+	}
+	atomic.AddInt64(&s.client.stats.retries, 1)
+	if r := s.client.Incidents; r != nil {
+		r.retry()
+	}
+	if m := s.client.Metrics; m != nil {
+		m.RecordRetry(s.op, s.table, attempt, delay)
+	}
+	if s.span != nil {
+		s.span.RecordRetry(attempt, err, delay)
+	}
+
+	slept := s.clock.Now()
+	sleepErr := s.clock.Sleep(ctx, delay)
+	s.client.stats.recordBackOff(s.clock.Now().Sub(slept))
+	if sleepErr != nil {
+		return sleepErr
+	}
+	if l := s.client.RetryRateLimiter; l != nil {
+		return l.wait(ctx, s.clock)
+	}
+
+	return nil
+}
+
+// beforeAttempt blocks until the client is ready to send the next attempt.
+func (s *retryState) beforeAttempt(ctx context.Context) error {
+	breaker := s.client.CircuitBreaker
+	if breaker != nil {
+		if ok, until := breaker.allow(s.clock); !ok {
+			return s.giveUp(NewCircuitOpenError(until))
+		}
+	}
+	// abort gives up on the call before the attempt is sent, giving back
+	// the probe the breaker may have admitted for it.
+	abort := func(err error) error {
+		if breaker != nil {
+			breaker.release()
+		}
+		return s.giveUp(err)
+	}
+	if b := s.client.CapacityBudget; b != nil && !b.allowRequest(s.clock) {
+		return abort(NewCapacityBudgetExhaustedError(nil))
+	}
+	if l := s.client.RateLimiter; l != nil {
+		if err := l.acquire(ctx, s.clock); err != nil {
+			return abort(err)
+		}
+	}
+	if l := s.client.ConcurrencyLimiter; l != nil {
+		if err := l.acquire(ctx); err != nil {
+			return abort(err)
+		}
+	}
+	if h := s.bulkhead; h != nil && h.limiter != nil {
+		if err := h.limiter.acquire(ctx); err != nil {
+			if l := s.client.ConcurrencyLimiter; l != nil {
+				l.release()
+			}
+			return abort(err)
+		}
+	}
+	s.sentAt = s.clock.Now()
+
+	return nil
+}
+
+// afterAttempt records the outcome of an attempt.
+func (s *retryState) afterAttempt(output interface{}, err error) {
+	if l := s.client.ConcurrencyLimiter; l != nil {
+		l.release()
+	}
+	if h := s.bulkhead; h != nil && h.limiter != nil {
+		h.limiter.release()
+	}
+	s.sent++
+	s.endAttemptSpan(err)
+	s.recordConsumedCapacity(output)
+	if b := s.client.CapacityBudget; b != nil {
+		if c := consumedCapacity(output); c != nil {
+			b.record(c, s.clock)
+		}
+	}
+	if err != nil && s.client.AggregateErrors {
+		s.errs = append(s.errs, err)
+	}
+	throttled := IsThrottlingError(err)
+	atomic.AddInt64(&s.client.stats.attempts, 1)
+	if throttled {
+		s.throttled++
+		atomic.AddInt64(&s.client.stats.throttles, 1)
+		s.logThrottledRequest()
+		if r := s.client.Incidents; r != nil {
+			r.throttle(s.table, s.clock)
+		}
+	}
+	if err == nil {
+		atomic.AddInt64(&s.client.stats.successes, 1)
+	}
+	if m := s.client.Metrics; m != nil {
+		m.RecordAttempt(s.op, s.table, s.clock.Now().Sub(s.sentAt), err)
+		if throttled {
+			m.RecordThrottle(s.op, s.table)
+		}
+		if err == nil {
+			m.RecordSuccess(s.op, s.table, s.sent)
+		}
+	}
+	if f := s.client.OnSuccess; f != nil && err == nil {
+		f(s.op, s.table, s.sent)
+	}
+	if err == nil {
+		s.reportConsumedCapacity()
+		s.endSpan(nil)
+	}
+
+	if b := s.client.CircuitBreaker; b != nil {
+		b.record(throttled, s.clock)
+	}
+	if l := s.client.RateLimiter; l != nil {
+		l.update(throttled, s.clock)
+	}
+	if d := s.client.BackOffDecay; d != nil {
+		d.record(err == nil)
+	}
+	if m := s.client.ThrottleMonitor; m != nil {
+		m.record(throttled, s.clock)
+	}
+	if b := s.client.RetryBudget; b != nil && err == nil && s.attempt == 0 {
+		b.deposit()
+	}
+	if h := s.bulkhead; h != nil && h.budget != nil && err == nil && s.attempt == 0 {
+		h.budget.deposit()
+	}
+}
+
+// giveUp reports that the call is failing with err, returning the error the
+// call should fail with.
+func (s *retryState) giveUp(err error) error {
+	err = s.aggregate(err)
+	s.reportConsumedCapacity()
+	s.logGiveUp(err)
+	atomic.AddInt64(&s.client.stats.failures, 1)
+	if f := s.client.OnGiveUp; f != nil {
+		f(s.op, s.table, s.sent, err)
+	}
+	if m := s.client.Metrics; m != nil {
+		m.RecordFailure(s.op, s.table, s.sent, err)
+	}
+	s.endSpan(err)
+
+	return err
+}
+
+// aggregate joins the errors returned by earlier attempts to err, the error
+// the call is failing with, if AggregateErrors is set.
+func (s *retryState) aggregate(err error) error {
+	if !s.client.AggregateErrors || len(s.errs) == 0 {
+		return err
+	}
+
+	errs := s.errs
+	if errors.Is(err, errs[len(errs)-1]) {
+		errs = errs[:len(errs)-1]
+	}
+	if len(errs) == 0 {
+		return err
+	}
+
+	joined := make([]error, 0, len(errs)+1)
+	joined = append(joined, errs...)
+
+	return errors.Join(append(joined, err)...)
+}
+
+// sleep pauses for d, returning early with the context's error if ctx is done
+// first.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,461 @@
+package ddbretry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry/ddbretrytest"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryDynamoDBClient_MaxElapsedTime(t *testing.T) {
+	client := ddbretrytest.NewFakeClient().Script("GetItem", ddbretrytest.Throttles(100)...)
+	c := &RetryDynamoDBClient{
+		DynamoDBClient: client,
+		Retries:        -1,
+		BackOffTime:    20 * time.Millisecond,
+		MaxElapsedTime: 50 * time.Millisecond,
+	}
+
+	start := time.Now()
+	output, err := c.GetItem(context.Background(), &ddb.GetItemInput{})
+	assert.Nil(t, output)
+	assert.True(t, IsProvisionedThroughputExceededException(err))
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+	assert.Less(t, client.CallCount("GetItem"), 10)
+}
+
+func TestRetryDynamoDBClient_DeadlineAwareBackOff(t *testing.T) {
+	client := ddbretrytest.NewFakeClient().Script("PutItem", ddbretrytest.Throttled())
+	c := &RetryDynamoDBClient{
+		DynamoDBClient: client,
+		Retries:        3,
+		BackOffTime:    time.Hour,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	start := time.Now()
+	output, err := c.PutItem(ctx, &ddb.PutItemInput{})
+	assert.Nil(t, output)
+	assert.True(t, IsBackOffDeadlineError(err))
+	assert.True(t, IsProvisionedThroughputExceededException(err))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestRetryDynamoDBClient_DeadlineAwareBackOffClock(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	deadline, _ := ctx.Deadline()
+
+	// The client's clock, not the wall clock, decides how much time is left.
+	clock := ddbretrytest.NewFakeClock(deadline.Add(-time.Second))
+	c := &RetryDynamoDBClient{
+		DynamoDBClient: ddbretrytest.NewFakeClient().Script("PutItem", ddbretrytest.Throttled()),
+		Retries:        3,
+		BackOffTime:    time.Minute,
+		Clock:          clock,
+	}
+
+	_, err := c.PutItem(ctx, &ddb.PutItemInput{})
+	assert.True(t, IsBackOffDeadlineError(err))
+	assert.Empty(t, clock.Sleeps())
+}
+
+func TestRetryDynamoDBClient_CancellableBackOff(t *testing.T) {
+	client := ddbretrytest.NewFakeClient().Script("DeleteItem", ddbretrytest.Throttled())
+	c := &RetryDynamoDBClient{
+		DynamoDBClient: client,
+		Retries:        -1,
+		BackOffTime:    time.Hour,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	start := time.Now()
+	output, err := c.DeleteItem(ctx, &ddb.DeleteItemInput{})
+	assert.Nil(t, output)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func newRetryAfterError(value string) error {
+	return &smithyhttp.ResponseError{
+		Response: &smithyhttp.Response{
+			Response: &http.Response{
+				StatusCode: http.StatusBadRequest,
+				Header: http.Header{
+					"Retry-After": []string{value},
+				},
+			},
+		},
+		Err: &types.ProvisionedThroughputExceededException{},
+	}
+}
+
+func TestRetryDynamoDBClient_HonorRetryAfter(t *testing.T) {
+	tests := []struct {
+		name            string
+		honorRetryAfter bool
+		wantDelay       time.Duration
+	}{
+		{
+			name:            "should use Retry-After hint as minimum delay when enabled",
+			honorRetryAfter: true,
+			wantDelay:       time.Hour,
+		},
+		{
+			name:            "should ignore Retry-After hint when disabled",
+			honorRetryAfter: false,
+			wantDelay:       2 * time.Minute,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &RetryDynamoDBClient{
+				DynamoDBClient:  ddbretrytest.NewFakeClient().Script("GetItem", newRetryAfterError("3600")),
+				Retries:         1,
+				BackOffTime:     2 * time.Minute,
+				HonorRetryAfter: tt.honorRetryAfter,
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+
+			_, err := c.GetItem(ctx, &ddb.GetItemInput{})
+			var backOffDeadlineError *BackOffDeadlineError
+			if assert.ErrorAs(t, err, &backOffDeadlineError) {
+				assert.Equal(t, tt.wantDelay, backOffDeadlineError.Delay)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		want   time.Duration
+		wantOk bool
+	}{
+		{
+			name:   "should parse delay in seconds",
+			err:    newRetryAfterError("5"),
+			want:   5 * time.Second,
+			wantOk: true,
+		},
+		{
+			name:   "should parse delay until an HTTP date",
+			err:    newRetryAfterError("Tue, 14 Nov 2023 22:14:20 GMT"),
+			want:   7 * time.Second,
+			wantOk: true,
+		},
+		{
+			name:   "should not wait for an HTTP date in the past",
+			err:    newRetryAfterError("Tue, 14 Nov 2023 22:13:00 GMT"),
+			want:   0,
+			wantOk: true,
+		},
+		{
+			name:   "should ignore invalid header value",
+			err:    newRetryAfterError("soon"),
+			wantOk: false,
+		},
+		{
+			name:   "should ignore errors without a response",
+			err:    &types.ProvisionedThroughputExceededException{},
+			wantOk: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := ddbretrytest.NewFakeClock(time.Unix(1700000053, 0))
+			got, ok := retryAfter(tt.err, clock)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantOk, ok)
+		})
+	}
+}
+
+type BlockingDynamoDBClient struct {
+	ddbretrytest.FakeClient
+	Blocks int
+}
+
+func (c *BlockingDynamoDBClient) GetItem(ctx context.Context, input *ddb.GetItemInput, o ...func(*ddb.Options)) (*ddb.GetItemOutput, error) {
+	if c.Blocks > 0 {
+		c.Blocks--
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	return c.FakeClient.GetItem(ctx, input, o...)
+}
+
+func TestRetryDynamoDBClient_AttemptTimeout(t *testing.T) {
+	tests := []struct {
+		name           string
+		attemptTimeout time.Duration
+		ctxTimeout     time.Duration
+		wantErr        error
+	}{
+		{
+			name:           "should retry attempt that exceeded AttemptTimeout",
+			attemptTimeout: 10 * time.Millisecond,
+			ctxTimeout:     time.Minute,
+			wantErr:        nil,
+		},
+		{
+			name:           "should not retry when the caller's context expired",
+			attemptTimeout: 0,
+			ctxTimeout:     10 * time.Millisecond,
+			wantErr:        context.DeadlineExceeded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &RetryDynamoDBClient{
+				DynamoDBClient: &BlockingDynamoDBClient{
+					Blocks: 2,
+				},
+				Retries:        3,
+				AttemptTimeout: tt.attemptTimeout,
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.ctxTimeout)
+			defer cancel()
+
+			_, err := c.GetItem(ctx, &ddb.GetItemInput{})
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestRetryDynamoDBClient_Hooks(t *testing.T) {
+	type retry struct {
+		op      string
+		table   string
+		attempt int
+		delay   time.Duration
+	}
+	tests := []struct {
+		name         string
+		throttles    int
+		wantRetries  []retry
+		wantSuccess  int
+		wantGiveUp   int
+		wantGiveUpOK bool
+	}{
+		{
+			name:      "should call OnRetry before each retry and OnSuccess once the call succeeds",
+			throttles: 2,
+			wantRetries: []retry{
+				{op: "PutItem", table: "users", attempt: 1, delay: time.Millisecond},
+				{op: "PutItem", table: "users", attempt: 2, delay: time.Millisecond},
+			},
+			wantSuccess: 3,
+		},
+		{
+			name:      "should call OnGiveUp once retries are exhausted",
+			throttles: 5,
+			wantRetries: []retry{
+				{op: "PutItem", table: "users", attempt: 1, delay: time.Millisecond},
+				{op: "PutItem", table: "users", attempt: 2, delay: time.Millisecond},
+				{op: "PutItem", table: "users", attempt: 3, delay: time.Millisecond},
+			},
+			wantGiveUp:   4,
+			wantGiveUpOK: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var retries []retry
+			success, giveUp := 0, 0
+			var giveUpErr error
+			c := &RetryDynamoDBClient{
+				DynamoDBClient: ddbretrytest.NewFakeClient().Script("PutItem", ddbretrytest.Throttles(tt.throttles)...),
+				Retries:        3,
+				BackOffTime:    time.Millisecond,
+				OnRetry: func(op string, table string, attempt int, err error, delay time.Duration) {
+					assert.True(t, IsProvisionedThroughputExceededException(err))
+					retries = append(retries, retry{op: op, table: table, attempt: attempt, delay: delay})
+				},
+				OnSuccess: func(op string, table string, attempts int) {
+					assert.Equal(t, "users", table)
+					success = attempts
+				},
+				OnGiveUp: func(op string, table string, attempts int, err error) {
+					assert.Equal(t, "users", table)
+					giveUp = attempts
+					giveUpErr = err
+				},
+			}
+
+			_, err := c.PutItem(context.Background(), &ddb.PutItemInput{TableName: aws.String("users")})
+			assert.Equal(t, tt.wantRetries, retries)
+			assert.Equal(t, tt.wantSuccess, success)
+			assert.Equal(t, tt.wantGiveUp, giveUp)
+			if tt.wantGiveUpOK {
+				assert.Equal(t, err, giveUpErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRetryDynamoDBClient_AggregateErrors(t *testing.T) {
+	throttle := &types.ProvisionedThroughputExceededException{}
+	internal := &types.InternalServerError{}
+	other := errors.New("foo")
+	deadline := time.Now().Add(time.Minute)
+
+	tests := []struct {
+		name            string
+		aggregateErrors bool
+		errs            []error
+		backOffTime     time.Duration
+		wantErrs        []error
+	}{
+		{
+			name:            "should return only the last error by default",
+			aggregateErrors: false,
+			errs:            []error{throttle, internal, throttle},
+			wantErrs:        []error{throttle},
+		},
+		{
+			name:            "should join the errors from every attempt",
+			aggregateErrors: true,
+			errs:            []error{throttle, internal, throttle},
+			wantErrs:        []error{throttle, internal, throttle},
+		},
+		{
+			name:            "should not join a single error",
+			aggregateErrors: true,
+			errs:            []error{other},
+			wantErrs:        []error{other},
+		},
+		{
+			name:            "should join earlier errors to an error wrapping the last one",
+			aggregateErrors: true,
+			errs:            []error{internal, throttle},
+			backOffTime:     time.Hour,
+			wantErrs:        []error{internal, NewBackOffDeadlineError(time.Hour, deadline, throttle)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &RetryDynamoDBClient{
+				DynamoDBClient:             ddbretrytest.NewFakeClient().Script("GetItem", tt.errs...),
+				Retries:                    1,
+				InternalServerErrorRetries: 1,
+				AggregateErrors:            tt.aggregateErrors,
+			}
+			if tt.backOffTime > 0 {
+				c.OperationPolicies = map[string]RetryPolicy{
+					"GetItem": {Retries: 1, BackOffTime: tt.backOffTime, InitialBackOff: time.Millisecond},
+				}
+			}
+
+			ctx, cancel := context.WithDeadline(context.Background(), deadline)
+			defer cancel()
+
+			_, err := c.GetItem(ctx, &ddb.GetItemInput{})
+			if len(tt.wantErrs) == 1 {
+				assert.Equal(t, tt.wantErrs[0], err)
+				return
+			}
+
+			joined, ok := err.(interface{ Unwrap() []error })
+			if assert.True(t, ok) {
+				assert.Equal(t, tt.wantErrs, joined.Unwrap())
+			}
+			assert.True(t, IsProvisionedThroughputExceededException(err))
+			assert.True(t, IsInternalServerError(err))
+		})
+	}
+}
+
+func TestExecuteWithRetry_operations(t *testing.T) {
+	calls := map[string]func(context.Context, *RetryDynamoDBClient) error{
+		"GetItem": func(ctx context.Context, c *RetryDynamoDBClient) error {
+			_, err := c.GetItem(ctx, &ddb.GetItemInput{})
+			return err
+		},
+		"DeleteItem": func(ctx context.Context, c *RetryDynamoDBClient) error {
+			_, err := c.DeleteItem(ctx, &ddb.DeleteItemInput{})
+			return err
+		},
+		"PutItem": func(ctx context.Context, c *RetryDynamoDBClient) error {
+			_, err := c.PutItem(ctx, &ddb.PutItemInput{})
+			return err
+		},
+		"UpdateItem": func(ctx context.Context, c *RetryDynamoDBClient) error {
+			_, err := c.UpdateItem(ctx, &ddb.UpdateItemInput{})
+			return err
+		},
+		"Query": func(ctx context.Context, c *RetryDynamoDBClient) error {
+			_, err := c.Query(ctx, &ddb.QueryInput{})
+			return err
+		},
+		"Scan": func(ctx context.Context, c *RetryDynamoDBClient) error {
+			_, err := c.Scan(ctx, &ddb.ScanInput{})
+			return err
+		},
+	}
+	tests := []struct {
+		name      string
+		throttles int
+		retries   int
+		wantErr   bool
+		wantCalls int
+	}{
+		{
+			name:      "should retry until the call succeeds",
+			throttles: 2,
+			retries:   3,
+			wantCalls: 3,
+		},
+		{
+			name:      "should give up once retries run out",
+			throttles: 3,
+			retries:   1,
+			wantErr:   true,
+			wantCalls: 2,
+		},
+	}
+	for _, tt := range tests {
+		for op, call := range calls {
+			t.Run(op+" "+tt.name, func(t *testing.T) {
+				fake := ddbretrytest.NewFakeClient().Script(op, ddbretrytest.Throttles(tt.throttles)...)
+				var successes int
+				c := &RetryDynamoDBClient{
+					DynamoDBClient: fake,
+					Retries:        tt.retries,
+					BackOffTime:    time.Millisecond,
+					OnSuccess: func(string, string, int) {
+						successes++
+					},
+				}
+
+				err := call(context.Background(), c)
+				assert.Equal(t, tt.wantErr, err != nil)
+				assert.Equal(t, tt.wantErr, IsProvisionedThroughputExceededException(err))
+				assert.Equal(t, tt.wantCalls, fake.CallCount(op))
+				if !tt.wantErr {
+					assert.Equal(t, 1, successes)
+				}
+			})
+		}
+	}
+}
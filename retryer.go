@@ -0,0 +1,131 @@
+package ddbretry
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// Retryer exposes a RetryPolicy as an aws.RetryerV2, so that ddbretry's
+// classification and backoff can drive the SDK's own retry middleware instead
+// of, or as well as, wrapping the client:
+//
+//	client := ddb.NewFromConfig(cfg, func(o *ddb.Options) {
+//		o.Retryer = ddbretry.NewRetryer(policy)
+//	})
+//
+// The SDK calls the Retryer once per attempt and does not track state
+// between them, so MaxElapsedTime and AttemptTimeout are not applied, and
+// DecorrelatedJitterBackOff derives each delay from the exponential schedule
+// rather than from the previous delay.
+type Retryer struct {
+	Policy      RetryPolicy
+	Classifier  ErrorClassifier
+	RetryBudget *RetryTokenBucket
+	RateLimiter *AdaptiveRateLimiter
+	// Clock, when set, replaces the real clock the RateLimiter waits on.
+	Clock Clock
+}
+
+var _ aws.RetryerV2 = (*Retryer)(nil)
+
+func NewRetryer(policy RetryPolicy) *Retryer {
+	return &Retryer{
+		Policy: policy,
+	}
+}
+
+// Retryer returns an aws.RetryerV2 sharing the client's retry settings,
+// classifier, retry budget, rate limiter and clock.
+func (c *RetryDynamoDBClient) Retryer() *Retryer {
+	return &Retryer{
+		Policy:      c.policy(),
+		Classifier:  c.classifier(),
+		RetryBudget: c.RetryBudget,
+		RateLimiter: c.RateLimiter,
+		Clock:       c.Clock,
+	}
+}
+
+func (r *Retryer) classify(err error) RetryDecision {
+	if r.Classifier != nil {
+		return r.Classifier.Classify(err)
+	}
+
+	return r.Policy.Classify(err)
+}
+
+func (r *Retryer) IsErrorRetryable(err error) bool {
+	return r.classify(err) != DoNotRetry
+}
+
+// MaxAttempts returns the number of attempts allowed by the policy, or 0 for
+// infinite retries.
+func (r *Retryer) MaxAttempts() int {
+	switch {
+	case r.Policy.Retries == InfiniteRetries:
+		return 0
+	case r.Policy.Retries < InfiniteRetries:
+		return 1
+	default:
+		return r.Policy.Retries + 1
+	}
+}
+
+func (r *Retryer) RetryDelay(attempt int, err error) (time.Duration, error) {
+	delay := r.Policy.backOff(attempt, exponential(r.Policy.BackOffTime, attempt-1), err)
+	if r.classify(err) == RetryWithLongBackOff {
+		delay = saturatingMul(delay, longBackOffMultiplier)
+	}
+
+	return delay, nil
+}
+
+func (r *Retryer) GetRetryToken(ctx context.Context, err error) (func(error) error, error) {
+	if r.RetryBudget != nil && !r.RetryBudget.acquire() {
+		return nil, NewRetryBudgetExhaustedError(err)
+	}
+
+	return nopRelease, nil
+}
+
+func (r *Retryer) GetInitialToken() func(error) error {
+	if r.RetryBudget == nil {
+		return nopRelease
+	}
+
+	return func(err error) error {
+		if err == nil {
+			r.RetryBudget.deposit()
+		}
+		return nil
+	}
+}
+
+func (r *Retryer) GetAttemptToken(ctx context.Context) (func(error) error, error) {
+	if r.RateLimiter == nil {
+		return nopRelease, nil
+	}
+	clock := r.clock()
+	if err := r.RateLimiter.acquire(ctx, clock); err != nil {
+		return nil, err
+	}
+
+	return func(err error) error {
+		r.RateLimiter.update(IsThrottlingError(err), clock)
+		return nil
+	}, nil
+}
+
+func (r *Retryer) clock() Clock {
+	if r.Clock != nil {
+		return r.Clock
+	}
+
+	return realClock{}
+}
+
+func nopRelease(error) error {
+	return nil
+}
@@ -0,0 +1,124 @@
+package ddbretry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry/ddbretrytest"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryer_MaxAttempts(t *testing.T) {
+	tests := []struct {
+		name    string
+		retries int
+		want    int
+	}{
+		{
+			name:    "should allow one attempt more than retries",
+			retries: 3,
+			want:    4,
+		},
+		{
+			name:    "should return zero for infinite retries",
+			retries: -1,
+			want:    0,
+		},
+		{
+			name:    "should allow a single attempt for invalid retries",
+			retries: -2,
+			want:    1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRetryer(RetryPolicy{Retries: tt.retries})
+			assert.Equal(t, tt.want, r.MaxAttempts())
+		})
+	}
+}
+
+func TestRetryer_IsErrorRetryable(t *testing.T) {
+	r := NewRetryer(RetryPolicy{})
+	assert.True(t, r.IsErrorRetryable(&types.ProvisionedThroughputExceededException{}))
+	assert.False(t, r.IsErrorRetryable(errors.New("foo")))
+
+	r.Classifier = ErrorClassifierFunc(func(err error) RetryDecision {
+		return Retry
+	})
+	assert.True(t, r.IsErrorRetryable(errors.New("foo")))
+}
+
+func TestRetryer_RetryDelay(t *testing.T) {
+	r := NewRetryer(RetryPolicy{
+		BackOffTime:     100 * time.Millisecond,
+		BackOffStrategy: EqualJitterBackOff,
+		MaxBackOff:      time.Second,
+	})
+
+	delay, err := r.RetryDelay(2, &types.ProvisionedThroughputExceededException{})
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, delay, 100*time.Millisecond)
+	assert.LessOrEqual(t, delay, 200*time.Millisecond)
+
+	delay, err = r.RetryDelay(2, &types.LimitExceededException{})
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, delay, time.Second)
+}
+
+func TestRetryer_tokens(t *testing.T) {
+	budget := NewRetryTokenBucket(5, 5)
+	r := &Retryer{
+		RetryBudget: budget,
+	}
+
+	release, err := r.GetRetryToken(context.Background(), &types.ProvisionedThroughputExceededException{})
+	assert.NoError(t, err)
+	assert.NoError(t, release(nil))
+
+	_, err = r.GetRetryToken(context.Background(), &types.ProvisionedThroughputExceededException{})
+	assert.True(t, IsRetryBudgetExhaustedError(err))
+
+	assert.NoError(t, r.GetInitialToken()(nil))
+	assert.Equal(t, 1, budget.Tokens())
+
+	release, err = r.GetAttemptToken(context.Background())
+	assert.NoError(t, err)
+	assert.NoError(t, release(nil))
+}
+
+func TestRetryer_GetAttemptTokenClock(t *testing.T) {
+	clock := ddbretrytest.NewFakeClock(time.Unix(1700000000, 0))
+	r := &Retryer{
+		RateLimiter: NewAdaptiveRateLimiter(),
+		Clock:       clock,
+	}
+
+	release, err := r.GetAttemptToken(context.Background())
+	assert.NoError(t, err)
+	assert.NoError(t, release(&types.ProvisionedThroughputExceededException{}))
+
+	_, err = r.GetAttemptToken(context.Background())
+	assert.NoError(t, err)
+	if assert.Len(t, clock.Sleeps(), 1) {
+		assert.GreaterOrEqual(t, clock.Sleeps()[0], time.Second)
+	}
+}
+
+func TestRetryDynamoDBClient_Retryer(t *testing.T) {
+	c := &RetryDynamoDBClient{
+		Retries:                    2,
+		BackOffTime:                time.Second,
+		InternalServerErrorRetries: 1,
+	}
+
+	c.Clock = ddbretrytest.NewFakeClock(time.Time{})
+
+	r := c.Retryer()
+	assert.Same(t, c.Clock, r.Clock)
+	assert.Equal(t, 3, r.MaxAttempts())
+	assert.True(t, r.IsErrorRetryable(&types.InternalServerError{}))
+}
@@ -0,0 +1,223 @@
+package ddbretry
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// RoutingStrategy selects the region a RoutingDynamoDBClient sends each read
+// to first.
+type RoutingStrategy int
+
+const (
+	// RoundRobinRouting sends reads to each region in turn.
+	RoundRobinRouting RoutingStrategy = iota
+	// LatencyRouting sends reads to the region with the lowest moving
+	// average latency, counting attempts that fail over as taking
+	// routingPenalty. A region whose average has not been updated for
+	// routingExpiry is tried again as if new, so that a region shunned
+	// while it was throttled wins back its reads once it recovers.
+	LatencyRouting
+)
+
+func (s RoutingStrategy) String() string {
+	switch s {
+	case RoundRobinRouting:
+		return "round-robin"
+	case LatencyRouting:
+		return "latency"
+	default:
+		return "unknown"
+	}
+}
+
+// routingPenalty is the latency recorded for an attempt that fails over, so
+// that LatencyRouting steers reads away from a throttled region.
+const routingPenalty = time.Second
+
+// routingExpiry is how long LatencyRouting remembers the average latency of
+// a region it has stopped sending reads to.
+const routingExpiry = 10 * time.Second
+
+// RoutingDynamoDBClient spreads the eventually consistent reads of a global
+// table across the clients of its replica regions, sending each GetItem,
+// Query, Scan and BatchGetItem call to the region the Strategy picks and, if
+// the call fails there with an error that fails over (see
+// FailoverDynamoDBClient), to each other region in turn. Writes, strongly
+// consistent reads and transactions always go to the home region's client,
+// the one it embeds.
+type RoutingDynamoDBClient struct {
+	DynamoDBClient
+	Replicas []DynamoDBClient
+	Strategy RoutingStrategy
+	// Clock, when set, replaces the real clock used to time reads for
+	// LatencyRouting.
+	Clock Clock
+
+	next      uint64
+	latencies []int64 // moving average in nanoseconds, per region
+	sampled   []int64 // time of the last latency recorded, per region
+}
+
+var _ DynamoDBClient = (*RoutingDynamoDBClient)(nil)
+
+// NewRoutingDynamoDBClient returns a client routing reads across home and
+// replicas with strategy.
+func NewRoutingDynamoDBClient(home DynamoDBClient, strategy RoutingStrategy, replicas ...DynamoDBClient) *RoutingDynamoDBClient {
+	return &RoutingDynamoDBClient{
+		DynamoDBClient: home,
+		Replicas:       replicas,
+		Strategy:       strategy,
+		latencies:      make([]int64, len(replicas)+1),
+		sampled:        make([]int64, len(replicas)+1),
+	}
+}
+
+// Unwrap returns the home region's client.
+func (c *RoutingDynamoDBClient) Unwrap() DynamoDBClient {
+	return c.DynamoDBClient
+}
+
+// region returns the client of the region at index i, the home region being
+// at index 0.
+func (c *RoutingDynamoDBClient) region(i int) DynamoDBClient {
+	if i == 0 {
+		return c.DynamoDBClient
+	}
+
+	return c.Replicas[i-1]
+}
+
+// first returns the index of the region a read is sent to first.
+func (c *RoutingDynamoDBClient) first() int {
+	n := len(c.Replicas) + 1
+	if c.Strategy == LatencyRouting && c.tracking() {
+		now := c.clock()
+		best, bestLatency := 0, c.latency(0, now)
+		for i := 1; i < n; i++ {
+			if latency := c.latency(i, now); latency < bestLatency {
+				best, bestLatency = i, latency
+			}
+		}
+		return best
+	}
+
+	return int((atomic.AddUint64(&c.next, 1) - 1) % uint64(n))
+}
+
+// tracking reports whether the client was built to track region latencies.
+func (c *RoutingDynamoDBClient) tracking() bool {
+	n := len(c.Replicas) + 1
+	return len(c.latencies) == n && len(c.sampled) == n
+}
+
+// latency returns the moving average latency of region i, or zero if it has
+// expired by now.
+func (c *RoutingDynamoDBClient) latency(i int, now time.Time) int64 {
+	if now.Sub(time.Unix(0, atomic.LoadInt64(&c.sampled[i]))) > routingExpiry {
+		return 0
+	}
+
+	return atomic.LoadInt64(&c.latencies[i])
+}
+
+// record folds the latency of an attempt sent to region i into its moving
+// average, starting a new one if the last has expired.
+func (c *RoutingDynamoDBClient) record(i int, latency time.Duration) {
+	if !c.tracking() {
+		return
+	}
+
+	now := c.clock()
+	expired := now.Sub(time.Unix(0, atomic.LoadInt64(&c.sampled[i]))) > routingExpiry
+	atomic.StoreInt64(&c.sampled[i], now.UnixNano())
+	for {
+		old := atomic.LoadInt64(&c.latencies[i])
+		avg := int64(latency)
+		if old != 0 && !expired {
+			avg = old + (int64(latency)-old)/8
+		}
+		if atomic.CompareAndSwapInt64(&c.latencies[i], old, avg) {
+			return
+		}
+	}
+}
+
+func (c *RoutingDynamoDBClient) clock() time.Time {
+	if c.Clock != nil {
+		return c.Clock.Now()
+	}
+
+	return time.Now()
+}
+
+// route sends a read to the region picked by the strategy, then to each
+// other region in turn while it fails with an error that fails over.
+func route[TIn, TOut any](c *RoutingDynamoDBClient, ctx context.Context, input TIn, call func(DynamoDBClient, TIn) (TOut, error)) (TOut, error) {
+	n := len(c.Replicas) + 1
+	first := c.first()
+
+	var output TOut
+	var err error
+	for k := 0; k < n; k++ {
+		i := (first + k) % n
+		start := c.clock()
+		output, err = call(c.region(i), input)
+		latency := c.clock().Sub(start)
+		if err != nil && failoverError(err) && latency < routingPenalty {
+			latency = routingPenalty
+		}
+		c.record(i, latency)
+		if err == nil || ctx.Err() != nil || !failoverError(err) {
+			break
+		}
+	}
+
+	return output, err
+}
+
+func (c *RoutingDynamoDBClient) GetItem(ctx context.Context, input *ddb.GetItemInput, o ...func(*ddb.Options)) (*ddb.GetItemOutput, error) {
+	if aws.ToBool(input.ConsistentRead) {
+		return c.DynamoDBClient.GetItem(ctx, input, o...)
+	}
+
+	return route(c, ctx, input, func(client DynamoDBClient, input *ddb.GetItemInput) (*ddb.GetItemOutput, error) {
+		return client.GetItem(ctx, input, o...)
+	})
+}
+
+func (c *RoutingDynamoDBClient) Query(ctx context.Context, input *ddb.QueryInput, o ...func(*ddb.Options)) (*ddb.QueryOutput, error) {
+	if aws.ToBool(input.ConsistentRead) {
+		return c.DynamoDBClient.Query(ctx, input, o...)
+	}
+
+	return route(c, ctx, input, func(client DynamoDBClient, input *ddb.QueryInput) (*ddb.QueryOutput, error) {
+		return client.Query(ctx, input, o...)
+	})
+}
+
+func (c *RoutingDynamoDBClient) Scan(ctx context.Context, input *ddb.ScanInput, o ...func(*ddb.Options)) (*ddb.ScanOutput, error) {
+	if aws.ToBool(input.ConsistentRead) {
+		return c.DynamoDBClient.Scan(ctx, input, o...)
+	}
+
+	return route(c, ctx, input, func(client DynamoDBClient, input *ddb.ScanInput) (*ddb.ScanOutput, error) {
+		return client.Scan(ctx, input, o...)
+	})
+}
+
+func (c *RoutingDynamoDBClient) BatchGetItem(ctx context.Context, input *ddb.BatchGetItemInput, o ...func(*ddb.Options)) (*ddb.BatchGetItemOutput, error) {
+	for _, ka := range input.RequestItems {
+		if aws.ToBool(ka.ConsistentRead) {
+			return c.DynamoDBClient.BatchGetItem(ctx, input, o...)
+		}
+	}
+
+	return route(c, ctx, input, func(client DynamoDBClient, input *ddb.BatchGetItemInput) (*ddb.BatchGetItemOutput, error) {
+		return client.BatchGetItem(ctx, input, o...)
+	})
+}
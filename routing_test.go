@@ -0,0 +1,115 @@
+package ddbretry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry/ddbretrytest"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoutingDynamoDBClient(t *testing.T) {
+	tests := []struct {
+		name           string
+		strategy       RoutingStrategy
+		throttled      []int
+		calls          int
+		consistentRead bool
+		wantCalls      []int
+	}{
+		{
+			name:      "should spread reads round-robin",
+			strategy:  RoundRobinRouting,
+			calls:     6,
+			wantCalls: []int{2, 2, 2},
+		},
+		{
+			name:      "should send throttled reads to the next region",
+			strategy:  RoundRobinRouting,
+			throttled: []int{0, 1, 0},
+			calls:     3,
+			wantCalls: []int{1, 1, 2},
+		},
+		{
+			name:           "should send strongly consistent reads to the home region",
+			strategy:       RoundRobinRouting,
+			calls:          3,
+			consistentRead: true,
+			wantCalls:      []int{3, 0, 0},
+		},
+		{
+			name:      "should steer reads away from a throttled region by latency",
+			strategy:  LatencyRouting,
+			throttled: []int{1, 0, 0},
+			calls:     3,
+			wantCalls: []int{1, 3, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			regions := make([]*ddbretrytest.FakeClient, 3)
+			for i := range regions {
+				regions[i] = ddbretrytest.NewFakeClient()
+				if len(tt.throttled) > 0 {
+					regions[i].Script("GetItem", ddbretrytest.Throttles(tt.throttled[i])...)
+				}
+			}
+			c := NewRoutingDynamoDBClient(regions[0], tt.strategy, regions[1], regions[2])
+			c.Clock = ddbretrytest.NewFakeClock(time.Unix(1700000000, 0))
+
+			for i := 0; i < tt.calls; i++ {
+				_, err := c.GetItem(context.Background(), &ddb.GetItemInput{
+					TableName:      aws.String("users"),
+					ConsistentRead: aws.Bool(tt.consistentRead),
+				})
+				assert.NoError(t, err)
+			}
+			for i, want := range tt.wantCalls {
+				assert.Equal(t, want, regions[i].CallCount("GetItem"), "region %d", i)
+			}
+		})
+	}
+}
+
+func TestRoutingDynamoDBClient_latencyRecovery(t *testing.T) {
+	home, replica := ddbretrytest.NewFakeClient(), ddbretrytest.NewFakeClient()
+	home.Script("GetItem", ddbretrytest.Throttles(1)...)
+	clock := ddbretrytest.NewFakeClock(time.Unix(1700000000, 0))
+	c := NewRoutingDynamoDBClient(home, LatencyRouting, replica)
+	c.Clock = clock
+
+	get := func() {
+		_, err := c.GetItem(context.Background(), &ddb.GetItemInput{TableName: aws.String("users")})
+		assert.NoError(t, err)
+	}
+
+	get()
+	get()
+	assert.Equal(t, 1, home.CallCount("GetItem"))
+	assert.Equal(t, 2, replica.CallCount("GetItem"))
+
+	clock.Advance(routingExpiry / 2)
+	get()
+	assert.Equal(t, 1, home.CallCount("GetItem"))
+
+	clock.Advance(routingExpiry)
+	get()
+	get()
+	assert.Equal(t, 3, home.CallCount("GetItem"))
+	assert.Equal(t, 3, replica.CallCount("GetItem"))
+}
+
+func TestRoutingDynamoDBClient_writes(t *testing.T) {
+	home, replica := ddbretrytest.NewFakeClient(), ddbretrytest.NewFakeClient()
+	c := NewRoutingDynamoDBClient(home, RoundRobinRouting, replica)
+
+	for i := 0; i < 2; i++ {
+		_, err := c.PutItem(context.Background(), &ddb.PutItemInput{})
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 2, home.CallCount("PutItem"))
+	assert.Equal(t, 0, replica.CallCount("PutItem"))
+}
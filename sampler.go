@@ -0,0 +1,60 @@
+package ddbretry
+
+import (
+	"sync"
+	"time"
+)
+
+// LogSampler limits the per-attempt records a client logs for each table to
+// burst per interval, so a throttled table cannot flood the log. Records over
+// the limit are dropped and counted, and the count is logged as a summary
+// line with the next record allowed for the table.
+type LogSampler struct {
+	mu sync.Mutex
+	clockSource
+	burst    int
+	interval time.Duration
+
+	windows map[string]*logWindow
+}
+
+type logWindow struct {
+	start      time.Time
+	logged     int
+	suppressed int
+}
+
+func NewLogSampler(burst int, interval time.Duration) *LogSampler {
+	return &LogSampler{
+		burst:    burst,
+		interval: interval,
+		windows:  map[string]*logWindow{},
+	}
+}
+
+// allow reports whether a record for table may be logged, along with the
+// number of records suppressed for it in earlier intervals that have not been
+// reported yet.
+func (s *LogSampler) allow(table string, clock Clock) (ok bool, suppressed int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.use(clock)
+	now := s.now()
+	w, ok := s.windows[table]
+	if !ok {
+		w = &logWindow{start: now}
+		s.windows[table] = w
+	}
+	if now.Sub(w.start) >= s.interval {
+		suppressed = w.suppressed
+		*w = logWindow{start: now}
+	}
+	if w.logged >= s.burst {
+		w.suppressed++
+		return false, 0
+	}
+	w.logged++
+
+	return true, suppressed
+}
@@ -0,0 +1,57 @@
+package ddbretry
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// DisableSDKRetries is a DynamoDB client option that turns off the SDK's own
+// retryer, so that each attempt made by RetryDynamoDBClient is sent exactly
+// once.
+func DisableSDKRetries(o *ddb.Options) {
+	o.Retryer = aws.NopRetryer{}
+}
+
+// NewRetryDynamoDBClientFromSDK wraps a copy of client with the SDK's retries
+// disabled. Wrapping a *dynamodb.Client as-is multiplies attempts, as the SDK
+// retries throttled requests up to three times before RetryDynamoDBClient
+// ever sees the error.
+func NewRetryDynamoDBClientFromSDK(client *ddb.Client, retries int, backOff time.Duration) (*RetryDynamoDBClient, error) {
+	return NewRetryDynamoDBClient(ddb.New(client.Options(), DisableSDKRetries), retries, backOff)
+}
+
+// NewFromAWSConfig creates a *dynamodb.Client from cfg with the SDK's retries
+// disabled and wraps it, applying opts in order. With no options the client
+// retries DefaultRetries times, backing off by DefaultBackOffTime.
+func NewFromAWSConfig(cfg aws.Config, opts ...Option) (*RetryDynamoDBClient, error) {
+	c := &RetryDynamoDBClient{
+		DynamoDBClient: ddb.NewFromConfig(cfg, DisableSDKRetries),
+	}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+	c.freeze()
+
+	return c, nil
+}
+
+// SDKMaxAttempts reports how many attempts the wrapped client makes for each
+// call it is given. It is 1 unless the wrapped client is a *dynamodb.Client
+// with the SDK's retryer enabled, and 0 if that retryer retries indefinitely.
+func (c *RetryDynamoDBClient) SDKMaxAttempts() int {
+	client, ok := c.DynamoDBClient.(*ddb.Client)
+	if !ok {
+		return 1
+	}
+
+	retryer := client.Options().Retryer
+	if retryer == nil {
+		return 1
+	}
+
+	return retryer.MaxAttempts()
+}
@@ -0,0 +1,70 @@
+package ddbretry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry/ddbretrytest"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryDynamoDBClient_SDKMaxAttempts(t *testing.T) {
+	sdkClient := ddb.New(ddb.Options{Region: "us-east-1"})
+	wrapped, err := NewRetryDynamoDBClient(sdkClient, 3, time.Second)
+	assert.NoError(t, err)
+	withoutSDKRetries, err := NewRetryDynamoDBClientFromSDK(sdkClient, 3, time.Second)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		client *RetryDynamoDBClient
+		want   int
+	}{
+		{
+			name:   "should report SDK retries for a wrapped SDK client",
+			client: wrapped,
+			want:   3,
+		},
+		{
+			name:   "should report a single attempt when SDK retries are disabled",
+			client: withoutSDKRetries,
+			want:   1,
+		},
+		{
+			name:   "should report a single attempt for other clients",
+			client: &RetryDynamoDBClient{DynamoDBClient: ddbretrytest.NewFakeClient()},
+			want:   1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.client.SDKMaxAttempts())
+		})
+	}
+}
+
+func TestNewFromAWSConfig(t *testing.T) {
+	cfg := aws.Config{Region: "us-east-1"}
+
+	c, err := NewFromAWSConfig(cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, c.SDKMaxAttempts())
+	assert.Equal(t, DefaultRetries, c.policy().Retries)
+
+	c, err = NewFromAWSConfig(cfg, WithConfig(Config{MaxBackOff: time.Minute}), WithRetries(5), WithBackOff(time.Second), WithBackOffStrategy(FullJitterBackOff))
+	assert.NoError(t, err)
+	assert.Equal(t, 5, c.Retries)
+	assert.Equal(t, time.Second, c.BackOffTime)
+	assert.Equal(t, time.Minute, c.MaxBackOff)
+	assert.Equal(t, FullJitterBackOff, c.BackOffStrategy)
+
+	c, err = NewFromAWSConfig(cfg, WithRetries(0))
+	assert.NoError(t, err)
+	assert.True(t, c.DisableRetries)
+
+	c, err = NewFromAWSConfig(cfg, WithBackOff(-time.Second))
+	assert.Nil(t, c)
+	assert.True(t, IsInvalidBackOffError(err))
+}
@@ -0,0 +1,27 @@
+package ddbretry
+
+import (
+	"time"
+)
+
+// shadowRetry reports a retry that Shadow suppressed, with the decision and
+// delay it would have been made with.
+func (s *retryState) shadowRetry(attempt int, err error, decision RetryDecision, delay time.Duration) {
+	if l := s.client.Logger; l != nil {
+		l.Debug("would retry DynamoDB request",
+			"operation", s.op,
+			"table", s.table,
+			"attempt", attempt,
+			"delay", delay,
+			"long_backoff", decision == RetryWithLongBackOff,
+			"error_code", errorCode(err),
+			"request_id", RequestID(err),
+		)
+	}
+	if f := s.client.OnShadowRetry; f != nil {
+		f(s.op, s.table, attempt, err, decision, delay)
+	}
+	if m, ok := s.client.Metrics.(ShadowMetricsRecorder); ok {
+		m.RecordShadowRetry(s.op, s.table, attempt, delay)
+	}
+}
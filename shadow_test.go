@@ -0,0 +1,51 @@
+package ddbretry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry/ddbretrytest"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryDynamoDBClient_Shadow(t *testing.T) {
+	type shadowRetry struct {
+		attempt  int
+		decision RetryDecision
+		delay    time.Duration
+	}
+	var shadowed []shadowRetry
+	metrics := &recordingMetricsRecorder{}
+	client := ddbretrytest.NewFakeClient().Script("GetItem", ddbretrytest.Throttled())
+	c := &RetryDynamoDBClient{
+		DynamoDBClient: client,
+		Retries:        3,
+		BackOffTime:    time.Second,
+		Shadow:         true,
+		Metrics:        metrics,
+		OnShadowRetry: func(op string, table string, attempt int, err error, decision RetryDecision, delay time.Duration) {
+			assert.Equal(t, "GetItem", op)
+			assert.True(t, IsProvisionedThroughputExceededException(err))
+			shadowed = append(shadowed, shadowRetry{attempt, decision, delay})
+		},
+	}
+
+	start := time.Now()
+	_, err := c.GetItem(context.Background(), &ddb.GetItemInput{TableName: aws.String("users")})
+	assert.True(t, IsProvisionedThroughputExceededException(err))
+	assert.Less(t, time.Since(start), time.Second)
+
+	assert.Equal(t, []shadowRetry{{1, Retry, time.Second}}, shadowed)
+	assert.Equal(t, []string{
+		"attempt GetItem users false",
+		"throttle GetItem users",
+		"shadow retry GetItem users 1 1s",
+		"failure GetItem users 1",
+	}, metrics.calls)
+	stats := c.Stats()
+	assert.Equal(t, int64(0), stats.Retries)
+	assert.Equal(t, int64(1), stats.Failures)
+}
@@ -0,0 +1,146 @@
+package ddbretry
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// MaxSimulatedAttempts bounds the attempts of a simulation, so that
+// simulating a policy with InfiniteRetries against a scenario that never
+// ends still returns.
+const MaxSimulatedAttempts = 10000
+
+// ThrottleScenario describes how DynamoDB answers the attempts of a simulated
+// call. Attempts fail with Err, or with a ProvisionedThroughputExceededException
+// if Err is nil, while any of the following hold: the attempt is among the
+// first ThrottledAttempts, every attempt being throttled if ThrottledAttempts
+// is negative, or it starts within ThrottledFor of the call. Every attempt
+// takes Latency, bounded by the policy's AttemptTimeout.
+type ThrottleScenario struct {
+	ThrottledAttempts int
+	ThrottledFor      time.Duration
+	Latency           time.Duration
+	Err               error
+}
+
+// SimulatedAttempt is an attempt of a simulated call. Start is measured from
+// the start of the call, and BackOff is the delay applied after the attempt,
+// if another follows it.
+type SimulatedAttempt struct {
+	Attempt  int
+	Start    time.Duration
+	Duration time.Duration
+	Err      error
+	BackOff  time.Duration
+}
+
+// Simulation is the timeline of a simulated call. Elapsed is the time the
+// call took, and AddedLatency the time it took beyond a single attempt that
+// succeeds at once. Err is the error the call failed with, or nil if it
+// succeeded.
+type Simulation struct {
+	Attempts     []SimulatedAttempt
+	Elapsed      time.Duration
+	AddedLatency time.Duration
+	Err          error
+}
+
+// Simulate computes the attempts the policy makes for a call under scenario,
+// without sending any, to judge the latency a policy change adds before
+// deploying it. Jittered delays are drawn from the policy's Rand, which can be
+// seeded to make simulations reproducible; WorstCase draws them at their
+// longest instead. The simulation does not take the client-level limits of a
+// RetryDynamoDBClient, such as retry budgets and circuit breakers, into
+// account.
+func (p RetryPolicy) Simulate(scenario ThrottleScenario) Simulation {
+	var sim Simulation
+	var elapsed, delay time.Duration
+	for attempt := 1; ; attempt++ {
+		a := SimulatedAttempt{
+			Attempt:  attempt,
+			Start:    elapsed,
+			Duration: scenario.Latency,
+		}
+		if scenario.throttled(attempt, elapsed) {
+			a.Err = scenario.err()
+		}
+		if p.AttemptTimeout > 0 && a.Duration > p.AttemptTimeout {
+			a.Duration, a.Err = p.AttemptTimeout, context.DeadlineExceeded
+		}
+		elapsed += a.Duration
+		sim.Attempts = append(sim.Attempts, a)
+
+		if sim.Err = p.simulatedRetry(attempt, elapsed, &delay, a.Err); sim.Err != nil || a.Err == nil {
+			break
+		}
+		sim.Attempts[len(sim.Attempts)-1].BackOff = delay
+		elapsed += delay
+	}
+
+	sim.Elapsed = elapsed
+	sim.AddedLatency = elapsed - scenario.Latency
+	if sim.AddedLatency < 0 {
+		sim.AddedLatency = 0
+	}
+
+	return sim
+}
+
+// WorstCase simulates a call under scenario as Simulate does, with every
+// jittered delay as long as it can be, giving the most latency the policy
+// can add to it.
+func (p RetryPolicy) WorstCase(scenario ThrottleScenario) Simulation {
+	p.Rand = maxRand()
+
+	return p.Simulate(scenario)
+}
+
+// simulatedRetry decides whether a simulated attempt that failed with err,
+// elapsed into the call, is retried, setting delay to the delay before the
+// next attempt if it is. It returns err if the call gives up, and nil
+// otherwise.
+func (p RetryPolicy) simulatedRetry(attempt int, elapsed time.Duration, delay *time.Duration, err error) error {
+	if err == nil {
+		return nil
+	}
+	if attempt >= MaxSimulatedAttempts {
+		return err
+	}
+
+	decision := Retry
+	if p.AttemptTimeout <= 0 || !errors.Is(err, context.DeadlineExceeded) {
+		decision = p.Classify(err)
+	}
+	if decision == DoNotRetry || (p.Retries != InfiniteRetries && attempt > p.Retries) {
+		return err
+	}
+
+	next := p.backOff(attempt, *delay, err)
+	if decision == RetryWithLongBackOff {
+		next = saturatingMul(next, longBackOffMultiplier)
+	}
+	if p.MaxElapsedTime > 0 && elapsed+next > p.MaxElapsedTime {
+		return err
+	}
+	*delay = next
+
+	return nil
+}
+
+func (s ThrottleScenario) throttled(attempt int, elapsed time.Duration) bool {
+	return s.ThrottledAttempts < 0 || attempt <= s.ThrottledAttempts || elapsed < s.ThrottledFor
+}
+
+func (s ThrottleScenario) err() error {
+	if s.Err != nil {
+		return s.Err
+	}
+
+	return &types.ProvisionedThroughputExceededException{
+		Message: aws.String("simulated throttle"),
+	}
+}
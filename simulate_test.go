@@ -0,0 +1,127 @@
+package ddbretry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicy_Simulate(t *testing.T) {
+	tests := []struct {
+		name             string
+		policy           RetryPolicy
+		scenario         ThrottleScenario
+		wantStarts       []time.Duration
+		wantElapsed      time.Duration
+		wantAddedLatency time.Duration
+		wantErr          bool
+	}{
+		{
+			name:             "should succeed at once without throttling",
+			policy:           RetryPolicy{Retries: 3, BackOffTime: 100 * time.Millisecond},
+			scenario:         ThrottleScenario{Latency: 10 * time.Millisecond},
+			wantStarts:       []time.Duration{0},
+			wantElapsed:      10 * time.Millisecond,
+			wantAddedLatency: 0,
+		},
+		{
+			name:             "should back off between throttled attempts",
+			policy:           RetryPolicy{Retries: 3, BackOffTime: 100 * time.Millisecond, BackOffStrategy: ExponentialBackOff},
+			scenario:         ThrottleScenario{ThrottledAttempts: 2, Latency: 10 * time.Millisecond},
+			wantStarts:       []time.Duration{0, 110 * time.Millisecond, 320 * time.Millisecond},
+			wantElapsed:      330 * time.Millisecond,
+			wantAddedLatency: 320 * time.Millisecond,
+		},
+		{
+			name:             "should give up once retries run out",
+			policy:           RetryPolicy{Retries: 2, BackOffTime: 100 * time.Millisecond},
+			scenario:         ThrottleScenario{ThrottledAttempts: -1, Latency: 10 * time.Millisecond},
+			wantStarts:       []time.Duration{0, 110 * time.Millisecond, 220 * time.Millisecond},
+			wantElapsed:      230 * time.Millisecond,
+			wantAddedLatency: 220 * time.Millisecond,
+			wantErr:          true,
+		},
+		{
+			name:             "should retry until throttling ends",
+			policy:           RetryPolicy{Retries: InfiniteRetries, BackOffTime: 100 * time.Millisecond},
+			scenario:         ThrottleScenario{ThrottledFor: 250 * time.Millisecond},
+			wantStarts:       []time.Duration{0, 100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond},
+			wantElapsed:      300 * time.Millisecond,
+			wantAddedLatency: 300 * time.Millisecond,
+		},
+		{
+			name:             "should give up rather than exceed MaxElapsedTime",
+			policy:           RetryPolicy{Retries: 5, BackOffTime: 100 * time.Millisecond, MaxElapsedTime: 250 * time.Millisecond},
+			scenario:         ThrottleScenario{ThrottledAttempts: -1},
+			wantStarts:       []time.Duration{0, 100 * time.Millisecond, 200 * time.Millisecond},
+			wantElapsed:      200 * time.Millisecond,
+			wantAddedLatency: 200 * time.Millisecond,
+			wantErr:          true,
+		},
+		{
+			name:             "should retry attempts that time out",
+			policy:           RetryPolicy{Retries: 1, BackOffTime: 100 * time.Millisecond, AttemptTimeout: 50 * time.Millisecond},
+			scenario:         ThrottleScenario{Latency: time.Second},
+			wantStarts:       []time.Duration{0, 150 * time.Millisecond},
+			wantElapsed:      200 * time.Millisecond,
+			wantAddedLatency: 0,
+			wantErr:          true,
+		},
+		{
+			name:             "should not retry errors the policy does not retry",
+			policy:           RetryPolicy{Retries: 3, BackOffTime: 100 * time.Millisecond},
+			scenario:         ThrottleScenario{ThrottledAttempts: 1, Err: &types.ConditionalCheckFailedException{}},
+			wantStarts:       []time.Duration{0},
+			wantElapsed:      0,
+			wantAddedLatency: 0,
+			wantErr:          true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sim := tt.policy.Simulate(tt.scenario)
+
+			var starts []time.Duration
+			for _, a := range sim.Attempts {
+				starts = append(starts, a.Start)
+			}
+			assert.Equal(t, tt.wantStarts, starts)
+			assert.Equal(t, tt.wantElapsed, sim.Elapsed)
+			assert.Equal(t, tt.wantAddedLatency, sim.AddedLatency)
+			assert.Equal(t, tt.wantErr, sim.Err != nil)
+			assert.Zero(t, sim.Attempts[len(sim.Attempts)-1].BackOff)
+		})
+	}
+}
+
+func TestRetryPolicy_Simulate_timeout(t *testing.T) {
+	p := RetryPolicy{Retries: 1, AttemptTimeout: 50 * time.Millisecond}
+	sim := p.Simulate(ThrottleScenario{Latency: time.Second})
+
+	assert.ErrorIs(t, sim.Err, context.DeadlineExceeded)
+	assert.Equal(t, 50*time.Millisecond, sim.Attempts[0].Duration)
+}
+
+func TestRetryPolicy_WorstCase(t *testing.T) {
+	p := RetryPolicy{Retries: 3, BackOffTime: 100 * time.Millisecond, BackOffStrategy: FullJitterBackOff, MaxBackOff: 300 * time.Millisecond}
+	scenario := ThrottleScenario{ThrottledAttempts: -1}
+
+	worst := p.WorstCase(scenario)
+	assert.Equal(t, 600*time.Millisecond, worst.AddedLatency)
+	assert.Len(t, worst.Attempts, 4)
+
+	p.Rand = NewSeededRand(1)
+	for i := 0; i < 100; i++ {
+		assert.LessOrEqual(t, p.Simulate(scenario).AddedLatency, worst.AddedLatency)
+	}
+	assert.Equal(t, seededPolicy(p).Simulate(scenario), seededPolicy(p).Simulate(scenario))
+}
+
+// seededPolicy returns p drawing jitter from a freshly seeded Rand.
+func seededPolicy(p RetryPolicy) RetryPolicy {
+	p.Rand = NewSeededRand(42)
+	return p
+}
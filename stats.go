@@ -0,0 +1,129 @@
+package ddbretry
+
+import (
+	"expvar"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stats holds the client's counters since it was created.
+type Stats struct {
+	Attempts  int64
+	Retries   int64
+	Throttles int64
+	Successes int64
+	Failures  int64
+	// BackOff is the total time spent sleeping between attempts, and
+	// BackOffBuckets its distribution.
+	BackOff        time.Duration
+	BackOffBuckets []BackOffBucket
+}
+
+// BackOffBucket counts the backoff sleeps longer than the previous bucket's
+// UpperBound and no longer than its own.
+type BackOffBucket struct {
+	UpperBound time.Duration
+	Count      int64
+}
+
+// backOffBounds are the upper bounds of the backoff histogram buckets, with a
+// final bucket for longer sleeps.
+var backOffBounds = [...]time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+	maxDuration,
+}
+
+// stats holds the counters behind Stats, updated atomically.
+type stats struct {
+	attempts  int64
+	retries   int64
+	throttles int64
+	successes int64
+	failures  int64
+	backOff   int64
+	buckets   [len(backOffBounds)]int64
+}
+
+// recordBackOff adds a sleep of d to the backoff histogram.
+func (s *stats) recordBackOff(d time.Duration) {
+	atomic.AddInt64(&s.backOff, int64(d))
+	for i, bound := range backOffBounds {
+		if d <= bound {
+			atomic.AddInt64(&s.buckets[i], 1)
+			return
+		}
+	}
+}
+
+// Stats returns a snapshot of the client's counters.
+func (c *RetryDynamoDBClient) Stats() Stats {
+	buckets := make([]BackOffBucket, len(backOffBounds))
+	for i, bound := range backOffBounds {
+		buckets[i] = BackOffBucket{
+			UpperBound: bound,
+			Count:      atomic.LoadInt64(&c.stats.buckets[i]),
+		}
+	}
+
+	return Stats{
+		Attempts:       atomic.LoadInt64(&c.stats.attempts),
+		Retries:        atomic.LoadInt64(&c.stats.retries),
+		Throttles:      atomic.LoadInt64(&c.stats.throttles),
+		Successes:      atomic.LoadInt64(&c.stats.successes),
+		Failures:       atomic.LoadInt64(&c.stats.failures),
+		BackOff:        time.Duration(atomic.LoadInt64(&c.stats.backOff)),
+		BackOffBuckets: buckets,
+	}
+}
+
+// expvarMu serializes PublishExpvar, so that two clients cannot both find a
+// name free and then collide publishing it.
+var expvarMu sync.Mutex
+
+// PublishExpvar publishes each of the client's Stats through expvar under
+// prefix, as prefix.attempts, prefix.retries, prefix.throttles,
+// prefix.successes, prefix.failures, prefix.backoff, the total backoff in
+// nanoseconds, and prefix.backoff_buckets, so that they are served on
+// /debug/vars. It returns an error, publishing nothing, if any of the names
+// is already in use.
+func (c *RetryDynamoDBClient) PublishExpvar(prefix string) error {
+	vars := map[string]func(Stats) interface{}{
+		"attempts":        func(s Stats) interface{} { return s.Attempts },
+		"retries":         func(s Stats) interface{} { return s.Retries },
+		"throttles":       func(s Stats) interface{} { return s.Throttles },
+		"successes":       func(s Stats) interface{} { return s.Successes },
+		"failures":        func(s Stats) interface{} { return s.Failures },
+		"backoff":         func(s Stats) interface{} { return int64(s.BackOff) },
+		"backoff_buckets": func(s Stats) interface{} { return s.BackOffBuckets },
+	}
+
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+
+	for name := range vars {
+		if expvar.Get(prefix+"."+name) != nil {
+			return fmt.Errorf("expvar %q is already published", prefix+"."+name)
+		}
+	}
+	for name, stat := range vars {
+		stat := stat
+		expvar.Publish(prefix+"."+name, expvar.Func(func() interface{} {
+			return stat(c.Stats())
+		}))
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package ddbretry
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry/ddbretrytest"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryDynamoDBClient_Stats(t *testing.T) {
+	c := &RetryDynamoDBClient{
+		DynamoDBClient: ddbretrytest.NewFakeClient().Script("GetItem", ddbretrytest.Throttles(4)...),
+		Retries:        2,
+		BackOffTime:    time.Millisecond,
+	}
+
+	_, err := c.GetItem(context.Background(), &ddb.GetItemInput{})
+	assert.Error(t, err)
+	_, err = c.GetItem(context.Background(), &ddb.GetItemInput{})
+	assert.NoError(t, err)
+
+	stats := c.Stats()
+	assert.Equal(t, int64(5), stats.Attempts)
+	assert.Equal(t, int64(3), stats.Retries)
+	assert.Equal(t, int64(4), stats.Throttles)
+	assert.Equal(t, int64(1), stats.Successes)
+	assert.Equal(t, int64(1), stats.Failures)
+
+	var sleeps int64
+	for _, bucket := range stats.BackOffBuckets {
+		sleeps += bucket.Count
+	}
+	assert.Equal(t, int64(3), sleeps)
+	assert.GreaterOrEqual(t, stats.BackOff, 3*time.Millisecond)
+}
+
+func TestStats_recordBackOff(t *testing.T) {
+	var s stats
+	s.recordBackOff(0)
+	s.recordBackOff(time.Millisecond)
+	s.recordBackOff(20 * time.Millisecond)
+	s.recordBackOff(time.Hour)
+
+	assert.Equal(t, int64(2), s.buckets[0])
+	assert.Equal(t, int64(1), s.buckets[3])
+	assert.Equal(t, int64(1), s.buckets[len(backOffBounds)-1])
+	assert.Equal(t, time.Hour+21*time.Millisecond, time.Duration(s.backOff))
+}
+
+// expvarPrefixes numbers the expvar prefixes used by tests, as expvar
+// cannot unpublish names between runs of -count.
+var expvarPrefixes int64
+
+func TestRetryDynamoDBClient_PublishExpvar(t *testing.T) {
+	prefix := fmt.Sprintf("ddbretry_test_%d", atomic.AddInt64(&expvarPrefixes, 1))
+	c := &RetryDynamoDBClient{
+		DynamoDBClient: ddbretrytest.NewFakeClient().Script("PutItem", ddbretrytest.Throttled()),
+		Retries:        1,
+		BackOffTime:    time.Millisecond,
+	}
+	assert.NoError(t, c.PublishExpvar(prefix))
+	assert.Error(t, c.PublishExpvar(prefix))
+
+	_, err := c.PutItem(context.Background(), &ddb.PutItemInput{})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "2", expvar.Get(prefix+".attempts").String())
+	assert.Equal(t, "1", expvar.Get(prefix+".retries").String())
+	assert.Equal(t, "1", expvar.Get(prefix+".throttles").String())
+	assert.Equal(t, "1", expvar.Get(prefix+".successes").String())
+	assert.Equal(t, "0", expvar.Get(prefix+".failures").String())
+
+	var buckets []BackOffBucket
+	assert.NoError(t, json.Unmarshal([]byte(expvar.Get(prefix+".backoff_buckets").String()), &buckets))
+	assert.Len(t, buckets, len(backOffBounds))
+}
+
+func TestRetryDynamoDBClient_PublishExpvarCollision(t *testing.T) {
+	prefix := fmt.Sprintf("ddbretry_test_%d", atomic.AddInt64(&expvarPrefixes, 1))
+	expvar.NewInt(prefix + ".retries")
+	c := &RetryDynamoDBClient{}
+
+	assert.Error(t, c.PublishExpvar(prefix))
+	assert.Nil(t, expvar.Get(prefix+".attempts"))
+}
+
+func TestRetryDynamoDBClient_PublishExpvarConcurrent(t *testing.T) {
+	prefix := fmt.Sprintf("ddbretry_test_%d", atomic.AddInt64(&expvarPrefixes, 1))
+
+	var (
+		wg        sync.WaitGroup
+		published int64
+	)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c := &RetryDynamoDBClient{}
+			if c.PublishExpvar(prefix) == nil {
+				atomic.AddInt64(&published, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(1), published)
+}
@@ -0,0 +1,108 @@
+// Package statsdmetrics sends ddbretry's retry metrics to a statsd server,
+// such as the Datadog agent, tagging each one with its operation and table in
+// the Datadog tag format.
+package statsdmetrics
+
+import (
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const DefaultPrefix = "ddbretry"
+
+// Sink is a ddbretry.MetricsRecorder writing one statsd packet per
+// measurement. Write errors are ignored, as statsd delivery is best-effort.
+type Sink struct {
+	mu     sync.Mutex
+	w      io.Writer
+	prefix string
+}
+
+// New creates a Sink sending packets over UDP to addr, such as
+// "127.0.0.1:8125".
+func New(addr string, prefix string) (*Sink, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewWithWriter(conn, prefix), nil
+}
+
+// NewWithWriter creates a Sink writing packets to w, with metric names
+// prefixed by prefix, or DefaultPrefix if prefix is empty.
+func NewWithWriter(w io.Writer, prefix string) *Sink {
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+
+	return &Sink{
+		w:      w,
+		prefix: prefix,
+	}
+}
+
+// Close closes the underlying writer, if it can be closed.
+func (s *Sink) Close() error {
+	if c, ok := s.w.(io.Closer); ok {
+		return c.Close()
+	}
+
+	return nil
+}
+
+func (s *Sink) RecordAttempt(op string, table string, latency time.Duration, err error) {
+	s.send("attempts", "1", "c", op, table)
+	s.send("attempt_latency", milliseconds(latency), "ms", op, table)
+}
+
+func (s *Sink) RecordRetry(op string, table string, attempt int, delay time.Duration) {
+	s.send("retries", "1", "c", op, table)
+	s.send("retry_delay", milliseconds(delay), "ms", op, table)
+}
+
+func (s *Sink) RecordThrottle(op string, table string) {
+	s.send("throttles", "1", "c", op, table)
+}
+
+func (s *Sink) RecordSuccess(op string, table string, attempts int) {
+	s.send("successes", "1", "c", op, table)
+}
+
+func (s *Sink) RecordFailure(op string, table string, attempts int, err error) {
+	s.send("failures", "1", "c", op, table)
+}
+
+func (s *Sink) send(name string, value string, kind string, op string, table string) {
+	var b strings.Builder
+	b.WriteString(s.prefix)
+	b.WriteByte('.')
+	b.WriteString(name)
+	b.WriteByte(':')
+	b.WriteString(value)
+	b.WriteByte('|')
+	b.WriteString(kind)
+	b.WriteString("|#operation:")
+	b.WriteString(tag(op))
+	if table != "" {
+		b.WriteString(",table:")
+		b.WriteString(tag(table))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, _ = io.WriteString(s.w, b.String())
+}
+
+// tag strips the characters that separate statsd fields and Datadog tags
+// from a tag value.
+var tag = strings.NewReplacer("|", "_", ",", "_", "#", "_", "\n", "_").Replace
+
+func milliseconds(d time.Duration) string {
+	return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', -1, 64)
+}
@@ -0,0 +1,64 @@
+package statsdmetrics
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ ddbretry.MetricsRecorder = (*Sink)(nil)
+
+// packetWriter records every write as a separate packet.
+type packetWriter struct {
+	packets []string
+}
+
+func (w *packetWriter) Write(p []byte) (int, error) {
+	w.packets = append(w.packets, string(p))
+
+	return len(p), nil
+}
+
+func TestSink(t *testing.T) {
+	w := &packetWriter{}
+	s := NewWithWriter(w, "")
+
+	s.RecordAttempt("GetItem", "users", 1500*time.Microsecond, nil)
+	s.RecordRetry("GetItem", "users", 1, 20*time.Millisecond)
+	s.RecordThrottle("PutItem", "")
+	s.RecordFailure("PutItem", "a,b", 3, nil)
+
+	assert.Equal(t, []string{
+		"ddbretry.attempts:1|c|#operation:GetItem,table:users",
+		"ddbretry.attempt_latency:1.5|ms|#operation:GetItem,table:users",
+		"ddbretry.retries:1|c|#operation:GetItem,table:users",
+		"ddbretry.retry_delay:20|ms|#operation:GetItem,table:users",
+		"ddbretry.throttles:1|c|#operation:PutItem",
+		"ddbretry.failures:1|c|#operation:PutItem,table:a_b",
+	}, w.packets)
+}
+
+func TestNew(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+
+	s, err := New(conn.LocalAddr().String(), "app")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer s.Close()
+
+	s.RecordThrottle("GetItem", "users")
+
+	buf := make([]byte, 512)
+	assert.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	n, _, err := conn.ReadFrom(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "app.throttles:1|c|#operation:GetItem,table:users", string(buf[:n]))
+}
@@ -0,0 +1,58 @@
+package ddbretry
+
+import (
+	"context"
+	"time"
+)
+
+// Tracer lets tracing systems follow calls through the retry loop. The
+// oteltrace and xraytrace packages provide implementations for OpenTelemetry
+// and AWS X-Ray.
+type Tracer interface {
+	// StartSpan is called before the first attempt of a call, returning a
+	// context carrying the span that covers the whole call.
+	StartSpan(ctx context.Context, op string, table string) (context.Context, Span)
+}
+
+// Span covers a single call, including all of its attempts and retries.
+type Span interface {
+	// StartAttempt is called before each attempt, returning the context the
+	// attempt is sent with and a function called with its result.
+	StartAttempt(ctx context.Context, attempt int) (context.Context, func(err error))
+	// RecordRetry is called before each retry with the error that caused it
+	// and the delay about to be applied.
+	RecordRetry(attempt int, err error, delay time.Duration)
+	// End is called once the call succeeds or fails for good.
+	End(attempts int, err error)
+}
+
+// startAttemptSpan starts the span for the next attempt, starting the call's
+// span first if this is the first attempt.
+func (s *retryState) startAttemptSpan(ctx context.Context) context.Context {
+	t := s.client.Tracer
+	if t == nil {
+		return ctx
+	}
+
+	if s.span == nil {
+		s.spanCtx, s.span = t.StartSpan(ctx, s.op, s.table)
+	}
+
+	ctx, s.endAttempt = s.span.StartAttempt(s.spanCtx, s.sent+1)
+
+	return ctx
+}
+
+func (s *retryState) endAttemptSpan(err error) {
+	if s.endAttempt != nil {
+		s.endAttempt(err)
+		s.endAttempt = nil
+	}
+}
+
+func (s *retryState) endSpan(err error) {
+	if s.span != nil {
+		s.span.End(s.sent, err)
+		s.span = nil
+	}
+}
@@ -0,0 +1,95 @@
+package ddbretry
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry/ddbretrytest"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+)
+
+type spanKey struct{}
+
+// recordingTracer records every tracing call as a string.
+type recordingTracer struct {
+	calls []string
+}
+
+func (r *recordingTracer) StartSpan(ctx context.Context, op string, table string) (context.Context, Span) {
+	r.calls = append(r.calls, fmt.Sprintf("start %s %s", op, table))
+
+	return context.WithValue(ctx, spanKey{}, op), &recordingSpan{tracer: r}
+}
+
+type recordingSpan struct {
+	tracer *recordingTracer
+}
+
+func (s *recordingSpan) StartAttempt(ctx context.Context, attempt int) (context.Context, func(err error)) {
+	s.tracer.calls = append(s.tracer.calls, fmt.Sprintf("attempt %d %v", attempt, ctx.Value(spanKey{})))
+
+	return ctx, func(err error) {
+		s.tracer.calls = append(s.tracer.calls, fmt.Sprintf("attempt %d done %t", attempt, err == nil))
+	}
+}
+
+func (s *recordingSpan) RecordRetry(attempt int, err error, delay time.Duration) {
+	s.tracer.calls = append(s.tracer.calls, fmt.Sprintf("retry %d", attempt))
+}
+
+func (s *recordingSpan) End(attempts int, err error) {
+	s.tracer.calls = append(s.tracer.calls, fmt.Sprintf("end %d %t", attempts, err == nil))
+}
+
+func TestRetryDynamoDBClient_Tracer(t *testing.T) {
+	tests := []struct {
+		name      string
+		throttles int
+		want      []string
+	}{
+		{
+			name:      "should trace each attempt within a span for the call",
+			throttles: 1,
+			want: []string{
+				"start GetItem users",
+				"attempt 1 GetItem",
+				"attempt 1 done false",
+				"retry 1",
+				"attempt 2 GetItem",
+				"attempt 2 done true",
+				"end 2 true",
+			},
+		},
+		{
+			name:      "should end span with error once retries are exhausted",
+			throttles: 5,
+			want: []string{
+				"start GetItem users",
+				"attempt 1 GetItem",
+				"attempt 1 done false",
+				"retry 1",
+				"attempt 2 GetItem",
+				"attempt 2 done false",
+				"end 2 false",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracer := &recordingTracer{}
+			c := &RetryDynamoDBClient{
+				DynamoDBClient: ddbretrytest.NewFakeClient().Script("GetItem", ddbretrytest.Throttles(tt.throttles)...),
+				Retries:        1,
+				BackOffTime:    time.Millisecond,
+				Tracer:         tracer,
+			}
+
+			_, _ = c.GetItem(context.Background(), &ddb.GetItemInput{TableName: aws.String("users")})
+			assert.Equal(t, tt.want, tracer.calls)
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package ddbretry
+
+import (
+	"context"
+	"sync"
+
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// FailedWrite is a write a WriteQueue gave up on: Input is the
+// *dynamodb.PutItemInput or *dynamodb.DeleteItemInput of the named
+// operation, and Err the error it failed with.
+type FailedWrite struct {
+	Operation string
+	Input     interface{}
+	Err       error
+}
+
+type queuedWrite struct {
+	put    *ddb.PutItemInput
+	delete *ddb.DeleteItemInput
+}
+
+// WriteQueue sends PutItem and DeleteItem calls from a pool of background
+// workers, for callers that prefer throughput over the latency of each
+// write. Writes are sent through the queue's client, so a RetryDynamoDBClient
+// retries them under its own settings, and each write it gives up on is
+// handed to the dead-letter function. A WriteQueue is safe for concurrent
+// use.
+type WriteQueue struct {
+	client     DynamoDBWriter
+	deadLetter func(FailedWrite)
+	writes     chan queuedWrite
+	ctx        context.Context
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+
+	// closing is closed when Close is first called, waking the Enqueue calls
+	// blocked on a full queue so that Close can take mu.
+	closing   chan struct{}
+	closeOnce sync.Once
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewWriteQueue returns a WriteQueue sending writes to client from workers
+// goroutines, buffering up to size writes before Enqueue calls block, and
+// calling deadLetter, if it is not nil, with each write that fails. Close
+// must be called to stop the workers.
+func NewWriteQueue(client DynamoDBWriter, workers int, size int, deadLetter func(FailedWrite)) *WriteQueue {
+	if workers < 1 {
+		workers = 1
+	}
+	if size < 0 {
+		size = 0
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	q := &WriteQueue{
+		client:     client,
+		deadLetter: deadLetter,
+		writes:     make(chan queuedWrite, size),
+		ctx:        ctx,
+		cancel:     cancel,
+		closing:    make(chan struct{}),
+	}
+	q.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go q.work()
+	}
+
+	return q
+}
+
+// EnqueuePut queues a PutItem call, blocking while the queue is full until
+// ctx is done or the queue is closed.
+func (q *WriteQueue) EnqueuePut(ctx context.Context, input *ddb.PutItemInput) error {
+	return q.enqueue(ctx, queuedWrite{put: input})
+}
+
+// EnqueueDelete queues a DeleteItem call, blocking while the queue is full
+// until ctx is done or the queue is closed.
+func (q *WriteQueue) EnqueueDelete(ctx context.Context, input *ddb.DeleteItemInput) error {
+	return q.enqueue(ctx, queuedWrite{delete: input})
+}
+
+func (q *WriteQueue) enqueue(ctx context.Context, w queuedWrite) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		return NewWriteQueueClosedError()
+	}
+
+	select {
+	case q.writes <- w:
+		return nil
+	case <-q.closing:
+		return NewWriteQueueClosedError()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops the queue from accepting writes and waits for the queued ones
+// to be sent. If ctx is done first, the writes still queued or being retried
+// are cancelled, handed to the dead-letter function, and Close returns the
+// context's error once the workers have stopped. Enqueue calls blocked on a
+// full queue fail with a WriteQueueClosedError.
+func (q *WriteQueue) Close(ctx context.Context) error {
+	q.closeOnce.Do(func() {
+		close(q.closing)
+	})
+
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return nil
+	}
+	q.closed = true
+	close(q.writes)
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		q.cancel()
+		return nil
+	case <-ctx.Done():
+		q.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (q *WriteQueue) work() {
+	defer q.wg.Done()
+
+	for w := range q.writes {
+		q.send(w)
+	}
+}
+
+func (q *WriteQueue) send(w queuedWrite) {
+	var failed FailedWrite
+	if w.put != nil {
+		_, err := q.client.PutItem(q.ctx, w.put)
+		failed = FailedWrite{Operation: "PutItem", Input: w.put, Err: err}
+	} else {
+		_, err := q.client.DeleteItem(q.ctx, w.delete)
+		failed = FailedWrite{Operation: "DeleteItem", Input: w.delete, Err: err}
+	}
+
+	if failed.Err != nil && q.deadLetter != nil {
+		q.deadLetter(failed)
+	}
+}
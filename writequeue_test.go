@@ -0,0 +1,134 @@
+package ddbretry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+)
+
+// tableWriter counts the writes it receives, failing those to the "bad"
+// table and blocking on release while it is not nil.
+type tableWriter struct {
+	mu      sync.Mutex
+	release chan struct{}
+	writes  int
+}
+
+func (w *tableWriter) write(ctx context.Context, table *string) error {
+	if w.release != nil {
+		select {
+		case <-w.release:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.writes++
+	if aws.ToString(table) == "bad" {
+		return errors.New("validation failed")
+	}
+
+	return nil
+}
+
+func (w *tableWriter) PutItem(ctx context.Context, input *ddb.PutItemInput, o ...func(*ddb.Options)) (*ddb.PutItemOutput, error) {
+	return &ddb.PutItemOutput{}, w.write(ctx, input.TableName)
+}
+
+func (w *tableWriter) DeleteItem(ctx context.Context, input *ddb.DeleteItemInput, o ...func(*ddb.Options)) (*ddb.DeleteItemOutput, error) {
+	return &ddb.DeleteItemOutput{}, w.write(ctx, input.TableName)
+}
+
+func (w *tableWriter) UpdateItem(ctx context.Context, input *ddb.UpdateItemInput, o ...func(*ddb.Options)) (*ddb.UpdateItemOutput, error) {
+	return &ddb.UpdateItemOutput{}, w.write(ctx, input.TableName)
+}
+
+func TestWriteQueue(t *testing.T) {
+	client := &tableWriter{}
+	var mu sync.Mutex
+	var failed []FailedWrite
+	q := NewWriteQueue(client, 4, 8, func(f FailedWrite) {
+		mu.Lock()
+		defer mu.Unlock()
+		failed = append(failed, f)
+	})
+	ctx := context.Background()
+
+	for i := 0; i < 20; i++ {
+		assert.NoError(t, q.EnqueuePut(ctx, &ddb.PutItemInput{TableName: aws.String(fmt.Sprint("table", i))}))
+	}
+	assert.NoError(t, q.EnqueueDelete(ctx, &ddb.DeleteItemInput{TableName: aws.String("bad")}))
+	assert.NoError(t, q.Close(ctx))
+
+	assert.Equal(t, 21, client.writes)
+	if assert.Len(t, failed, 1) {
+		assert.Equal(t, "DeleteItem", failed[0].Operation)
+		assert.Equal(t, "bad", aws.ToString(failed[0].Input.(*ddb.DeleteItemInput).TableName))
+		assert.EqualError(t, failed[0].Err, "validation failed")
+	}
+	assert.True(t, IsWriteQueueClosedError(q.EnqueuePut(ctx, &ddb.PutItemInput{})))
+}
+
+func TestWriteQueue_Close(t *testing.T) {
+	client := &tableWriter{release: make(chan struct{})}
+	var mu sync.Mutex
+	failed := 0
+	q := NewWriteQueue(client, 1, 4, func(f FailedWrite) {
+		mu.Lock()
+		defer mu.Unlock()
+		failed++
+		assert.ErrorIs(t, f.Err, context.Canceled)
+	})
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, q.EnqueuePut(context.Background(), &ddb.PutItemInput{}))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, q.Close(ctx), context.DeadlineExceeded)
+	assert.Equal(t, 3, failed)
+}
+
+func TestWriteQueue_CloseBlockedEnqueue(t *testing.T) {
+	client := &tableWriter{release: make(chan struct{})}
+	q := NewWriteQueue(client, 1, 1, nil)
+
+	// One write for the worker to block on and one to fill the queue.
+	assert.NoError(t, q.EnqueuePut(context.Background(), &ddb.PutItemInput{}))
+	assert.Eventually(t, func() bool {
+		return len(q.writes) == 0
+	}, time.Second, time.Millisecond)
+	assert.NoError(t, q.EnqueuePut(context.Background(), &ddb.PutItemInput{}))
+
+	enqueued := make(chan error)
+	go func() {
+		enqueued <- q.EnqueuePut(context.Background(), &ddb.PutItemInput{})
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	closed := make(chan error)
+	go func() {
+		closed <- q.Close(ctx)
+	}()
+	select {
+	case err := <-closed:
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	case <-time.After(time.Second):
+		t.Fatal("Close did not return")
+	}
+	assert.True(t, IsWriteQueueClosedError(<-enqueued))
+}
@@ -0,0 +1,112 @@
+// Package xraytrace traces ddbretry calls with AWS X-Ray, opening a
+// subsegment per call with a nested subsegment per attempt. Throttled
+// attempts are flagged and annotated so that retries show up in service maps
+// and can be searched for with filter expressions.
+//
+// Calls must be made with a context that carries an X-Ray segment, such as
+// the one Lambda provides; otherwise nothing is recorded.
+package xraytrace
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/aws/smithy-go"
+)
+
+// Tracer is a ddbretry.Tracer recording X-Ray subsegments.
+type Tracer struct{}
+
+func New() *Tracer {
+	return &Tracer{}
+}
+
+func (t *Tracer) StartSpan(ctx context.Context, op string, table string) (context.Context, ddbretry.Span) {
+	ctx, seg := xray.BeginSubsegment(ctx, "DynamoDB."+op)
+	if seg != nil {
+		_ = seg.AddAnnotation("operation", op)
+		if table != "" {
+			_ = seg.AddAnnotation("table", table)
+		}
+	}
+
+	return ctx, &Span{
+		name:    "DynamoDB." + op + ".Attempt",
+		segment: seg,
+	}
+}
+
+type Span struct {
+	name      string
+	segment   *xray.Segment
+	throttles int
+	retries   int
+}
+
+func (s *Span) StartAttempt(ctx context.Context, attempt int) (context.Context, func(err error)) {
+	if s.segment == nil {
+		return ctx, func(error) {}
+	}
+
+	ctx, seg := xray.BeginSubsegment(ctx, s.name)
+	if seg == nil {
+		return ctx, func(error) {}
+	}
+	_ = seg.AddAnnotation("attempt", attempt)
+
+	return ctx, func(err error) {
+		if ddbretry.IsThrottlingError(err) {
+			s.throttles++
+			throttle(seg)
+			_ = seg.AddAnnotation("throttled", true)
+		}
+		if code := errorCode(err); code != "" {
+			_ = seg.AddAnnotation("error_code", code)
+		}
+		seg.Close(err)
+	}
+}
+
+func (s *Span) RecordRetry(attempt int, err error, delay time.Duration) {
+	s.retries++
+	if s.segment != nil {
+		_ = s.segment.AddMetadata("retry_delay_ms", delay.Milliseconds())
+	}
+}
+
+func (s *Span) End(attempts int, err error) {
+	if s.segment == nil {
+		return
+	}
+
+	_ = s.segment.AddAnnotation("attempts", attempts)
+	_ = s.segment.AddAnnotation("retries", s.retries)
+	if s.throttles > 0 {
+		_ = s.segment.AddAnnotation("throttles", s.throttles)
+		if ddbretry.IsThrottlingError(err) {
+			throttle(s.segment)
+		}
+	}
+	s.segment.Close(err)
+}
+
+// throttle flags seg as throttled.
+func throttle(seg *xray.Segment) {
+	seg.Lock()
+	defer seg.Unlock()
+
+	seg.Throttle = true
+	seg.Error = true
+}
+
+func errorCode(err error) string {
+	var apiError smithy.APIError
+	if errors.As(err, &apiError) {
+		return apiError.ErrorCode()
+	}
+
+	return ""
+}
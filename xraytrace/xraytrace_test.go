@@ -0,0 +1,80 @@
+package xraytrace
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Thumbscrew/ddbretry"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ ddbretry.Tracer = (*Tracer)(nil)
+
+// recordingEmitter keeps emitted segments instead of sending them to the
+// X-Ray daemon.
+type recordingEmitter struct {
+	mu       sync.Mutex
+	segments []*xray.Segment
+}
+
+func (e *recordingEmitter) Emit(seg *xray.Segment) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.segments = append(e.segments, seg)
+}
+
+func (e *recordingEmitter) RefreshEmitterWithAddress(raddr *net.UDPAddr) {}
+
+func TestTracer(t *testing.T) {
+	ctx, err := xray.ContextWithConfig(context.Background(), xray.Config{
+		Emitter: &recordingEmitter{},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	ctx, root := xray.BeginSegment(ctx, "test")
+	defer root.Close(nil)
+	root.Sampled = true
+
+	tracer := New()
+	ctx, span := tracer.StartSpan(ctx, "GetItem", "users")
+	_, endAttempt := span.StartAttempt(ctx, 1)
+	endAttempt(&types.ProvisionedThroughputExceededException{})
+	span.RecordRetry(1, &types.ProvisionedThroughputExceededException{}, 20*time.Millisecond)
+	_, endAttempt = span.StartAttempt(ctx, 2)
+	endAttempt(nil)
+	span.End(2, nil)
+
+	call := span.(*Span).segment
+	assert.Equal(t, "DynamoDB.GetItem", call.Name)
+	assert.Equal(t, "users", call.Annotations["table"])
+	assert.Equal(t, 2, call.Annotations["attempts"])
+	assert.Equal(t, 1, call.Annotations["retries"])
+	assert.Equal(t, 1, call.Annotations["throttles"])
+	assert.False(t, call.Throttle)
+}
+
+func TestTracer_withoutSegment(t *testing.T) {
+	ctx, err := xray.ContextWithConfig(context.Background(), xray.Config{
+		ContextMissingStrategy: ignoreContextMissing{},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	ctx, span := New().StartSpan(ctx, "GetItem", "users")
+	_, endAttempt := span.StartAttempt(ctx, 1)
+	endAttempt(&types.ProvisionedThroughputExceededException{})
+	span.RecordRetry(1, nil, time.Millisecond)
+	span.End(1, nil)
+}
+
+type ignoreContextMissing struct{}
+
+func (ignoreContextMissing) ContextMissing(v interface{}) {}
@@ -0,0 +1,24 @@
+// Package zaplogger adapts a zap logger to the ddbretry.Logger interface.
+package zaplogger
+
+import (
+	"go.uber.org/zap"
+)
+
+type Logger struct {
+	logger *zap.SugaredLogger
+}
+
+func New(logger *zap.Logger) *Logger {
+	return &Logger{
+		logger: logger.Sugar(),
+	}
+}
+
+func (l *Logger) Debug(msg string, args ...any) {
+	l.logger.Debugw(msg, args...)
+}
+
+func (l *Logger) Warn(msg string, args ...any) {
+	l.logger.Warnw(msg, args...)
+}
@@ -0,0 +1,31 @@
+package zaplogger
+
+import (
+	"testing"
+
+	"github.com/Thumbscrew/ddbretry"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+var _ ddbretry.Logger = (*Logger)(nil)
+
+func TestLogger(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	l := New(zap.New(core))
+
+	l.Debug("retrying", "operation", "GetItem", "attempt", 1)
+	l.Warn("giving up", "operation", "PutItem")
+
+	entries := logs.AllUntimed()
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, zapcore.DebugLevel, entries[0].Level)
+		assert.Equal(t, "retrying", entries[0].Message)
+		assert.Equal(t, map[string]interface{}{"operation": "GetItem", "attempt": int64(1)}, entries[0].ContextMap())
+
+		assert.Equal(t, zapcore.WarnLevel, entries[1].Level)
+		assert.Equal(t, map[string]interface{}{"operation": "PutItem"}, entries[1].ContextMap())
+	}
+}